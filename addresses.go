@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/jaxxstorm/dnsscale/providers"
+	"go.uber.org/zap"
+)
+
+// Address families that can be published for a node
+const (
+	addressFamilyIPv4 = "ipv4"
+	addressFamilyIPv6 = "ipv6"
+	addressFamilyBoth = "both"
+)
+
+var addressFamilies = []string{addressFamilyIPv4, addressFamilyIPv6, addressFamilyBoth}
+
+// defaultRecordTTL is the TTL used for every record dnsscale publishes
+const defaultRecordTTL int64 = 300
+
+func validAddressFamily(family string) bool {
+	for _, f := range addressFamilies {
+		if family == f {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSettings holds the effective publishing settings for a node once tag
+// overrides have been applied
+type nodeSettings struct {
+	addressFamily      string
+	primaryAddressOnly bool
+	wildcard           bool
+}
+
+// settingsForNode resolves the publishing settings for a node. Tag overrides
+// are applied in configuration order, so later matching entries win.
+func (r *DNSReconciler) settingsForNode(node TailscaleNode) nodeSettings {
+	settings := r.settings
+	for _, override := range r.tagOverrides {
+		if !nodeHasTag(node, override.Tag) {
+			continue
+		}
+		if override.AddressFamily != "" {
+			settings.addressFamily = override.AddressFamily
+		}
+		if override.PrimaryAddressOnly != nil {
+			settings.primaryAddressOnly = *override.PrimaryAddressOnly
+		}
+		if override.Wildcard != nil {
+			settings.wildcard = *override.Wildcard
+		}
+	}
+	return settings
+}
+
+func nodeHasTag(node TailscaleNode, tag string) bool {
+	for _, t := range node.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNodeAddress parses an address reported by the Tailscale API. Both bare
+// addresses and single-host prefixes (100.64.0.1/32) are accepted.
+func parseNodeAddress(raw string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(raw)
+	if err != nil {
+		prefix, prefixErr := netip.ParsePrefix(raw)
+		if prefixErr != nil || !prefix.IsSingleIP() {
+			return netip.Addr{}, fmt.Errorf("invalid address %q: %w", raw, err)
+		}
+		addr = prefix.Addr()
+	}
+
+	addr = addr.Unmap()
+	if addr.Zone() != "" {
+		return netip.Addr{}, fmt.Errorf("invalid address %q: zoned addresses cannot be published", raw)
+	}
+	if addr.IsUnspecified() {
+		return netip.Addr{}, fmt.Errorf("invalid address %q: unspecified address", raw)
+	}
+	return addr, nil
+}
+
+// addressRecordType returns the record type used to publish addr
+func addressRecordType(addr netip.Addr) string {
+	if addr.Is4() {
+		return "A"
+	}
+	return "AAAA"
+}
+
+func familyAllowed(family string, addr netip.Addr) bool {
+	switch family {
+	case addressFamilyIPv4:
+		return addr.Is4()
+	case addressFamilyIPv6:
+		return addr.Is6()
+	default:
+		return true
+	}
+}
+
+// addressRecords builds the A and AAAA records to publish under name for a
+// node. Malformed addresses are logged and skipped rather than being sent to
+// the DNS provider.
+func (r *DNSReconciler) addressRecords(node TailscaleNode, name string, settings nodeSettings) []providers.DNSRecord {
+	var records []providers.DNSRecord
+	published := make(map[string]bool)
+
+	for _, raw := range node.Addresses {
+		addr, err := parseNodeAddress(raw)
+		if err != nil {
+			r.logger.Warn("Skipping malformed node address",
+				zap.String("node_name", node.Name),
+				zap.String("address", raw),
+				zap.Error(err))
+			continue
+		}
+
+		if !familyAllowed(settings.addressFamily, addr) {
+			continue
+		}
+
+		recordType := addressRecordType(addr)
+		if settings.primaryAddressOnly && published[recordType] {
+			continue
+		}
+		published[recordType] = true
+
+		records = append(records, providers.DNSRecord{
+			Name:  name,
+			Type:  recordType,
+			Value: addr.String(),
+			TTL:   defaultRecordTTL,
+		})
+	}
+
+	return records
+}
+
+// recordNamesEqual compares record names ignoring case and any trailing dot,
+// since providers differ in how they return fully qualified names
+func recordNamesEqual(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// recordValueKey normalises a record value so that equivalent values written
+// in different forms compare equal
+func recordValueKey(record providers.DNSRecord) string {
+	if record.Type == "A" || record.Type == "AAAA" {
+		if addr, err := netip.ParseAddr(record.Value); err == nil {
+			return addr.Unmap().String()
+		}
+	}
+	if record.Type == "SRV" {
+		// Targets may come back with or without the trailing dot
+		target := strings.ToLower(strings.TrimSuffix(record.Value, "."))
+		return fmt.Sprintf("%d %d %d %s", record.Priority, record.Weight, record.Port, target)
+	}
+	return record.Value
+}
@@ -47,19 +47,52 @@ func init() {
 	rootCmd.PersistentFlags().String("tailscale-tailnet", "", "Tailscale tailnet name")
 
 	// DNS flags
-	rootCmd.PersistentFlags().String("dns-provider", "", "DNS provider (route53 or cloudflare)")
+	rootCmd.PersistentFlags().String("dns-provider", "", "DNS provider (route53, cloudflare, gcloud, azure, digitalocean, rfc2136, powerdns, hetzner, zonefile, hosts, pihole, adguardhome, infoblox, ns1, dnsimple, gandi, technitium, etcd, coredns, routeros, builtin or exec)")
 	rootCmd.PersistentFlags().String("dns-domain", "", "DNS domain to manage")
-	rootCmd.PersistentFlags().String("dns-zone-id", "", "DNS zone ID")
+	rootCmd.PersistentFlags().String("dns-zone-id", "", "DNS zone ID (needed for route53, cloudflare and gcloud)")
 
 	// Provider-specific flags
 	rootCmd.PersistentFlags().String("cloudflare-api-token", "", "Cloudflare API token")
 	rootCmd.PersistentFlags().String("route53-profile", "", "AWS profile to use")
 	rootCmd.PersistentFlags().String("route53-region", "", "AWS region")
+	rootCmd.PersistentFlags().String("gcloud-project", "", "Google Cloud project containing the managed zone")
+	rootCmd.PersistentFlags().String("gcloud-credentials-file", "", "Google Cloud service account JSON key")
+	rootCmd.PersistentFlags().String("azure-subscription-id", "", "Azure subscription ID")
+	rootCmd.PersistentFlags().String("azure-resource-group", "", "Azure resource group containing the DNS zone")
+	rootCmd.PersistentFlags().String("digitalocean-api-token", "", "DigitalOcean API token")
+	rootCmd.PersistentFlags().String("digitalocean-domain", "", "DigitalOcean domain holding the records (defaults to --dns-domain)")
+	rootCmd.PersistentFlags().String("rfc2136-server", "", "Primary name server accepting dynamic updates (host:port)")
+	rootCmd.PersistentFlags().String("rfc2136-tsig-key-name", "", "TSIG key name used to sign updates")
+	rootCmd.PersistentFlags().String("powerdns-api-url", "", "PowerDNS API URL (e.g. http://127.0.0.1:8081)")
+	rootCmd.PersistentFlags().String("powerdns-api-key", "", "PowerDNS API key")
+	rootCmd.PersistentFlags().String("hetzner-api-token", "", "Hetzner DNS API token")
+	rootCmd.PersistentFlags().String("zonefile-path", "", "Zone file to write records to")
+	rootCmd.PersistentFlags().String("hosts-path", "", "Hosts file to write records to")
+	rootCmd.PersistentFlags().String("pihole-url", "", "Pi-hole URL (e.g. http://pi.hole)")
+	rootCmd.PersistentFlags().String("adguardhome-url", "", "AdGuard Home URL (e.g. http://127.0.0.1:3000)")
+	rootCmd.PersistentFlags().String("infoblox-url", "", "Infoblox Grid Master URL (e.g. https://gm.example.com)")
+	rootCmd.PersistentFlags().String("infoblox-view", "", "Infoblox DNS view")
+	rootCmd.PersistentFlags().String("ns1-api-key", "", "NS1 API key")
+	rootCmd.PersistentFlags().String("dnsimple-api-token", "", "DNSimple API access token")
+	rootCmd.PersistentFlags().String("dnsimple-account-id", "", "DNSimple account ID")
+	rootCmd.PersistentFlags().String("gandi-personal-access-token", "", "Gandi personal access token")
+	rootCmd.PersistentFlags().String("gandi-domain", "", "Gandi LiveDNS domain holding the records (defaults to --dns-domain)")
+	rootCmd.PersistentFlags().String("technitium-url", "", "Technitium DNS Server URL (e.g. http://127.0.0.1:5380)")
+	rootCmd.PersistentFlags().String("technitium-api-token", "", "Technitium DNS Server API token")
+	rootCmd.PersistentFlags().StringSlice("etcd-endpoints", []string{}, "etcd endpoints used by the CoreDNS etcd plugin")
+	rootCmd.PersistentFlags().String("coredns-kubeconfig", "", "Kubeconfig for the cluster running CoreDNS (in-cluster configuration if empty)")
+	rootCmd.PersistentFlags().String("coredns-configmap", "", "ConfigMap holding the CoreDNS hosts block")
+	rootCmd.PersistentFlags().String("routeros-url", "", "RouterOS REST API URL (e.g. https://192.168.88.1)")
+	rootCmd.PersistentFlags().StringSlice("builtin-listen", []string{}, "Addresses the built-in DNS server listens on (default :53)")
+	rootCmd.PersistentFlags().String("exec-command", "", "Provider plugin executable speaking the exec JSON protocol")
 
 	// App flags
 	rootCmd.PersistentFlags().Int("workers", 2, "Number of worker goroutines")
 	rootCmd.PersistentFlags().Duration("poll-interval", 0, "Interval to poll Tailscale API (e.g., 30s, 1m)")
 	rootCmd.PersistentFlags().StringSlice("required-tags", []string{}, "Only manage nodes with these tags")
+	rootCmd.PersistentFlags().String("address-family", "", "Address families to publish (ipv4, ipv6 or both)")
+	rootCmd.PersistentFlags().Bool("primary-address-only", false, "Only publish the first address of each family")
+	rootCmd.PersistentFlags().Bool("wildcard", false, "Also publish *.<node>.<domain> records for every node")
 
 	// Logging flags
 	rootCmd.PersistentFlags().String("log-level", "", "Log level (debug, info, warn, error)")
@@ -74,9 +107,42 @@ func init() {
 	viper.BindPFlag("dns.cloudflare.api_token", rootCmd.PersistentFlags().Lookup("cloudflare-api-token"))
 	viper.BindPFlag("dns.route53.profile", rootCmd.PersistentFlags().Lookup("route53-profile"))
 	viper.BindPFlag("dns.route53.region", rootCmd.PersistentFlags().Lookup("route53-region"))
+	viper.BindPFlag("dns.gcloud.project", rootCmd.PersistentFlags().Lookup("gcloud-project"))
+	viper.BindPFlag("dns.gcloud.credentials_file", rootCmd.PersistentFlags().Lookup("gcloud-credentials-file"))
+	viper.BindPFlag("dns.azure.subscription_id", rootCmd.PersistentFlags().Lookup("azure-subscription-id"))
+	viper.BindPFlag("dns.azure.resource_group", rootCmd.PersistentFlags().Lookup("azure-resource-group"))
+	viper.BindPFlag("dns.digitalocean.api_token", rootCmd.PersistentFlags().Lookup("digitalocean-api-token"))
+	viper.BindPFlag("dns.digitalocean.domain", rootCmd.PersistentFlags().Lookup("digitalocean-domain"))
+	viper.BindPFlag("dns.rfc2136.server", rootCmd.PersistentFlags().Lookup("rfc2136-server"))
+	viper.BindPFlag("dns.rfc2136.tsig_key_name", rootCmd.PersistentFlags().Lookup("rfc2136-tsig-key-name"))
+	viper.BindPFlag("dns.powerdns.api_url", rootCmd.PersistentFlags().Lookup("powerdns-api-url"))
+	viper.BindPFlag("dns.powerdns.api_key", rootCmd.PersistentFlags().Lookup("powerdns-api-key"))
+	viper.BindPFlag("dns.hetzner.api_token", rootCmd.PersistentFlags().Lookup("hetzner-api-token"))
+	viper.BindPFlag("dns.zonefile.path", rootCmd.PersistentFlags().Lookup("zonefile-path"))
+	viper.BindPFlag("dns.hosts.path", rootCmd.PersistentFlags().Lookup("hosts-path"))
+	viper.BindPFlag("dns.pihole.url", rootCmd.PersistentFlags().Lookup("pihole-url"))
+	viper.BindPFlag("dns.adguardhome.url", rootCmd.PersistentFlags().Lookup("adguardhome-url"))
+	viper.BindPFlag("dns.infoblox.url", rootCmd.PersistentFlags().Lookup("infoblox-url"))
+	viper.BindPFlag("dns.infoblox.view", rootCmd.PersistentFlags().Lookup("infoblox-view"))
+	viper.BindPFlag("dns.ns1.api_key", rootCmd.PersistentFlags().Lookup("ns1-api-key"))
+	viper.BindPFlag("dns.dnsimple.api_token", rootCmd.PersistentFlags().Lookup("dnsimple-api-token"))
+	viper.BindPFlag("dns.dnsimple.account_id", rootCmd.PersistentFlags().Lookup("dnsimple-account-id"))
+	viper.BindPFlag("dns.gandi.personal_access_token", rootCmd.PersistentFlags().Lookup("gandi-personal-access-token"))
+	viper.BindPFlag("dns.gandi.domain", rootCmd.PersistentFlags().Lookup("gandi-domain"))
+	viper.BindPFlag("dns.technitium.url", rootCmd.PersistentFlags().Lookup("technitium-url"))
+	viper.BindPFlag("dns.technitium.api_token", rootCmd.PersistentFlags().Lookup("technitium-api-token"))
+	viper.BindPFlag("dns.etcd.endpoints", rootCmd.PersistentFlags().Lookup("etcd-endpoints"))
+	viper.BindPFlag("dns.coredns.kubeconfig", rootCmd.PersistentFlags().Lookup("coredns-kubeconfig"))
+	viper.BindPFlag("dns.coredns.configmap", rootCmd.PersistentFlags().Lookup("coredns-configmap"))
+	viper.BindPFlag("dns.routeros.url", rootCmd.PersistentFlags().Lookup("routeros-url"))
+	viper.BindPFlag("dns.builtin.listen", rootCmd.PersistentFlags().Lookup("builtin-listen"))
+	viper.BindPFlag("dns.exec.command", rootCmd.PersistentFlags().Lookup("exec-command"))
 	viper.BindPFlag("app.workers", rootCmd.PersistentFlags().Lookup("workers"))
 	viper.BindPFlag("app.poll_interval", rootCmd.PersistentFlags().Lookup("poll-interval"))
 	viper.BindPFlag("app.required_tags", rootCmd.PersistentFlags().Lookup("required-tags"))
+	viper.BindPFlag("app.address_family", rootCmd.PersistentFlags().Lookup("address-family"))
+	viper.BindPFlag("app.primary_address_only", rootCmd.PersistentFlags().Lookup("primary-address-only"))
+	viper.BindPFlag("app.wildcard", rootCmd.PersistentFlags().Lookup("wildcard"))
 	viper.BindPFlag("logging.level", rootCmd.PersistentFlags().Lookup("log-level"))
 	viper.BindPFlag("logging.format", rootCmd.PersistentFlags().Lookup("log-format"))
 
@@ -88,6 +154,31 @@ func init() {
 	viper.BindEnv("dns.cloudflare.api_token", "CLOUDFLARE_API_TOKEN")
 	viper.BindEnv("dns.route53.profile", "AWS_PROFILE")
 	viper.BindEnv("dns.route53.region", "AWS_REGION")
+	viper.BindEnv("dns.gcloud.project", "GOOGLE_CLOUD_PROJECT")
+	viper.BindEnv("dns.azure.subscription_id", "AZURE_SUBSCRIPTION_ID")
+	viper.BindEnv("dns.azure.tenant_id", "AZURE_TENANT_ID")
+	viper.BindEnv("dns.azure.client_id", "AZURE_CLIENT_ID")
+	viper.BindEnv("dns.azure.client_secret", "AZURE_CLIENT_SECRET")
+	viper.BindEnv("dns.digitalocean.api_token", "DIGITALOCEAN_TOKEN")
+	viper.BindEnv("dns.digitalocean.domain", "DIGITALOCEAN_DOMAIN")
+	viper.BindEnv("dns.rfc2136.tsig_secret", "RFC2136_TSIG_SECRET")
+	viper.BindEnv("dns.powerdns.api_key", "PDNS_API_KEY")
+	viper.BindEnv("dns.hetzner.api_token", "HETZNER_DNS_API_TOKEN")
+	viper.BindEnv("dns.pihole.password", "PIHOLE_PASSWORD")
+	viper.BindEnv("dns.adguardhome.username", "ADGUARDHOME_USERNAME")
+	viper.BindEnv("dns.adguardhome.password", "ADGUARDHOME_PASSWORD")
+	viper.BindEnv("dns.infoblox.username", "INFOBLOX_USERNAME")
+	viper.BindEnv("dns.infoblox.password", "INFOBLOX_PASSWORD")
+	viper.BindEnv("dns.ns1.api_key", "NS1_APIKEY")
+	viper.BindEnv("dns.dnsimple.api_token", "DNSIMPLE_TOKEN")
+	viper.BindEnv("dns.dnsimple.account_id", "DNSIMPLE_ACCOUNT_ID")
+	viper.BindEnv("dns.gandi.personal_access_token", "GANDI_PERSONAL_ACCESS_TOKEN")
+	viper.BindEnv("dns.gandi.domain", "GANDI_DOMAIN")
+	viper.BindEnv("dns.technitium.api_token", "TECHNITIUM_API_TOKEN")
+	viper.BindEnv("dns.etcd.username", "ETCD_USERNAME")
+	viper.BindEnv("dns.etcd.password", "ETCD_PASSWORD")
+	viper.BindEnv("dns.routeros.username", "ROUTEROS_USERNAME")
+	viper.BindEnv("dns.routeros.password", "ROUTEROS_PASSWORD")
 }
 
 // initConfig reads in config file and ENV variables.
@@ -161,11 +252,14 @@ tailscale:
   tailnet: "example@gmail.com"
 
 dns:
-  # DNS provider: route53 or cloudflare
+  # DNS provider: route53, cloudflare, gcloud, azure, digitalocean, rfc2136, powerdns,
+  # hetzner, zonefile, hosts, pihole, adguardhome, infoblox, ns1, dnsimple, gandi, technitium,
+  # etcd, coredns, routeros, builtin or exec
   provider: "cloudflare"
   # The domain to manage DNS records for
   domain: "example.com"
-  # The zone ID from your DNS provider
+  # The zone ID from your DNS provider (only needed for route53, cloudflare and
+  # gcloud, optional for hetzner)
   zone_id: "abc123def456"
   
   # Cloudflare-specific configuration (only needed if provider is cloudflare)
@@ -180,6 +274,249 @@ dns:
     # AWS region (optional, defaults to us-east-1)
     region: "us-east-1"
 
+  # Google Cloud DNS configuration (only needed if provider is gcloud)
+  # zone_id is the name of the Cloud DNS managed zone
+  gcloud:
+    # Project containing the managed zone
+    project: "my-project"
+    # Service account JSON key (optional, defaults to application default credentials)
+    credentials_file: "/path/to/service-account.json"
+
+  # Azure DNS configuration (only needed if provider is azure)
+  azure:
+    subscription_id: "00000000-0000-0000-0000-000000000000"
+    resource_group: "dns"
+    # DNS zone name (optional, defaults to dns.domain)
+    zone_name: "example.com"
+    # Service principal credentials
+    tenant_id: "00000000-0000-0000-0000-000000000000"
+    client_id: "00000000-0000-0000-0000-000000000000"
+    client_secret: "your-client-secret"
+    # Use the host's managed identity instead of a client secret (optional)
+    use_managed_identity: false
+
+  # DigitalOcean configuration (only needed if provider is digitalocean)
+  digitalocean:
+    # Get this from https://cloud.digitalocean.com/account/api/tokens
+    api_token: "your-digitalocean-api-token"
+    # DigitalOcean domain holding the records, e.g. a parent of dns.domain
+    # (optional, defaults to dns.domain)
+    domain: "example.com"
+
+  # RFC 2136 dynamic updates (only needed if provider is rfc2136)
+  # The server must allow updates and zone transfers for the TSIG key
+  rfc2136:
+    # Primary name server (port defaults to 53)
+    server: "ns1.example.com:53"
+    # Zone to update (optional, defaults to dns.domain)
+    zone: "example.com"
+    tsig_key_name: "dnsscale"
+    # Base64 encoded secret, e.g. from tsig-keygen or keymgr
+    tsig_secret: "your-tsig-secret"
+    # hmac-sha256 or hmac-sha512 (optional, defaults to hmac-sha256)
+    tsig_algorithm: "hmac-sha256"
+
+  # PowerDNS Authoritative configuration (only needed if provider is powerdns)
+  powerdns:
+    # Webserver address of pdns_server (requires api=yes)
+    api_url: "http://127.0.0.1:8081"
+    api_key: "your-powerdns-api-key"
+    # Server ID (optional, defaults to localhost)
+    server_id: "localhost"
+    # Zone to manage (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Rectify the zone after changes, for DNSSEC signed zones (optional)
+    rectify: false
+    # Send NOTIFY to secondaries after changes (optional)
+    notify: false
+
+  # Hetzner DNS configuration (only needed if provider is hetzner)
+  # zone_id is optional; the zone is looked up by name when it is empty
+  hetzner:
+    # Get this from https://dns.hetzner.com/settings/api-token
+    api_token: "your-hetzner-dns-api-token"
+    # Zone name (optional, defaults to dns.domain)
+    zone_name: "example.com"
+
+  # Zone file output (only needed if provider is zonefile)
+  zonefile:
+    path: "/var/named/example.com.zone"
+    # Zone origin (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Write records only, for $INCLUDE from a zone file maintained elsewhere (optional)
+    fragment: false
+    # SOA and NS used when creating a new zone file (optional)
+    primary_ns: "ns1.example.com"
+    hostmaster: "hostmaster.example.com"
+    nameservers:
+      - "ns1.example.com"
+    # Run after every change (optional)
+    reload_command: "rndc reload example.com"
+
+  # Hosts file output for dnsmasq or the CoreDNS hosts plugin (only needed if
+  # provider is hosts). Only A and AAAA records can be published.
+  hosts:
+    path: "/etc/dnsmasq.d/dnsscale.hosts"
+    # Name of the managed block (optional, defaults to dnsscale)
+    marker: "dnsscale"
+    # Signal this process after changes (optional)
+    pid_file: "/run/dnsmasq/dnsmasq.pid"
+    # HUP, INT or TERM (optional, defaults to HUP)
+    signal: "HUP"
+
+  # Pi-hole v6 local DNS records (only needed if provider is pihole)
+  # Only A and AAAA records can be published.
+  pihole:
+    url: "http://pi.hole"
+    # Web interface password or app password (optional if none is set)
+    password: "your-pihole-app-password"
+    # Pi-hole can't hold TXT records, so ownership is tracked in this file
+    state_file: "/var/lib/dnsscale/pihole-state.json"
+
+  # AdGuard Home DNS rewrites (only needed if provider is adguardhome)
+  # Only A and AAAA records can be published.
+  adguardhome:
+    url: "http://127.0.0.1:3000"
+    username: "admin"
+    password: "your-adguardhome-password"
+    # Rewrites can't carry TXT records, so ownership is tracked in this file
+    state_file: "/var/lib/dnsscale/adguardhome-state.json"
+
+  # Infoblox NIOS configuration (only needed if provider is infoblox)
+  infoblox:
+    url: "https://gm.example.com"
+    # WAPI version (optional, defaults to 2.12)
+    wapi_version: "2.12"
+    username: "dnsscale"
+    password: "your-infoblox-password"
+    # DNS view (optional, defaults to default)
+    view: "default"
+    # Zone to manage (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Extensible attribute marking owned records (optional, defaults to dnsscale-owner)
+    owner_attribute: "dnsscale-owner"
+    # Also create PTR records for A and AAAA records (optional)
+    create_ptr: false
+    # PEM bundle used to verify the Grid Master certificate (optional)
+    ca_file: "/etc/ssl/certs/infoblox-ca.pem"
+    # Skip certificate verification (optional, not recommended)
+    insecure_skip_verify: false
+
+  # NS1 configuration (only needed if provider is ns1)
+  ns1:
+    # Get this from the NS1 portal under Account Settings > API Keys
+    api_key: "your-ns1-api-key"
+    # Zone to manage (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Record metadata set on records dnsscale creates (optional)
+    meta:
+      note: "managed by dnsscale"
+
+  # DNSimple configuration (only needed if provider is dnsimple)
+  dnsimple:
+    # Account access token from https://dnsimple.com/a/<account>/account/access_tokens
+    api_token: "your-dnsimple-api-token"
+    account_id: "12345"
+    # Zone to manage (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Use the sandbox API at api.sandbox.dnsimple.com (optional)
+    sandbox: false
+
+  # Gandi LiveDNS configuration (only needed if provider is gandi)
+  gandi:
+    # Create this at https://account.gandi.net/ under Authentication options
+    personal_access_token: "your-gandi-personal-access-token"
+    # LiveDNS domain holding the records, e.g. a parent of dns.domain
+    # (optional, defaults to dns.domain)
+    domain: "example.com"
+
+  # Technitium DNS Server configuration (only needed if provider is technitium)
+  technitium:
+    url: "http://127.0.0.1:5380"
+    # Create this in the web console under Administration > Sessions
+    api_token: "your-technitium-api-token"
+    # Primary zone to manage (optional, defaults to dns.domain)
+    zone: "example.com"
+
+  # etcd configuration for CoreDNS's etcd plugin (only needed if provider is etcd)
+  etcd:
+    endpoints:
+      - "https://etcd-0.example.com:2379"
+    # Credentials and TLS client certificate (optional)
+    username: "dnsscale"
+    password: "your-etcd-password"
+    ca_file: "/etc/etcd/ca.pem"
+    cert_file: "/etc/etcd/dnsscale.pem"
+    key_file: "/etc/etcd/dnsscale-key.pem"
+    # Path prefix configured in the CoreDNS etcd plugin (optional, defaults to /skydns)
+    prefix: "/skydns"
+    # Zone served by the etcd plugin (optional, defaults to dns.domain)
+    zone: "example.com"
+    # Expire records this long after dnsscale stops (optional, minimum 5s)
+    lease_ttl: "5m"
+
+  # Hosts block in a CoreDNS ConfigMap (only needed if provider is coredns)
+  # Only A and AAAA records can be published.
+  coredns:
+    # Kubeconfig to use (optional, the in-cluster configuration is used if empty)
+    kubeconfig: "/home/user/.kube/config"
+    context: "production"
+    namespace: "kube-system"
+    configmap: "coredns"
+    # Key holding the block: the Corefile, which must already contain the
+    # markers inside a hosts block, or a hosts file mounted for the hosts plugin
+    key: "Corefile"
+    # Name of the managed block (optional, defaults to dnsscale)
+    marker: "dnsscale"
+
+  # MikroTik RouterOS v7 static DNS entries (only needed if provider is routeros)
+  routeros:
+    url: "https://192.168.88.1"
+    username: "dnsscale"
+    password: "your-routeros-password"
+    # Only entries in this zone are managed (optional, defaults to dns.domain)
+    zone: "example.com"
+    # CA bundle for the router's certificate (optional)
+    ca_file: "/etc/ssl/certs/router-ca.pem"
+    # Skip certificate verification (optional, not recommended)
+    insecure_skip_verify: false
+
+  # Serve the zone from dnsscale's own authoritative DNS server (only needed if
+  # provider is builtin). Delegate the zone to the hosts running dnsscale.
+  builtin:
+    # Addresses to answer on over UDP and TCP (optional, defaults to :53)
+    listen:
+      - ":53"
+    # Zone to serve (optional, defaults to dns.domain)
+    zone: "ts.example.com"
+    # SOA and NS records (optional, default to ns1.<zone> and hostmaster.<zone>)
+    primary_ns: "ns1.example.com"
+    hostmaster: "hostmaster.example.com"
+    nameservers:
+      - "ns1.example.com"
+      - "ns2.example.com"
+    # Secondaries allowed to transfer the zone with AXFR (optional)
+    allow_transfer:
+      - "192.0.2.53"
+      - "198.51.100.0/24"
+    # Secondaries sent a NOTIFY after each change (optional)
+    notify:
+      - "192.0.2.53:53"
+
+  # External provider plugin speaking line-delimited JSON on stdin and stdout
+  # (only needed if provider is exec)
+  exec:
+    command: "/usr/local/bin/dnsscale-plugin-mydns"
+    args:
+      - "--server=mydns.internal"
+    # Added to dnsscale's environment (optional)
+    env:
+      - "MYDNS_TOKEN=your-token"
+    # Working directory (optional)
+    dir: "/var/lib/dnsscale"
+    # How long the plugin has to answer a request (optional, defaults to 30s)
+    timeout: "30s"
+
 app:
   # Number of worker goroutines for processing DNS updates
   workers: 2
@@ -190,6 +527,61 @@ app:
   required_tags:
     - "tag:production"
     - "tag:webserver"
+  # Address families to publish: ipv4, ipv6 or both (default: both)
+  address_family: "both"
+  # Only publish the first address of each family (optional)
+  primary_address_only: false
+  # Also publish *.<node>.<domain> for every node (optional)
+  wildcard: false
+  # Per-tag overrides of the settings above (optional)
+  # Overrides are applied in order, so later matching entries win
+  tag_overrides:
+    - tag: "tag:legacy"
+      address_family: "ipv4"
+    - tag: "tag:reverse-proxy"
+      wildcard: true
+  # Round-robin group records built from tagged nodes (optional)
+  # Every node with the tag contributes its addresses to <name>.<domain>
+  groups:
+    - tag: "tag:web"
+      # Record name, defaults to the tag without "tag:" (web.example.com)
+      name: "web"
+      # Only include nodes that are currently online
+      online_only: true
+  # SRV records for services offered by nodes (optional)
+  # Publishes _<service>._<protocol>.<domain> pointing at <node>.<domain>
+  services:
+    - service: "http"
+      protocol: "tcp"
+      port: 8080
+      priority: 10
+      weight: 5
+      # Nodes with any of these tags are targets
+      tags:
+        - "tag:web"
+      # Nodes must also have all of these posture attributes (optional)
+      attributes:
+        "custom:tier": "prod"
+      online_only: true
+  # Active health checks run from this host against each node's Tailscale
+  # address (optional). Failing nodes are left out of group and service records
+  health_checks:
+    - name: "web-http"
+      # Nodes with any of these tags are probed
+      tags:
+        - "tag:web"
+      # Probe type: tcp or http
+      type: "http"
+      port: 80
+      # HTTP path to request, any 2xx or 3xx response is healthy
+      path: "/healthz"
+      # How long a probe result is cached before the node is probed again
+      interval: "30s"
+      timeout: "5s"
+      # Also withdraw the node's own records while it is unhealthy
+      node_records: false
+  # Serve node and health status as JSON on /status (optional)
+  status_address: "127.0.0.1:9090"
 
 logging:
   # Log level: debug, info, warn, error
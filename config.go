@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -28,11 +29,31 @@ type TailscaleConfig struct {
 
 // DNSConfig holds DNS provider configuration
 type DNSConfig struct {
-	Provider   string           `mapstructure:"provider" yaml:"provider"`
-	Domain     string           `mapstructure:"domain" yaml:"domain"`
-	ZoneID     string           `mapstructure:"zone_id" yaml:"zone_id"`
-	Route53    Route53Config    `mapstructure:"route53" yaml:"route53,omitempty"`
-	Cloudflare CloudflareConfig `mapstructure:"cloudflare" yaml:"cloudflare,omitempty"`
+	Provider     string             `mapstructure:"provider" yaml:"provider"`
+	Domain       string             `mapstructure:"domain" yaml:"domain"`
+	ZoneID       string             `mapstructure:"zone_id" yaml:"zone_id"`
+	Route53      Route53Config      `mapstructure:"route53" yaml:"route53,omitempty"`
+	Cloudflare   CloudflareConfig   `mapstructure:"cloudflare" yaml:"cloudflare,omitempty"`
+	GCloud       GCloudConfig       `mapstructure:"gcloud" yaml:"gcloud,omitempty"`
+	Azure        AzureConfig        `mapstructure:"azure" yaml:"azure,omitempty"`
+	DigitalOcean DigitalOceanConfig `mapstructure:"digitalocean" yaml:"digitalocean,omitempty"`
+	RFC2136      RFC2136Config      `mapstructure:"rfc2136" yaml:"rfc2136,omitempty"`
+	PowerDNS     PowerDNSConfig     `mapstructure:"powerdns" yaml:"powerdns,omitempty"`
+	Hetzner      HetznerConfig      `mapstructure:"hetzner" yaml:"hetzner,omitempty"`
+	ZoneFile     ZoneFileConfig     `mapstructure:"zonefile" yaml:"zonefile,omitempty"`
+	Hosts        HostsConfig        `mapstructure:"hosts" yaml:"hosts,omitempty"`
+	Pihole       PiholeConfig       `mapstructure:"pihole" yaml:"pihole,omitempty"`
+	AdGuardHome  AdGuardHomeConfig  `mapstructure:"adguardhome" yaml:"adguardhome,omitempty"`
+	Infoblox     InfobloxConfig     `mapstructure:"infoblox" yaml:"infoblox,omitempty"`
+	NS1          NS1Config          `mapstructure:"ns1" yaml:"ns1,omitempty"`
+	DNSimple     DNSimpleConfig     `mapstructure:"dnsimple" yaml:"dnsimple,omitempty"`
+	Gandi        GandiConfig        `mapstructure:"gandi" yaml:"gandi,omitempty"`
+	Technitium   TechnitiumConfig   `mapstructure:"technitium" yaml:"technitium,omitempty"`
+	Etcd         EtcdConfig         `mapstructure:"etcd" yaml:"etcd,omitempty"`
+	CoreDNS      CoreDNSConfig      `mapstructure:"coredns" yaml:"coredns,omitempty"`
+	RouterOS     RouterOSConfig     `mapstructure:"routeros" yaml:"routeros,omitempty"`
+	Builtin      BuiltinConfig      `mapstructure:"builtin" yaml:"builtin,omitempty"`
+	Exec         ExecConfig         `mapstructure:"exec" yaml:"exec,omitempty"`
 }
 
 // Route53Config holds AWS Route53 specific configuration
@@ -48,11 +69,284 @@ type CloudflareConfig struct {
 	APIToken string `mapstructure:"api_token" yaml:"api_token"`
 }
 
+// GCloudConfig holds Google Cloud DNS specific configuration. The managed
+// zone name is taken from dns.zone_id.
+type GCloudConfig struct {
+	Project string `mapstructure:"project" yaml:"project"`
+	// Service account JSON key, application default credentials are used if empty
+	CredentialsFile string `mapstructure:"credentials_file" yaml:"credentials_file,omitempty"`
+	// Overrides the Cloud DNS API endpoint, e.g. for a local fake server
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+}
+
+// AzureConfig holds Azure DNS specific configuration
+type AzureConfig struct {
+	SubscriptionID string `mapstructure:"subscription_id" yaml:"subscription_id"`
+	ResourceGroup  string `mapstructure:"resource_group" yaml:"resource_group"`
+	ZoneName       string `mapstructure:"zone_name" yaml:"zone_name,omitempty"` // Defaults to dns.domain
+	// Client secret authentication
+	TenantID     string `mapstructure:"tenant_id" yaml:"tenant_id,omitempty"`
+	ClientID     string `mapstructure:"client_id" yaml:"client_id,omitempty"`
+	ClientSecret string `mapstructure:"client_secret" yaml:"client_secret,omitempty"`
+	// Authenticate with the managed identity of the host instead. client_id
+	// may be set to pick a user-assigned identity.
+	UseManagedIdentity bool `mapstructure:"use_managed_identity" yaml:"use_managed_identity,omitempty"`
+	// Override the resource manager and login endpoints, e.g. for a stand-in server
+	Endpoint      string `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+	AuthorityHost string `mapstructure:"authority_host" yaml:"authority_host,omitempty"`
+}
+
+// DigitalOceanConfig holds DigitalOcean specific configuration
+type DigitalOceanConfig struct {
+	APIToken string `mapstructure:"api_token" yaml:"api_token"`
+	Domain   string `mapstructure:"domain" yaml:"domain,omitempty"` // Defaults to dns.domain
+}
+
+// RFC2136Config holds settings for sending dynamic updates to an
+// authoritative server such as BIND or Knot
+type RFC2136Config struct {
+	Server string `mapstructure:"server" yaml:"server"`       // host:port of the primary, port defaults to 53
+	Zone   string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+	// TSIG key allowed to update and transfer the zone. Updates are unsigned
+	// if no key name is set.
+	TSIGKeyName   string        `mapstructure:"tsig_key_name" yaml:"tsig_key_name,omitempty"`
+	TSIGSecret    string        `mapstructure:"tsig_secret" yaml:"tsig_secret,omitempty"`       // Base64 encoded
+	TSIGAlgorithm string        `mapstructure:"tsig_algorithm" yaml:"tsig_algorithm,omitempty"` // hmac-sha256 or hmac-sha512
+	Timeout       time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+}
+
+// PowerDNSConfig holds PowerDNS Authoritative server specific configuration
+type PowerDNSConfig struct {
+	APIURL   string `mapstructure:"api_url" yaml:"api_url"` // e.g. http://127.0.0.1:8081
+	APIKey   string `mapstructure:"api_key" yaml:"api_key"`
+	ServerID string `mapstructure:"server_id" yaml:"server_id,omitempty"` // Defaults to localhost
+	Zone     string `mapstructure:"zone" yaml:"zone,omitempty"`           // Defaults to dns.domain
+	Rectify  bool   `mapstructure:"rectify" yaml:"rectify,omitempty"`     // Rectify the zone after each change
+	Notify   bool   `mapstructure:"notify" yaml:"notify,omitempty"`       // NOTIFY secondaries after each change
+}
+
+// HetznerConfig holds Hetzner DNS specific configuration. The zone is
+// dns.zone_id when set, otherwise it is looked up by zone_name.
+type HetznerConfig struct {
+	APIToken string `mapstructure:"api_token" yaml:"api_token"`
+	ZoneName string `mapstructure:"zone_name" yaml:"zone_name,omitempty"` // Defaults to dns.domain
+}
+
+// ZoneFileConfig holds settings for rendering records into a zone file on
+// disk instead of calling a DNS API
+type ZoneFileConfig struct {
+	Path string `mapstructure:"path" yaml:"path"`
+	Zone string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+	// Write records only, for a zone file that pulls them in with $INCLUDE
+	Fragment bool `mapstructure:"fragment" yaml:"fragment,omitempty"`
+	// SOA and NS used when creating a new standalone zone file
+	PrimaryNS   string   `mapstructure:"primary_ns" yaml:"primary_ns,omitempty"`
+	Hostmaster  string   `mapstructure:"hostmaster" yaml:"hostmaster,omitempty"`
+	Nameservers []string `mapstructure:"nameservers" yaml:"nameservers,omitempty"`
+	// Run after each change, e.g. "rndc reload example.com"
+	ReloadCommand string        `mapstructure:"reload_command" yaml:"reload_command,omitempty"`
+	ReloadTimeout time.Duration `mapstructure:"reload_timeout" yaml:"reload_timeout,omitempty"`
+}
+
+// HostsConfig holds settings for maintaining a hosts file read by dnsmasq or
+// the CoreDNS hosts plugin
+type HostsConfig struct {
+	Path   string `mapstructure:"path" yaml:"path"`
+	Marker string `mapstructure:"marker" yaml:"marker,omitempty"` // Name of the managed block, defaults to dnsscale
+	// Signal the process in this pidfile after each change, e.g. dnsmasq
+	PIDFile string `mapstructure:"pid_file" yaml:"pid_file,omitempty"`
+	Signal  string `mapstructure:"signal" yaml:"signal,omitempty"` // HUP, INT or TERM, defaults to HUP
+}
+
+// PiholeConfig holds Pi-hole specific configuration
+type PiholeConfig struct {
+	URL      string `mapstructure:"url" yaml:"url"`                     // e.g. http://pi.hole
+	Password string `mapstructure:"password" yaml:"password,omitempty"` // Web interface password or app password
+	// Pi-hole can't hold TXT records, so ownership is tracked in this file
+	StateFile string `mapstructure:"state_file" yaml:"state_file,omitempty"`
+}
+
+// AdGuardHomeConfig holds AdGuard Home specific configuration
+type AdGuardHomeConfig struct {
+	URL      string `mapstructure:"url" yaml:"url"` // e.g. http://127.0.0.1:3000
+	Username string `mapstructure:"username" yaml:"username,omitempty"`
+	Password string `mapstructure:"password" yaml:"password,omitempty"`
+	// Rewrites can't carry TXT records, so ownership is tracked in this file
+	StateFile string `mapstructure:"state_file" yaml:"state_file,omitempty"`
+}
+
+// InfobloxConfig holds Infoblox NIOS specific configuration
+type InfobloxConfig struct {
+	URL         string `mapstructure:"url" yaml:"url"`                             // Grid Master, e.g. https://gm.example.com
+	WAPIVersion string `mapstructure:"wapi_version" yaml:"wapi_version,omitempty"` // Defaults to 2.12
+	Username    string `mapstructure:"username" yaml:"username"`
+	Password    string `mapstructure:"password" yaml:"password"`
+	View        string `mapstructure:"view" yaml:"view,omitempty"` // Defaults to default
+	Zone        string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+	// Extensible attribute marking records dnsscale owns, defaults to dnsscale-owner
+	OwnerAttribute string `mapstructure:"owner_attribute" yaml:"owner_attribute,omitempty"`
+	CreatePTR      bool   `mapstructure:"create_ptr" yaml:"create_ptr,omitempty"`
+	// TLS verification of the Grid Master
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" yaml:"insecure_skip_verify,omitempty"`
+	CAFile             string `mapstructure:"ca_file" yaml:"ca_file,omitempty"`
+}
+
+// NS1Config holds NS1 specific configuration
+type NS1Config struct {
+	APIKey string `mapstructure:"api_key" yaml:"api_key"`
+	Zone   string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+	// Record metadata set on records dnsscale creates, e.g. {"note": "dnsscale"}
+	Meta map[string]interface{} `mapstructure:"meta" yaml:"meta,omitempty"`
+	// Overrides the API endpoint, e.g. for a private deployment
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+}
+
+// DNSimpleConfig holds DNSimple specific configuration
+type DNSimpleConfig struct {
+	APIToken  string `mapstructure:"api_token" yaml:"api_token"`
+	AccountID string `mapstructure:"account_id" yaml:"account_id"`
+	Zone      string `mapstructure:"zone" yaml:"zone,omitempty"`       // Defaults to dns.domain
+	Sandbox   bool   `mapstructure:"sandbox" yaml:"sandbox,omitempty"` // Use the sandbox API for testing
+}
+
+// GandiConfig holds Gandi LiveDNS specific configuration
+type GandiConfig struct {
+	PersonalAccessToken string `mapstructure:"personal_access_token" yaml:"personal_access_token"`
+	Domain              string `mapstructure:"domain" yaml:"domain,omitempty"` // Defaults to dns.domain
+	// Overrides the LiveDNS API endpoint, e.g. for Gandi's sandbox
+	Endpoint string `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+}
+
+// TechnitiumConfig holds Technitium DNS Server specific configuration
+type TechnitiumConfig struct {
+	URL      string `mapstructure:"url" yaml:"url"` // e.g. http://127.0.0.1:5380
+	APIToken string `mapstructure:"api_token" yaml:"api_token"`
+	Zone     string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+}
+
+// EtcdConfig holds configuration for the etcd backend of CoreDNS's etcd plugin
+type EtcdConfig struct {
+	Endpoints []string      `mapstructure:"endpoints" yaml:"endpoints"` // e.g. https://etcd-0:2379
+	Username  string        `mapstructure:"username" yaml:"username,omitempty"`
+	Password  string        `mapstructure:"password" yaml:"password,omitempty"`
+	CertFile  string        `mapstructure:"cert_file" yaml:"cert_file,omitempty"`
+	KeyFile   string        `mapstructure:"key_file" yaml:"key_file,omitempty"`
+	CAFile    string        `mapstructure:"ca_file" yaml:"ca_file,omitempty"`
+	Prefix    string        `mapstructure:"prefix" yaml:"prefix,omitempty"` // Must match the CoreDNS path, defaults to /skydns
+	Zone      string        `mapstructure:"zone" yaml:"zone,omitempty"`     // Defaults to dns.domain
+	Timeout   time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+	// Attach keys to a lease kept alive while dnsscale runs, so records expire
+	// this long after it stops. Keys are permanent if unset.
+	LeaseTTL time.Duration `mapstructure:"lease_ttl" yaml:"lease_ttl,omitempty"`
+}
+
+// CoreDNSConfig holds configuration for a hosts block kept in a CoreDNS
+// ConfigMap in Kubernetes
+type CoreDNSConfig struct {
+	Kubeconfig string `mapstructure:"kubeconfig" yaml:"kubeconfig,omitempty"` // In-cluster configuration is used if empty
+	Context    string `mapstructure:"context" yaml:"context,omitempty"`
+	Namespace  string `mapstructure:"namespace" yaml:"namespace,omitempty"` // Defaults to kube-system
+	ConfigMap  string `mapstructure:"configmap" yaml:"configmap,omitempty"` // Defaults to coredns
+	// Key holding the hosts lines, either the Corefile or a file mounted for
+	// the hosts plugin. Defaults to Corefile.
+	Key    string `mapstructure:"key" yaml:"key,omitempty"`
+	Marker string `mapstructure:"marker" yaml:"marker,omitempty"` // Name of the managed block, defaults to dnsscale
+}
+
+// RouterOSConfig holds MikroTik RouterOS specific configuration
+type RouterOSConfig struct {
+	URL      string `mapstructure:"url" yaml:"url"` // REST API, e.g. https://192.168.88.1
+	Username string `mapstructure:"username" yaml:"username"`
+	Password string `mapstructure:"password" yaml:"password"`
+	Zone     string `mapstructure:"zone" yaml:"zone,omitempty"` // Defaults to dns.domain
+	// TLS verification of the router
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify" yaml:"insecure_skip_verify,omitempty"`
+	CAFile             string `mapstructure:"ca_file" yaml:"ca_file,omitempty"`
+}
+
+// BuiltinConfig holds settings for serving the zone from dnsscale's own
+// authoritative DNS server
+type BuiltinConfig struct {
+	Listen []string `mapstructure:"listen" yaml:"listen,omitempty"` // Defaults to :53
+	Zone   string   `mapstructure:"zone" yaml:"zone,omitempty"`     // Defaults to dns.domain
+	// SOA and NS records of the zone
+	PrimaryNS   string   `mapstructure:"primary_ns" yaml:"primary_ns,omitempty"`
+	Hostmaster  string   `mapstructure:"hostmaster" yaml:"hostmaster,omitempty"`
+	Nameservers []string `mapstructure:"nameservers" yaml:"nameservers,omitempty"`
+	// Secondaries allowed to AXFR the zone (addresses or CIDRs) and sent a
+	// NOTIFY after each change (host or host:port)
+	AllowTransfer []string `mapstructure:"allow_transfer" yaml:"allow_transfer,omitempty"`
+	Notify        []string `mapstructure:"notify" yaml:"notify,omitempty"`
+}
+
+// ExecConfig holds settings for an external provider plugin speaking the
+// line-delimited JSON protocol on stdin and stdout
+type ExecConfig struct {
+	Command string   `mapstructure:"command" yaml:"command"`
+	Args    []string `mapstructure:"args" yaml:"args,omitempty"`
+	Env     []string `mapstructure:"env" yaml:"env,omitempty"` // KEY=value, added to dnsscale's environment
+	Dir     string   `mapstructure:"dir" yaml:"dir,omitempty"`
+	// How long the plugin has to answer a request
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+}
+
 // AppConfig holds general application configuration
 type AppConfig struct {
-	Workers      int           `mapstructure:"workers" yaml:"workers"`
-	PollInterval time.Duration `mapstructure:"poll_interval" yaml:"poll_interval"`
-	RequiredTags []string      `mapstructure:"required_tags" yaml:"required_tags,omitempty"`
+	Workers            int                 `mapstructure:"workers" yaml:"workers"`
+	PollInterval       time.Duration       `mapstructure:"poll_interval" yaml:"poll_interval"`
+	RequiredTags       []string            `mapstructure:"required_tags" yaml:"required_tags,omitempty"`
+	AddressFamily      string              `mapstructure:"address_family" yaml:"address_family,omitempty"` // ipv4, ipv6 or both
+	PrimaryAddressOnly bool                `mapstructure:"primary_address_only" yaml:"primary_address_only,omitempty"`
+	Wildcard           bool                `mapstructure:"wildcard" yaml:"wildcard,omitempty"` // Also publish *.<node>.<domain>
+	TagOverrides       []TagOverride       `mapstructure:"tag_overrides" yaml:"tag_overrides,omitempty"`
+	Groups             []GroupConfig       `mapstructure:"groups" yaml:"groups,omitempty"`
+	Services           []ServiceConfig     `mapstructure:"services" yaml:"services,omitempty"`
+	HealthChecks       []HealthCheckConfig `mapstructure:"health_checks" yaml:"health_checks,omitempty"`
+	StatusAddress      string              `mapstructure:"status_address" yaml:"status_address,omitempty"` // e.g. 127.0.0.1:9090
+}
+
+// TagOverride changes how records are published for nodes carrying a tag.
+// Unset fields fall back to the global app settings.
+type TagOverride struct {
+	Tag                string `mapstructure:"tag" yaml:"tag"`
+	AddressFamily      string `mapstructure:"address_family" yaml:"address_family,omitempty"`
+	PrimaryAddressOnly *bool  `mapstructure:"primary_address_only" yaml:"primary_address_only,omitempty"`
+	Wildcard           *bool  `mapstructure:"wildcard" yaml:"wildcard,omitempty"`
+}
+
+// GroupConfig publishes a round-robin record containing the addresses of
+// every node carrying a tag
+type GroupConfig struct {
+	Tag        string `mapstructure:"tag" yaml:"tag"`
+	Name       string `mapstructure:"name" yaml:"name,omitempty"` // Defaults to the tag without "tag:"
+	OnlineOnly bool   `mapstructure:"online_only" yaml:"online_only,omitempty"`
+}
+
+// ServiceConfig publishes an SRV record at _service._proto.<domain> pointing
+// at every node that matches its tags or posture attributes
+type ServiceConfig struct {
+	Service    string            `mapstructure:"service" yaml:"service"`
+	Protocol   string            `mapstructure:"protocol" yaml:"protocol,omitempty"` // tcp or udp
+	Port       uint16            `mapstructure:"port" yaml:"port"`
+	Priority   uint16            `mapstructure:"priority" yaml:"priority,omitempty"`
+	Weight     uint16            `mapstructure:"weight" yaml:"weight,omitempty"`
+	Tags       []string          `mapstructure:"tags" yaml:"tags,omitempty"`             // Nodes with any of these tags match
+	Attributes map[string]string `mapstructure:"attributes" yaml:"attributes,omitempty"` // Nodes must have all of these posture attributes
+	OnlineOnly bool              `mapstructure:"online_only" yaml:"online_only,omitempty"`
+}
+
+// HealthCheckConfig actively probes nodes carrying any of its tags. Nodes
+// failing the probe are left out of group and service records, and out of
+// their own records too when NodeRecords is set.
+type HealthCheckConfig struct {
+	Name        string        `mapstructure:"name" yaml:"name"`
+	Tags        []string      `mapstructure:"tags" yaml:"tags"`
+	Type        string        `mapstructure:"type" yaml:"type"` // tcp or http
+	Port        uint16        `mapstructure:"port" yaml:"port"`
+	Path        string        `mapstructure:"path" yaml:"path,omitempty"` // http only
+	Interval    time.Duration `mapstructure:"interval" yaml:"interval,omitempty"`
+	Timeout     time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+	NodeRecords bool          `mapstructure:"node_records" yaml:"node_records,omitempty"`
 }
 
 // LoggingConfig holds logging configuration
@@ -61,6 +355,24 @@ type LoggingConfig struct {
 	Format string `mapstructure:"format" yaml:"format"` // json or console
 }
 
+// providersWithoutSRV are the providers that can't publish the SRV records
+// used by app.services
+var providersWithoutSRV = map[string]bool{
+	"hosts":       true,
+	"pihole":      true,
+	"adguardhome": true,
+	"coredns":     true,
+}
+
+// providersWithoutWildcards are the providers that can't publish wildcard
+// records
+var providersWithoutWildcards = map[string]bool{
+	"hosts":   true,
+	"pihole":  true,
+	"etcd":    true,
+	"coredns": true,
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Validate Tailscale configuration
@@ -78,20 +390,222 @@ func (c *Config) Validate() error {
 	if c.DNS.Domain == "" {
 		return fmt.Errorf("dns.domain is required")
 	}
-	if c.DNS.ZoneID == "" {
-		return fmt.Errorf("dns.zone_id is required")
-	}
-
 	// Provider-specific validation
 	switch c.DNS.Provider {
 	case "route53":
 		// Route53 validation - credentials are typically handled via AWS SDK
+		if c.DNS.ZoneID == "" {
+			return fmt.Errorf("dns.zone_id is required")
+		}
 	case "cloudflare":
+		if c.DNS.ZoneID == "" {
+			return fmt.Errorf("dns.zone_id is required")
+		}
 		if c.DNS.Cloudflare.APIToken == "" {
 			return fmt.Errorf("dns.cloudflare.api_token is required when using cloudflare provider")
 		}
+	case "gcloud":
+		if c.DNS.ZoneID == "" {
+			return fmt.Errorf("dns.zone_id is required")
+		}
+		if c.DNS.GCloud.Project == "" {
+			return fmt.Errorf("dns.gcloud.project is required when using gcloud provider")
+		}
+	case "azure":
+		if c.DNS.Azure.SubscriptionID == "" {
+			return fmt.Errorf("dns.azure.subscription_id is required when using azure provider")
+		}
+		if c.DNS.Azure.ResourceGroup == "" {
+			return fmt.Errorf("dns.azure.resource_group is required when using azure provider")
+		}
+		if c.DNS.Azure.ZoneName == "" {
+			c.DNS.Azure.ZoneName = c.DNS.Domain // Set default
+		}
+		if !c.DNS.Azure.UseManagedIdentity && (c.DNS.Azure.TenantID == "" || c.DNS.Azure.ClientID == "" || c.DNS.Azure.ClientSecret == "") {
+			return fmt.Errorf("dns.azure.tenant_id, client_id and client_secret are required unless dns.azure.use_managed_identity is set")
+		}
+	case "digitalocean":
+		if c.DNS.DigitalOcean.APIToken == "" {
+			return fmt.Errorf("dns.digitalocean.api_token is required when using digitalocean provider")
+		}
+		if c.DNS.DigitalOcean.Domain == "" {
+			c.DNS.DigitalOcean.Domain = c.DNS.Domain // Set default
+		}
+	case "rfc2136":
+		if c.DNS.RFC2136.Server == "" {
+			return fmt.Errorf("dns.rfc2136.server is required when using rfc2136 provider")
+		}
+		if c.DNS.RFC2136.Zone == "" {
+			c.DNS.RFC2136.Zone = c.DNS.Domain // Set default
+		}
+		if c.DNS.RFC2136.TSIGKeyName != "" && c.DNS.RFC2136.TSIGSecret == "" {
+			return fmt.Errorf("dns.rfc2136.tsig_secret is required when dns.rfc2136.tsig_key_name is set")
+		}
+		if c.DNS.RFC2136.TSIGAlgorithm == "" {
+			c.DNS.RFC2136.TSIGAlgorithm = "hmac-sha256" // Set default
+		}
+		if c.DNS.RFC2136.TSIGAlgorithm != "hmac-sha256" && c.DNS.RFC2136.TSIGAlgorithm != "hmac-sha512" {
+			return fmt.Errorf("dns.rfc2136.tsig_algorithm must be hmac-sha256 or hmac-sha512")
+		}
+		if c.DNS.RFC2136.Timeout <= 0 {
+			c.DNS.RFC2136.Timeout = 10 * time.Second // Set default
+		}
+	case "powerdns":
+		if c.DNS.PowerDNS.APIURL == "" {
+			return fmt.Errorf("dns.powerdns.api_url is required when using powerdns provider")
+		}
+		if c.DNS.PowerDNS.APIKey == "" {
+			return fmt.Errorf("dns.powerdns.api_key is required when using powerdns provider")
+		}
+		if c.DNS.PowerDNS.ServerID == "" {
+			c.DNS.PowerDNS.ServerID = "localhost" // Set default
+		}
+		if c.DNS.PowerDNS.Zone == "" {
+			c.DNS.PowerDNS.Zone = c.DNS.Domain // Set default
+		}
+	case "hetzner":
+		if c.DNS.Hetzner.APIToken == "" {
+			return fmt.Errorf("dns.hetzner.api_token is required when using hetzner provider")
+		}
+		if c.DNS.Hetzner.ZoneName == "" {
+			c.DNS.Hetzner.ZoneName = c.DNS.Domain // Set default
+		}
+	case "zonefile":
+		if c.DNS.ZoneFile.Path == "" {
+			return fmt.Errorf("dns.zonefile.path is required when using zonefile provider")
+		}
+		if c.DNS.ZoneFile.Zone == "" {
+			c.DNS.ZoneFile.Zone = c.DNS.Domain // Set default
+		}
+		if c.DNS.ZoneFile.ReloadTimeout <= 0 {
+			c.DNS.ZoneFile.ReloadTimeout = 30 * time.Second // Set default
+		}
+	case "hosts":
+		if c.DNS.Hosts.Path == "" {
+			return fmt.Errorf("dns.hosts.path is required when using hosts provider")
+		}
+		if c.DNS.Hosts.Marker == "" {
+			c.DNS.Hosts.Marker = "dnsscale" // Set default
+		}
+		if c.DNS.Hosts.Signal == "" {
+			c.DNS.Hosts.Signal = "HUP" // Set default
+		}
+	case "pihole":
+		if c.DNS.Pihole.URL == "" {
+			return fmt.Errorf("dns.pihole.url is required when using pihole provider")
+		}
+		if c.DNS.Pihole.StateFile == "" {
+			c.DNS.Pihole.StateFile = "dnsscale-pihole-state.json" // Set default
+		}
+	case "adguardhome":
+		if c.DNS.AdGuardHome.URL == "" {
+			return fmt.Errorf("dns.adguardhome.url is required when using adguardhome provider")
+		}
+		if c.DNS.AdGuardHome.StateFile == "" {
+			c.DNS.AdGuardHome.StateFile = "dnsscale-adguardhome-state.json" // Set default
+		}
+	case "infoblox":
+		if c.DNS.Infoblox.URL == "" {
+			return fmt.Errorf("dns.infoblox.url is required when using infoblox provider")
+		}
+		if c.DNS.Infoblox.Username == "" || c.DNS.Infoblox.Password == "" {
+			return fmt.Errorf("dns.infoblox.username and password are required when using infoblox provider")
+		}
+		if c.DNS.Infoblox.WAPIVersion == "" {
+			c.DNS.Infoblox.WAPIVersion = "2.12" // Set default
+		}
+		if c.DNS.Infoblox.View == "" {
+			c.DNS.Infoblox.View = "default" // Set default
+		}
+		if c.DNS.Infoblox.Zone == "" {
+			c.DNS.Infoblox.Zone = c.DNS.Domain // Set default
+		}
+		if c.DNS.Infoblox.OwnerAttribute == "" {
+			c.DNS.Infoblox.OwnerAttribute = "dnsscale-owner" // Set default
+		}
+	case "ns1":
+		if c.DNS.NS1.APIKey == "" {
+			return fmt.Errorf("dns.ns1.api_key is required when using ns1 provider")
+		}
+		if c.DNS.NS1.Zone == "" {
+			c.DNS.NS1.Zone = c.DNS.Domain // Set default
+		}
+	case "dnsimple":
+		if c.DNS.DNSimple.APIToken == "" {
+			return fmt.Errorf("dns.dnsimple.api_token is required when using dnsimple provider")
+		}
+		if c.DNS.DNSimple.AccountID == "" {
+			return fmt.Errorf("dns.dnsimple.account_id is required when using dnsimple provider")
+		}
+		if c.DNS.DNSimple.Zone == "" {
+			c.DNS.DNSimple.Zone = c.DNS.Domain // Set default
+		}
+	case "gandi":
+		if c.DNS.Gandi.PersonalAccessToken == "" {
+			return fmt.Errorf("dns.gandi.personal_access_token is required when using gandi provider")
+		}
+		if c.DNS.Gandi.Domain == "" {
+			c.DNS.Gandi.Domain = c.DNS.Domain // Set default
+		}
+	case "technitium":
+		if c.DNS.Technitium.URL == "" {
+			return fmt.Errorf("dns.technitium.url is required when using technitium provider")
+		}
+		if c.DNS.Technitium.APIToken == "" {
+			return fmt.Errorf("dns.technitium.api_token is required when using technitium provider")
+		}
+		if c.DNS.Technitium.Zone == "" {
+			c.DNS.Technitium.Zone = c.DNS.Domain // Set default
+		}
+	case "etcd":
+		if len(c.DNS.Etcd.Endpoints) == 0 {
+			return fmt.Errorf("dns.etcd.endpoints is required when using etcd provider")
+		}
+		if c.DNS.Etcd.Prefix == "" {
+			c.DNS.Etcd.Prefix = "/skydns" // Set default
+		}
+		if c.DNS.Etcd.Zone == "" {
+			c.DNS.Etcd.Zone = c.DNS.Domain // Set default
+		}
+	case "coredns":
+		if c.DNS.CoreDNS.Namespace == "" {
+			c.DNS.CoreDNS.Namespace = "kube-system" // Set default
+		}
+		if c.DNS.CoreDNS.ConfigMap == "" {
+			c.DNS.CoreDNS.ConfigMap = "coredns" // Set default
+		}
+		if c.DNS.CoreDNS.Key == "" {
+			c.DNS.CoreDNS.Key = "Corefile" // Set default
+		}
+		if c.DNS.CoreDNS.Marker == "" {
+			c.DNS.CoreDNS.Marker = "dnsscale" // Set default
+		}
+	case "routeros":
+		if c.DNS.RouterOS.URL == "" {
+			return fmt.Errorf("dns.routeros.url is required when using routeros provider")
+		}
+		if c.DNS.RouterOS.Username == "" {
+			return fmt.Errorf("dns.routeros.username is required when using routeros provider")
+		}
+		if c.DNS.RouterOS.Zone == "" {
+			c.DNS.RouterOS.Zone = c.DNS.Domain // Set default
+		}
+	case "builtin":
+		if len(c.DNS.Builtin.Listen) == 0 {
+			c.DNS.Builtin.Listen = []string{":53"} // Set default
+		}
+		if c.DNS.Builtin.Zone == "" {
+			c.DNS.Builtin.Zone = c.DNS.Domain // Set default
+		}
+	case "exec":
+		if c.DNS.Exec.Command == "" {
+			return fmt.Errorf("dns.exec.command is required when using exec provider")
+		}
+		if c.DNS.Exec.Timeout <= 0 {
+			c.DNS.Exec.Timeout = 30 * time.Second // Set default
+		}
 	default:
-		return fmt.Errorf("unsupported dns provider: %s (supported: route53, cloudflare)", c.DNS.Provider)
+		return fmt.Errorf("unsupported dns provider: %s (supported: route53, cloudflare, gcloud, azure, digitalocean, rfc2136, powerdns, hetzner, zonefile, hosts, pihole, adguardhome, infoblox, ns1, dnsimple, gandi, technitium, etcd, coredns, routeros, builtin, exec)", c.DNS.Provider)
 	}
 
 	// Validate app configuration
@@ -101,6 +615,102 @@ func (c *Config) Validate() error {
 	if c.App.PollInterval <= 0 {
 		c.App.PollInterval = 30 * time.Second // Set default
 	}
+	if c.App.AddressFamily == "" {
+		c.App.AddressFamily = addressFamilyBoth // Set default
+	}
+	if !validAddressFamily(c.App.AddressFamily) {
+		return fmt.Errorf("invalid app.address_family: %s (supported: %v)", c.App.AddressFamily, addressFamilies)
+	}
+	for i, override := range c.App.TagOverrides {
+		if override.Tag == "" {
+			return fmt.Errorf("app.tag_overrides[%d].tag is required", i)
+		}
+		if override.AddressFamily != "" && !validAddressFamily(override.AddressFamily) {
+			return fmt.Errorf("invalid app.tag_overrides[%d].address_family: %s (supported: %v)", i, override.AddressFamily, addressFamilies)
+		}
+		if override.Wildcard != nil && *override.Wildcard && providersWithoutWildcards[c.DNS.Provider] {
+			return fmt.Errorf("app.tag_overrides[%d].wildcard isn't supported by the %s provider", i, c.DNS.Provider)
+		}
+	}
+	if c.App.Wildcard && providersWithoutWildcards[c.DNS.Provider] {
+		return fmt.Errorf("app.wildcard isn't supported by the %s provider", c.DNS.Provider)
+	}
+	if len(c.App.Services) > 0 && providersWithoutSRV[c.DNS.Provider] {
+		return fmt.Errorf("app.services isn't supported by the %s provider", c.DNS.Provider)
+	}
+	groupNames := make(map[string]bool)
+	for i, group := range c.App.Groups {
+		if group.Tag == "" {
+			return fmt.Errorf("app.groups[%d].tag is required", i)
+		}
+		name := groupName(group)
+		if name == "" || strings.ContainsAny(name, " *") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".") {
+			return fmt.Errorf("invalid app.groups[%d].name: %q", i, name)
+		}
+		if groupNames[name] {
+			return fmt.Errorf("duplicate app.groups name: %s", name)
+		}
+		groupNames[name] = true
+	}
+	serviceLabels := make(map[string]bool)
+	for i := range c.App.Services {
+		service := &c.App.Services[i]
+		if service.Service == "" {
+			return fmt.Errorf("app.services[%d].service is required", i)
+		}
+		if service.Protocol == "" {
+			service.Protocol = "tcp" // Set default
+		}
+		if protocol := strings.TrimPrefix(service.Protocol, "_"); protocol != "tcp" && protocol != "udp" {
+			return fmt.Errorf("invalid app.services[%d].protocol: %s (supported: tcp, udp)", i, service.Protocol)
+		}
+		if service.Port == 0 {
+			return fmt.Errorf("app.services[%d].port is required", i)
+		}
+		if len(service.Tags) == 0 && len(service.Attributes) == 0 {
+			return fmt.Errorf("app.services[%d] must match on tags or attributes", i)
+		}
+		label := serviceLabel(*service)
+		if serviceLabels[label] {
+			return fmt.Errorf("duplicate app.services entry: %s", label)
+		}
+		serviceLabels[label] = true
+	}
+	checkNames := make(map[string]bool)
+	for i := range c.App.HealthChecks {
+		check := &c.App.HealthChecks[i]
+		if check.Name == "" {
+			return fmt.Errorf("app.health_checks[%d].name is required", i)
+		}
+		if checkNames[check.Name] {
+			return fmt.Errorf("duplicate app.health_checks name: %s", check.Name)
+		}
+		checkNames[check.Name] = true
+		if len(check.Tags) == 0 {
+			return fmt.Errorf("app.health_checks[%d].tags is required", i)
+		}
+		switch check.Type {
+		case healthCheckTCP:
+		case healthCheckHTTP:
+			if check.Path == "" {
+				check.Path = "/" // Set default
+			}
+			if !strings.HasPrefix(check.Path, "/") {
+				return fmt.Errorf("invalid app.health_checks[%d].path: %s (must start with /)", i, check.Path)
+			}
+		default:
+			return fmt.Errorf("invalid app.health_checks[%d].type: %s (supported: tcp, http)", i, check.Type)
+		}
+		if check.Port == 0 {
+			return fmt.Errorf("app.health_checks[%d].port is required", i)
+		}
+		if check.Interval <= 0 {
+			check.Interval = 30 * time.Second // Set default
+		}
+		if check.Timeout <= 0 {
+			check.Timeout = 5 * time.Second // Set default
+		}
+	}
 
 	// Validate logging configuration
 	validLevels := []string{"debug", "info", "warn", "error"}
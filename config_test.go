@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateUnsupportedRecords(t *testing.T) {
+	enabled := true
+	service := ServiceConfig{Service: "http", Port: 80, Tags: []string{"tag:web"}}
+
+	tests := []struct {
+		name     string
+		provider string
+		app      AppConfig
+		wantErr  string
+	}{
+		{"wildcard", "pihole", AppConfig{Wildcard: true}, "app.wildcard"},
+		{"tag override wildcard", "etcd", AppConfig{TagOverrides: []TagOverride{{Tag: "tag:web", Wildcard: &enabled}}}, "app.tag_overrides[0].wildcard"},
+		{"services", "adguardhome", AppConfig{Services: []ServiceConfig{service}}, "app.services"},
+		{"supported wildcard", "adguardhome", AppConfig{Wildcard: true}, ""},
+		{"supported services", "etcd", AppConfig{Services: []ServiceConfig{service}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{App: tt.app}
+			config.Tailscale.APIKey = "key"
+			config.Tailscale.Tailnet = "example.com"
+			config.DNS.Provider = tt.provider
+			config.DNS.Domain = "example.com"
+			config.DNS.Pihole.URL = "http://pihole"
+			config.DNS.AdGuardHome.URL = "http://adguard"
+			config.DNS.Etcd.Endpoints = []string{"http://127.0.0.1:2379"}
+
+			err := config.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateProviderDomainDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*Config)
+		domain func(*Config) string
+		want   string
+	}{
+		{
+			name:   "digitalocean default",
+			config: func(c *Config) { c.DNS.Provider = "digitalocean"; c.DNS.DigitalOcean.APIToken = "token" },
+			domain: func(c *Config) string { return c.DNS.DigitalOcean.Domain },
+			want:   "ts.example.com",
+		},
+		{
+			name: "digitalocean parent domain",
+			config: func(c *Config) {
+				c.DNS.Provider = "digitalocean"
+				c.DNS.DigitalOcean.APIToken = "token"
+				c.DNS.DigitalOcean.Domain = "example.com"
+			},
+			domain: func(c *Config) string { return c.DNS.DigitalOcean.Domain },
+			want:   "example.com",
+		},
+		{
+			name:   "gandi default",
+			config: func(c *Config) { c.DNS.Provider = "gandi"; c.DNS.Gandi.PersonalAccessToken = "token" },
+			domain: func(c *Config) string { return c.DNS.Gandi.Domain },
+			want:   "ts.example.com",
+		},
+		{
+			name: "gandi parent domain",
+			config: func(c *Config) {
+				c.DNS.Provider = "gandi"
+				c.DNS.Gandi.PersonalAccessToken = "token"
+				c.DNS.Gandi.Domain = "example.com"
+			},
+			domain: func(c *Config) string { return c.DNS.Gandi.Domain },
+			want:   "example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config Config
+			config.Tailscale.APIKey = "key"
+			config.Tailscale.Tailnet = "example.com"
+			config.DNS.Domain = "ts.example.com"
+			tt.config(&config)
+
+			if err := config.Validate(); err != nil {
+				t.Fatalf("Validate() = %v", err)
+			}
+			if got := tt.domain(&config); got != tt.want {
+				t.Fatalf("domain = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
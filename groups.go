@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jaxxstorm/dnsscale/providers"
+	"go.uber.org/zap"
+)
+
+// groupKeyPrefix marks queue items that refer to a group record rather than
+// a single node
+const groupKeyPrefix = "group:"
+
+// staleGroupsKey queues the removal of records left behind by groups that are
+// no longer configured
+const staleGroupsKey = "cleanup:groups"
+
+// groupOwnershipValue returns the TXT value marking a group record as managed
+// by dnsscale. Groups are owned independently of any member node.
+func groupOwnershipValue(name string) string {
+	return fmt.Sprintf("\"%s group=%s\"", providers.OwnershipPrefix, name)
+}
+
+// groupName returns the record label for a group, defaulting to the tag
+// without its "tag:" prefix
+func groupName(group GroupConfig) string {
+	if group.Name != "" {
+		return group.Name
+	}
+	return strings.TrimPrefix(group.Tag, "tag:")
+}
+
+// queueGroups queues every configured group so its membership is recomputed
+func (r *DNSReconciler) queueGroups() {
+	for _, group := range r.groups {
+		r.queue.Add(groupKeyPrefix + groupName(group))
+	}
+}
+
+// removeStaleGroups deletes group records whose group is no longer configured
+func (r *DNSReconciler) removeStaleGroups(ctx context.Context) error {
+	configured := make(map[string]bool)
+	for _, group := range r.groups {
+		configured[strings.Trim(groupOwnershipValue(groupName(group)), "\"")] = true
+	}
+	return r.removeStaleOwners(ctx, "group=", configured)
+}
+
+// reconcileGroup publishes the pooled records for a group from the addresses
+// of its current members
+func (r *DNSReconciler) reconcileGroup(ctx context.Context, name string) error {
+	var group GroupConfig
+	var found bool
+	for _, g := range r.groups {
+		if groupName(g) == name {
+			group = g
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("group %s is not configured", name)
+	}
+
+	recordName := fmt.Sprintf("%s.%s", name, r.domain)
+	owner := groupOwnershipValue(name)
+
+	var desired []providers.DNSRecord
+	var members []string
+
+	r.cacheMutex.RLock()
+	for _, node := range r.nodeCache {
+		if node.Name == name && r.shouldManageNode(node) {
+			r.cacheMutex.RUnlock()
+			r.logger.Warn("Skipping group whose name collides with a node",
+				zap.String("group", name),
+				zap.String("node_id", node.ID))
+			return nil
+		}
+
+		if !nodeHasTag(node, group.Tag) || !r.shouldManageNode(node) {
+			continue
+		}
+		if group.OnlineOnly && !node.Online {
+			continue
+		}
+		if !r.health.healthy(node, false) {
+			continue
+		}
+
+		members = append(members, node.Name)
+		desired = append(desired, r.addressRecords(node, recordName, r.settingsForNode(node))...)
+	}
+	r.cacheMutex.RUnlock()
+	sort.Strings(members)
+
+	if err := r.syncOwnedRecords(ctx, recordName, owner, []string{"A", "AAAA"}, desired); err != nil {
+		return err
+	}
+
+	r.logger.Info("Reconciled group record",
+		zap.String("record_name", recordName),
+		zap.String("group", name),
+		zap.Strings("members", members))
+
+	return nil
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// maxConcurrentProbes limits how many health probes run at once, so a large
+// tailnet doesn't open hundreds of connections every interval
+const maxConcurrentProbes = 8
+
+// healthTick is how often the prober looks for nodes that are due a probe
+const healthTick = time.Second
+
+// Supported health check types
+const (
+	healthCheckTCP  = "tcp"
+	healthCheckHTTP = "http"
+)
+
+// probeResult is the cached outcome of one health check against one node
+type probeResult struct {
+	Check     string        `json:"check"`
+	Address   string        `json:"address"`
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// healthChecker actively probes nodes over their Tailscale addresses and
+// caches the results. Nodes are treated as healthy until a probe fails, so
+// records stay stable while dnsscale starts up.
+type healthChecker struct {
+	checks   []HealthCheckConfig
+	results  map[string]probeResult // Keyed by node ID and check name
+	inFlight map[string]bool
+	mu       sync.RWMutex
+	sem      chan struct{}
+	logger   *zap.Logger
+}
+
+func newHealthChecker(checks []HealthCheckConfig, logger *zap.Logger) *healthChecker {
+	return &healthChecker{
+		checks:   checks,
+		results:  make(map[string]probeResult),
+		inFlight: make(map[string]bool),
+		sem:      make(chan struct{}, maxConcurrentProbes),
+		logger:   logger,
+	}
+}
+
+func probeKey(nodeID, check string) string {
+	return nodeID + "/" + check
+}
+
+// checkApplies reports whether a health check probes a node
+func checkApplies(check HealthCheckConfig, node TailscaleNode) bool {
+	for _, tag := range check.Tags {
+		if nodeHasTag(node, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// healthy reports whether a node passes its health checks. When
+// forNodeRecords is set only checks that also gate the node's own records are
+// considered. A nil checker treats every node as healthy.
+func (h *healthChecker) healthy(node TailscaleNode, forNodeRecords bool) bool {
+	if h == nil {
+		return true
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, check := range h.checks {
+		if forNodeRecords && !check.NodeRecords {
+			continue
+		}
+		if !checkApplies(check, node) {
+			continue
+		}
+		if result, ok := h.results[probeKey(node.ID, check.Name)]; ok && !result.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeResults returns the cached probe results for a node
+func (h *healthChecker) nodeResults(nodeID string) []probeResult {
+	if h == nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var results []probeResult
+	for _, check := range h.checks {
+		if result, ok := h.results[probeKey(nodeID, check.Name)]; ok {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// watchHealth probes nodes as their results expire and queues reconciliation
+// whenever a node's health changes
+func (r *DNSReconciler) watchHealth(ctx context.Context) {
+	ticker := time.NewTicker(healthTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.scheduleProbes(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// scheduleProbes starts a probe for every node and check whose cached result
+// is older than the check's interval, and forgets results for removed nodes
+func (r *DNSReconciler) scheduleProbes(ctx context.Context) {
+	h := r.health
+
+	r.cacheMutex.RLock()
+	nodes := make([]TailscaleNode, 0, len(r.nodeCache))
+	for _, node := range r.nodeCache {
+		if r.shouldManageNode(node) {
+			nodes = append(nodes, node)
+		}
+	}
+	r.cacheMutex.RUnlock()
+
+	current := make(map[string]bool)
+	now := time.Now()
+
+	h.mu.Lock()
+	var due []func()
+	for _, node := range nodes {
+		for _, check := range h.checks {
+			if !checkApplies(check, node) {
+				continue
+			}
+
+			key := probeKey(node.ID, check.Name)
+			current[key] = true
+
+			if h.inFlight[key] {
+				continue
+			}
+			if result, ok := h.results[key]; ok && now.Sub(result.CheckedAt) < check.Interval {
+				continue
+			}
+
+			h.inFlight[key] = true
+			due = append(due, func() { r.runProbe(ctx, node, check) })
+		}
+	}
+
+	for key := range h.results {
+		if !current[key] {
+			delete(h.results, key)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, probe := range due {
+		go probe()
+	}
+}
+
+// runProbe probes a node once, stores the result and queues reconciliation if
+// the node's health changed
+func (r *DNSReconciler) runProbe(ctx context.Context, node TailscaleNode, check HealthCheckConfig) {
+	h := r.health
+	key := probeKey(node.ID, check.Name)
+
+	select {
+	case h.sem <- struct{}{}:
+	case <-ctx.Done():
+		h.mu.Lock()
+		delete(h.inFlight, key)
+		h.mu.Unlock()
+		return
+	}
+	result := probeNode(ctx, node, check)
+	<-h.sem
+
+	h.mu.Lock()
+	previous, seen := h.results[key]
+	h.results[key] = result
+	delete(h.inFlight, key)
+	h.mu.Unlock()
+
+	r.logger.Debug("Health probe completed",
+		zap.String("node_name", node.Name),
+		zap.String("check", check.Name),
+		zap.String("address", result.Address),
+		zap.Bool("healthy", result.Healthy),
+		zap.Duration("latency", result.Latency),
+		zap.String("error", result.Error))
+
+	// Nodes start out healthy, so only a failure or a recovery is a change
+	if (seen && previous.Healthy == result.Healthy) || (!seen && result.Healthy) {
+		return
+	}
+
+	r.logger.Info("Node health changed",
+		zap.String("node_name", node.Name),
+		zap.String("node_id", node.ID),
+		zap.String("check", check.Name),
+		zap.Bool("healthy", result.Healthy),
+		zap.String("error", result.Error))
+
+	if check.NodeRecords {
+		r.queue.Add(node.ID)
+	}
+	r.queueGroups()
+	r.queueServices()
+}
+
+// probeAddress picks the address a node is probed on, preferring IPv4
+func probeAddress(node TailscaleNode) (netip.Addr, bool) {
+	var fallback netip.Addr
+	for _, raw := range node.Addresses {
+		addr, err := parseNodeAddress(raw)
+		if err != nil {
+			continue
+		}
+		if addr.Is4() {
+			return addr, true
+		}
+		if !fallback.IsValid() {
+			fallback = addr
+		}
+	}
+	return fallback, fallback.IsValid()
+}
+
+// probeNode runs a single health check against a node
+func probeNode(ctx context.Context, node TailscaleNode, check HealthCheckConfig) probeResult {
+	result := probeResult{
+		Check:     check.Name,
+		CheckedAt: time.Now(),
+	}
+
+	addr, ok := probeAddress(node)
+	if !ok {
+		result.Error = "node has no usable address"
+		return result
+	}
+	target := netip.AddrPortFrom(addr, check.Port)
+	result.Address = target.String()
+
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch check.Type {
+	case healthCheckHTTP:
+		err = probeHTTP(ctx, target, check.Path)
+	default:
+		err = probeTCP(ctx, target)
+	}
+	result.Latency = time.Since(start)
+
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+func probeTCP(ctx context.Context, target netip.AddrPort) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", target.String())
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// probeHTTP succeeds when a GET of path returns a 2xx or 3xx status
+func probeHTTP(ctx context.Context, target netip.AddrPort, path string) error {
+	probeURL := "http://" + net.JoinHostPort(target.Addr().String(), strconv.Itoa(int(target.Port()))) + path
+	req, err := http.NewRequestWithContext(ctx, "GET", probeURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	// Redirects are a healthy response, not something to follow
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("unhealthy status %d", resp.StatusCode)
+	}
+	return nil
+}
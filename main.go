@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
@@ -57,6 +58,10 @@ type TailscaleNode struct {
 	Tags      []string  `json:"tags"`
 	Online    bool      `json:"online"`
 	LastSeen  time.Time `json:"last_seen"`
+
+	// Attributes holds the device's posture attributes, only populated when
+	// a service matches on them
+	Attributes map[string]string `json:"attributes,omitempty"`
 }
 
 // ToTailscaleNode converts a TailscaleDevice to a simplified TailscaleNode
@@ -87,6 +92,12 @@ func (d *TailscaleDevice) ToTailscaleNode() TailscaleNode {
 	}
 }
 
+// TailscaleDeviceAttributesResponse represents the API response for a
+// device's posture attributes
+type TailscaleDeviceAttributesResponse struct {
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
 // TailscaleClient handles Tailscale API interactions
 type TailscaleClient struct {
 	apiKey     string
@@ -94,6 +105,13 @@ type TailscaleClient struct {
 	logger     *zap.Logger
 	httpClient *http.Client
 	baseURL    string
+
+	// attributesFor reports whether a device's posture attributes have to be
+	// fetched, nil if no device needs them
+	attributesFor func(node TailscaleNode) bool
+	// Attributes from the last successful fetch, keyed by device ID, used
+	// when fetching fails. Only accessed from ListNodes.
+	attributes map[string]map[string]string
 }
 
 func NewTailscaleClient(apiKey, tailnet string, logger *zap.Logger) *TailscaleClient {
@@ -115,37 +133,14 @@ func (t *TailscaleClient) ListNodes(ctx context.Context) ([]TailscaleNode, error
 		zap.String("url", apiURL),
 		zap.String("tailnet", t.tailnet))
 
-	// Create the request
-	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set authentication header
-	req.Header.Set("Authorization", "Bearer "+t.apiKey)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "dnsscale/1.0")
-
-	// Make the request
-	resp, err := t.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Check for API errors
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, resp.Status)
-	}
-
-	// Parse the response
 	var devicesResp TailscaleDevicesResponse
-	if err := json.NewDecoder(resp.Body).Decode(&devicesResp); err != nil {
-		return nil, fmt.Errorf("failed to decode API response: %w", err)
+	if err := t.get(ctx, apiURL, &devicesResp); err != nil {
+		return nil, err
 	}
 
 	// Convert devices to nodes
 	nodes := make([]TailscaleNode, 0, len(devicesResp.Devices))
+	fetched := make(map[string]map[string]string)
 	for _, device := range devicesResp.Devices {
 		// Only include authorized devices
 		if !device.Authorized {
@@ -156,6 +151,10 @@ func (t *TailscaleClient) ListNodes(ctx context.Context) ([]TailscaleNode, error
 		}
 
 		node := device.ToTailscaleNode()
+		if t.attributesFor != nil && t.attributesFor(node) {
+			node.Attributes = t.nodeAttributes(ctx, node)
+			fetched[node.ID] = node.Attributes
+		}
 		nodes = append(nodes, node)
 
 		t.logger.Debug("Found device",
@@ -166,6 +165,8 @@ func (t *TailscaleClient) ListNodes(ctx context.Context) ([]TailscaleNode, error
 			zap.Strings("tags", node.Tags))
 	}
 
+	t.attributes = fetched
+
 	t.logger.Info("Retrieved devices from Tailscale API",
 		zap.Int("total_devices", len(devicesResp.Devices)),
 		zap.Int("authorized_devices", len(nodes)))
@@ -173,6 +174,71 @@ func (t *TailscaleClient) ListNodes(ctx context.Context) ([]TailscaleNode, error
 	return nodes, nil
 }
 
+// nodeAttributes fetches a node's posture attributes. If that fails the
+// attributes from the previous poll are kept, so one failing device doesn't
+// hold up the whole sync or drop out of services.
+func (t *TailscaleClient) nodeAttributes(ctx context.Context, node TailscaleNode) map[string]string {
+	attributes, err := t.deviceAttributes(ctx, node.ID)
+	if err != nil {
+		t.logger.Warn("Failed to fetch device attributes, keeping previous attributes",
+			zap.String("device_name", node.Name),
+			zap.String("device_id", node.ID),
+			zap.Error(err))
+		return t.attributes[node.ID]
+	}
+	return attributes
+}
+
+// deviceAttributes fetches a device's posture attributes. Values are
+// converted to strings so they can be matched against configuration.
+func (t *TailscaleClient) deviceAttributes(ctx context.Context, deviceID string) (map[string]string, error) {
+	apiURL := fmt.Sprintf("%s/api/v2/device/%s/attributes", t.baseURL, url.PathEscape(deviceID))
+
+	var attributesResp TailscaleDeviceAttributesResponse
+	if err := t.get(ctx, apiURL, &attributesResp); err != nil {
+		return nil, fmt.Errorf("failed to get attributes for device %s: %w", deviceID, err)
+	}
+
+	attributes := make(map[string]string, len(attributesResp.Attributes))
+	for key, value := range attributesResp.Attributes {
+		attributes[key] = fmt.Sprint(value)
+	}
+	return attributes, nil
+}
+
+// get makes an authenticated GET request to the Tailscale API and decodes the
+// JSON response into out
+func (t *TailscaleClient) get(ctx context.Context, apiURL string, out interface{}) error {
+	// Create the request
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set authentication header
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	// Make the request
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check for API errors
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, resp.Status)
+	}
+
+	// Parse the response
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode API response: %w", err)
+	}
+	return nil
+}
+
 // DNSReconciler is the main reconciliation controller
 type DNSReconciler struct {
 	tailscale    *TailscaleClient
@@ -183,6 +249,12 @@ type DNSReconciler struct {
 	cacheMutex   sync.RWMutex
 	pollInterval time.Duration
 	annotations  map[string]string // For filtering based on tags
+	settings     nodeSettings      // Publishing settings before tag overrides
+	tagOverrides []TagOverride
+	groups       []GroupConfig
+	services     []ServiceConfig
+	health       *healthChecker // nil when no health checks are configured
+	statusAddr   string
 	logger       *zap.Logger
 }
 
@@ -195,6 +267,7 @@ func NewDNSReconciler(ts *TailscaleClient, dns providers.DNSProvider, domain str
 		nodeCache:    make(map[string]TailscaleNode),
 		pollInterval: pollInterval,
 		annotations:  make(map[string]string),
+		settings:     nodeSettings{addressFamily: addressFamilyBoth},
 		logger:       logger,
 	}
 }
@@ -211,6 +284,16 @@ func (r *DNSReconciler) Run(ctx context.Context, workers int) error {
 	// Start the Tailscale watcher
 	go r.watchTailscale(ctx)
 
+	if r.health != nil {
+		go r.watchHealth(ctx)
+	}
+	if r.statusAddr != "" {
+		go r.serveStatus(ctx, r.statusAddr)
+	}
+	if notifier, ok := r.dnsProvider.(providers.ResyncNotifier); ok {
+		go r.watchResync(ctx, notifier.Resync())
+	}
+
 	// Start workers
 	var wg sync.WaitGroup
 	for i := 0; i < workers; i++ {
@@ -236,6 +319,11 @@ func (r *DNSReconciler) watchTailscale(ctx context.Context) {
 	// Initial sync
 	r.syncNodes(ctx)
 
+	// Clean up after groups and services removed from the configuration
+	// since the last run
+	r.queue.Add(staleGroupsKey)
+	r.queue.Add(staleServicesKey)
+
 	for {
 		select {
 		case <-ticker.C:
@@ -246,6 +334,32 @@ func (r *DNSReconciler) watchTailscale(ctx context.Context) {
 	}
 }
 
+// watchResync republishes every record whenever the provider reports having
+// lost records
+func (r *DNSReconciler) watchResync(ctx context.Context, resync <-chan struct{}) {
+	for {
+		select {
+		case <-resync:
+			r.logger.Warn("DNS provider lost records, publishing every record again")
+			r.queueAll()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// queueAll queues every cached node, group and service
+func (r *DNSReconciler) queueAll() {
+	r.cacheMutex.RLock()
+	for id := range r.nodeCache {
+		r.queue.Add(id)
+	}
+	r.cacheMutex.RUnlock()
+
+	r.queueGroups()
+	r.queueServices()
+}
+
 // syncNodes fetches current state from Tailscale and queues changes
 func (r *DNSReconciler) syncNodes(ctx context.Context) {
 	nodes, err := r.tailscale.ListNodes(ctx)
@@ -260,6 +374,7 @@ func (r *DNSReconciler) syncNodes(ctx context.Context) {
 	defer r.cacheMutex.Unlock()
 
 	currentNodes := make(map[string]bool)
+	changed := false
 
 	// Check for new or updated nodes
 	for _, node := range nodes {
@@ -268,6 +383,7 @@ func (r *DNSReconciler) syncNodes(ctx context.Context) {
 		if existingNode, exists := r.nodeCache[node.ID]; !exists || !nodesEqual(existingNode, node) {
 			r.nodeCache[node.ID] = node
 			r.queue.Add(node.ID)
+			changed = true
 			r.logger.Info("Queuing node for reconciliation",
 				zap.String("node_name", node.Name),
 				zap.String("node_id", node.ID),
@@ -281,9 +397,16 @@ func (r *DNSReconciler) syncNodes(ctx context.Context) {
 		if !currentNodes[id] {
 			delete(r.nodeCache, id)
 			r.queue.Add(id + ":delete")
+			changed = true
 			r.logger.Info("Queuing node for deletion", zap.String("node_id", id))
 		}
 	}
+
+	// Any node change can alter group membership and service targets
+	if changed {
+		r.queueGroups()
+		r.queueServices()
+	}
 }
 
 // worker processes items from the queue
@@ -317,6 +440,22 @@ func (r *DNSReconciler) reconcile(ctx context.Context, key string) error {
 		return r.deleteNodeDNS(ctx, nodeID)
 	}
 
+	if key == staleGroupsKey {
+		return r.removeStaleGroups(ctx)
+	}
+
+	if strings.HasPrefix(key, groupKeyPrefix) {
+		return r.reconcileGroup(ctx, strings.TrimPrefix(key, groupKeyPrefix))
+	}
+
+	if key == staleServicesKey {
+		return r.removeStaleServices(ctx)
+	}
+
+	if strings.HasPrefix(key, serviceKeyPrefix) {
+		return r.reconcileService(ctx, strings.TrimPrefix(key, serviceKeyPrefix))
+	}
+
 	r.cacheMutex.RLock()
 	node, exists := r.nodeCache[key]
 	r.cacheMutex.RUnlock()
@@ -335,48 +474,112 @@ func (r *DNSReconciler) reconcile(ctx context.Context, key string) error {
 
 	// Create DNS records for the node
 	recordName := fmt.Sprintf("%s.%s", node.Name, r.domain)
+	settings := r.settingsForNode(node)
+
+	existing, err := r.dnsProvider.ListRecords(ctx, r.domain)
+	if err != nil {
+		return fmt.Errorf("failed to list existing DNS records: %w", err)
+	}
 
-	for _, addr := range node.Addresses {
-		recordType := "A"
-		if strings.Contains(addr, ":") {
-			recordType = "AAAA"
+	// Records for address families that are no longer wanted are removed here too
+	var desired []providers.DNSRecord
+	if r.health.healthy(node, true) {
+		desired = r.addressRecords(node, recordName, settings)
+	} else {
+		r.logger.Info("Withdrawing records for unhealthy node",
+			zap.String("node_name", node.Name),
+			zap.String("node_id", node.ID))
+	}
+	if err := r.syncRecords(ctx, existing, recordName, []string{"A", "AAAA"}, desired); err != nil {
+		return err
+	}
+
+	// Create TXT ownership record to indicate this record is managed by dnsscale
+	owner := nodeOwnershipValue(node.ID)
+	r.updateOwnershipRecord(ctx, recordName, owner)
+
+	// Wildcard records let nodes serving many virtual hosts resolve any
+	// subdomain, and share the node's ownership tracking
+	wildcardName := "*." + recordName
+	if settings.wildcard {
+		var desired []providers.DNSRecord
+		if r.health.healthy(node, true) {
+			desired = r.addressRecords(node, wildcardName, settings)
 		}
+		if err := r.syncRecords(ctx, existing, wildcardName, []string{"A", "AAAA"}, desired); err != nil {
+			return err
+		}
+		r.updateOwnershipRecord(ctx, wildcardName, owner)
+	} else if ownsName(existing, wildcardName, owner) {
+		r.logger.Info("Removing wildcard records no longer wanted for node",
+			zap.String("record_name", wildcardName),
+			zap.String("node_name", node.Name))
+		r.deleteRecordsNamed(ctx, existing, wildcardName)
+	}
 
-		record := providers.DNSRecord{
-			Name:  recordName,
-			Type:  recordType,
-			Value: addr,
-			TTL:   300,
+	return nil
+}
+
+// syncRecords makes the provider's records of the given types at name match
+// desired. Missing values are created and any other values are deleted.
+func (r *DNSReconciler) syncRecords(ctx context.Context, existing []providers.DNSRecord, name string, recordTypes []string, desired []providers.DNSRecord) error {
+	for _, recordType := range recordTypes {
+		current := make(map[string]providers.DNSRecord)
+		for _, record := range existing {
+			if record.Type == recordType && recordNamesEqual(record.Name, name) {
+				current[recordValueKey(record)] = record
+			}
 		}
 
-		if err := r.dnsProvider.UpdateRecord(ctx, r.domain, record); err != nil {
-			return fmt.Errorf("failed to update DNS record: %w", err)
+		wanted := make(map[string]providers.DNSRecord)
+		for _, record := range desired {
+			if record.Type == recordType {
+				wanted[recordValueKey(record)] = record
+			}
 		}
 
-		r.logger.Info("Updated DNS record",
-			zap.String("record_type", recordType),
-			zap.String("record_name", record.Name),
-			zap.String("record_value", addr),
-			zap.String("node_name", node.Name))
-	}
+		// Replace a lone value in place so the name never goes unresolvable
+		if len(current) == 1 && len(wanted) == 1 {
+			for key, record := range wanted {
+				if _, ok := current[key]; ok {
+					continue
+				}
+				if err := r.dnsProvider.UpdateRecord(ctx, r.domain, record); err != nil {
+					return fmt.Errorf("failed to update DNS record: %w", err)
+				}
+				r.logger.Info("Updated DNS record",
+					zap.String("record_type", record.Type),
+					zap.String("record_name", record.Name),
+					zap.String("record_value", record.Value))
+				current = map[string]providers.DNSRecord{key: record}
+			}
+		}
 
-	// Create TXT ownership record to indicate this record is managed by dnsscale
-	txtRecord := providers.DNSRecord{
-		Name:  recordName,
-		Type:  "TXT",
-		Value: fmt.Sprintf("\"dnsscale-managed node_id=%s\"", node.ID),
-		TTL:   300,
-	}
+		for key, record := range wanted {
+			if _, ok := current[key]; ok {
+				continue
+			}
+			if err := r.dnsProvider.CreateRecord(ctx, r.domain, record); err != nil {
+				return fmt.Errorf("failed to create DNS record: %w", err)
+			}
+			r.logger.Info("Created DNS record",
+				zap.String("record_type", record.Type),
+				zap.String("record_name", record.Name),
+				zap.String("record_value", record.Value))
+		}
 
-	if err := r.dnsProvider.UpdateRecord(ctx, r.domain, txtRecord); err != nil {
-		r.logger.Warn("Failed to create TXT ownership record",
-			zap.String("record_name", txtRecord.Name),
-			zap.Error(err))
-		// Don't fail the entire reconciliation if TXT record fails
-	} else {
-		r.logger.Info("Updated TXT ownership record",
-			zap.String("record_name", txtRecord.Name),
-			zap.String("record_value", txtRecord.Value))
+		for key, record := range current {
+			if _, ok := wanted[key]; ok {
+				continue
+			}
+			if err := r.dnsProvider.DeleteRecord(ctx, r.domain, record); err != nil {
+				return fmt.Errorf("failed to delete DNS record: %w", err)
+			}
+			r.logger.Info("Deleted DNS record",
+				zap.String("record_type", record.Type),
+				zap.String("record_name", record.Name),
+				zap.String("record_value", record.Value))
+		}
 	}
 
 	return nil
@@ -391,31 +594,17 @@ func (r *DNSReconciler) deleteNodeDNS(ctx context.Context, nodeID string) error
 		return err
 	}
 
+	owner := nodeOwnershipValue(nodeID)
 	for _, record := range records {
-		// Check if this is a TXT record managed by us with the specific node ID
-		if record.Type == "TXT" && strings.Contains(record.Value, fmt.Sprintf("node_id=%s", nodeID)) {
-			// This is our ownership record, delete all records with this name
-			recordName := record.Name
+		// Check if this is a TXT record managed by us with the specific node ID.
+		// A node may own several names, such as its wildcard record.
+		if isOwnershipRecord(record, owner) {
 			r.logger.Info("Found dnsscale-managed record to delete",
-				zap.String("record_name", recordName),
+				zap.String("record_name", record.Name),
 				zap.String("node_id", nodeID))
 
 			// Delete all records (A, AAAA, TXT) with this name
-			for _, recordToDelete := range records {
-				if recordToDelete.Name == recordName {
-					if err := r.dnsProvider.DeleteRecord(ctx, r.domain, recordToDelete); err != nil {
-						r.logger.Error("Failed to delete DNS record",
-							zap.String("record_name", recordToDelete.Name),
-							zap.String("record_type", recordToDelete.Type),
-							zap.Error(err))
-					} else {
-						r.logger.Info("Deleted DNS record",
-							zap.String("record_name", recordToDelete.Name),
-							zap.String("record_type", recordToDelete.Type))
-					}
-				}
-			}
-			break // We found our record, no need to continue
+			r.deleteRecordsNamed(ctx, records, record.Name)
 		}
 	}
 
@@ -438,7 +627,7 @@ func (r *DNSReconciler) shouldManageNode(node TailscaleNode) bool {
 
 // Helper function to compare nodes
 func nodesEqual(a, b TailscaleNode) bool {
-	if a.Name != b.Name || a.Online != b.Online || len(a.Addresses) != len(b.Addresses) {
+	if a.Name != b.Name || a.Online != b.Online || len(a.Addresses) != len(b.Addresses) || len(a.Tags) != len(b.Tags) {
 		return false
 	}
 
@@ -448,7 +637,14 @@ func nodesEqual(a, b TailscaleNode) bool {
 		}
 	}
 
-	return true
+	// Tags decide which overrides apply, so a tag change needs a reconcile
+	for i, tag := range a.Tags {
+		if tag != b.Tags[i] {
+			return false
+		}
+	}
+
+	return maps.Equal(a.Attributes, b.Attributes)
 }
 
 // setupLogger creates a Zap logger with the specified config
@@ -490,6 +686,211 @@ func createDNSProvider(ctx context.Context, config *Config, logger *zap.Logger)
 		}
 		logger.Info("Initializing Cloudflare DNS provider", zap.String("zone_id", config.DNS.ZoneID))
 		return providers.NewCloudflareProvider(config.DNS.Cloudflare.APIToken, config.DNS.ZoneID)
+	case "gcloud":
+		logger.Info("Initializing Google Cloud DNS provider",
+			zap.String("project", config.DNS.GCloud.Project),
+			zap.String("managed_zone", config.DNS.ZoneID))
+		return providers.NewGoogleCloudDNSProvider(ctx, providers.GoogleCloudDNSConfig{
+			Project:         config.DNS.GCloud.Project,
+			ManagedZone:     config.DNS.ZoneID,
+			CredentialsFile: config.DNS.GCloud.CredentialsFile,
+			Endpoint:        config.DNS.GCloud.Endpoint,
+		})
+	case "azure":
+		logger.Info("Initializing Azure DNS provider",
+			zap.String("resource_group", config.DNS.Azure.ResourceGroup),
+			zap.String("zone_name", config.DNS.Azure.ZoneName),
+			zap.Bool("managed_identity", config.DNS.Azure.UseManagedIdentity))
+		return providers.NewAzureDNSProvider(ctx, providers.AzureDNSConfig{
+			SubscriptionID:     config.DNS.Azure.SubscriptionID,
+			ResourceGroup:      config.DNS.Azure.ResourceGroup,
+			ZoneName:           config.DNS.Azure.ZoneName,
+			TenantID:           config.DNS.Azure.TenantID,
+			ClientID:           config.DNS.Azure.ClientID,
+			ClientSecret:       config.DNS.Azure.ClientSecret,
+			UseManagedIdentity: config.DNS.Azure.UseManagedIdentity,
+			Endpoint:           config.DNS.Azure.Endpoint,
+			AuthorityHost:      config.DNS.Azure.AuthorityHost,
+		})
+	case "digitalocean":
+		logger.Info("Initializing DigitalOcean DNS provider", zap.String("domain", config.DNS.DigitalOcean.Domain))
+		return providers.NewDigitalOceanProvider(config.DNS.DigitalOcean.APIToken, config.DNS.DigitalOcean.Domain)
+	case "rfc2136":
+		logger.Info("Initializing RFC 2136 DNS provider",
+			zap.String("server", config.DNS.RFC2136.Server),
+			zap.String("zone", config.DNS.RFC2136.Zone),
+			zap.Bool("tsig", config.DNS.RFC2136.TSIGKeyName != ""))
+		return providers.NewRFC2136Provider(providers.RFC2136Config{
+			Server:        config.DNS.RFC2136.Server,
+			Zone:          config.DNS.RFC2136.Zone,
+			TSIGKeyName:   config.DNS.RFC2136.TSIGKeyName,
+			TSIGSecret:    config.DNS.RFC2136.TSIGSecret,
+			TSIGAlgorithm: config.DNS.RFC2136.TSIGAlgorithm,
+			Timeout:       config.DNS.RFC2136.Timeout,
+		})
+	case "powerdns":
+		logger.Info("Initializing PowerDNS DNS provider",
+			zap.String("api_url", config.DNS.PowerDNS.APIURL),
+			zap.String("server_id", config.DNS.PowerDNS.ServerID),
+			zap.String("zone", config.DNS.PowerDNS.Zone))
+		return providers.NewPowerDNSProvider(providers.PowerDNSConfig{
+			APIURL:   config.DNS.PowerDNS.APIURL,
+			APIKey:   config.DNS.PowerDNS.APIKey,
+			ServerID: config.DNS.PowerDNS.ServerID,
+			Zone:     config.DNS.PowerDNS.Zone,
+			Rectify:  config.DNS.PowerDNS.Rectify,
+			Notify:   config.DNS.PowerDNS.Notify,
+		})
+	case "hetzner":
+		logger.Info("Initializing Hetzner DNS provider",
+			zap.String("zone_id", config.DNS.ZoneID),
+			zap.String("zone_name", config.DNS.Hetzner.ZoneName))
+		return providers.NewHetznerDNSProvider(ctx, config.DNS.Hetzner.APIToken, config.DNS.ZoneID, config.DNS.Hetzner.ZoneName)
+	case "zonefile":
+		logger.Info("Initializing zone file DNS provider",
+			zap.String("path", config.DNS.ZoneFile.Path),
+			zap.String("zone", config.DNS.ZoneFile.Zone),
+			zap.Bool("fragment", config.DNS.ZoneFile.Fragment))
+		return providers.NewZoneFileProvider(providers.ZoneFileConfig{
+			Path:          config.DNS.ZoneFile.Path,
+			Zone:          config.DNS.ZoneFile.Zone,
+			Fragment:      config.DNS.ZoneFile.Fragment,
+			PrimaryNS:     config.DNS.ZoneFile.PrimaryNS,
+			Hostmaster:    config.DNS.ZoneFile.Hostmaster,
+			Nameservers:   config.DNS.ZoneFile.Nameservers,
+			ReloadCommand: config.DNS.ZoneFile.ReloadCommand,
+			ReloadTimeout: config.DNS.ZoneFile.ReloadTimeout,
+		})
+	case "hosts":
+		logger.Info("Initializing hosts file DNS provider",
+			zap.String("path", config.DNS.Hosts.Path),
+			zap.String("pid_file", config.DNS.Hosts.PIDFile))
+		return providers.NewHostsFileProvider(providers.HostsFileConfig{
+			Path:    config.DNS.Hosts.Path,
+			Marker:  config.DNS.Hosts.Marker,
+			PIDFile: config.DNS.Hosts.PIDFile,
+			Signal:  config.DNS.Hosts.Signal,
+		})
+	case "pihole":
+		logger.Info("Initializing Pi-hole DNS provider",
+			zap.String("url", config.DNS.Pihole.URL),
+			zap.String("state_file", config.DNS.Pihole.StateFile))
+		return providers.NewPiholeProvider(config.DNS.Pihole.URL, config.DNS.Pihole.Password, config.DNS.Pihole.StateFile)
+	case "adguardhome":
+		logger.Info("Initializing AdGuard Home DNS provider",
+			zap.String("url", config.DNS.AdGuardHome.URL),
+			zap.String("state_file", config.DNS.AdGuardHome.StateFile))
+		return providers.NewAdGuardHomeProvider(config.DNS.AdGuardHome.URL, config.DNS.AdGuardHome.Username, config.DNS.AdGuardHome.Password, config.DNS.AdGuardHome.StateFile)
+	case "infoblox":
+		logger.Info("Initializing Infoblox DNS provider",
+			zap.String("url", config.DNS.Infoblox.URL),
+			zap.String("view", config.DNS.Infoblox.View),
+			zap.String("zone", config.DNS.Infoblox.Zone))
+		return providers.NewInfobloxProvider(providers.InfobloxConfig{
+			URL:                config.DNS.Infoblox.URL,
+			WAPIVersion:        config.DNS.Infoblox.WAPIVersion,
+			Username:           config.DNS.Infoblox.Username,
+			Password:           config.DNS.Infoblox.Password,
+			View:               config.DNS.Infoblox.View,
+			Zone:               config.DNS.Infoblox.Zone,
+			OwnerAttribute:     config.DNS.Infoblox.OwnerAttribute,
+			CreatePTR:          config.DNS.Infoblox.CreatePTR,
+			InsecureSkipVerify: config.DNS.Infoblox.InsecureSkipVerify,
+			CAFile:             config.DNS.Infoblox.CAFile,
+		})
+	case "ns1":
+		logger.Info("Initializing NS1 DNS provider", zap.String("zone", config.DNS.NS1.Zone))
+		return providers.NewNS1Provider(config.DNS.NS1.APIKey, config.DNS.NS1.Zone, config.DNS.NS1.Endpoint, config.DNS.NS1.Meta)
+	case "dnsimple":
+		logger.Info("Initializing DNSimple DNS provider",
+			zap.String("account_id", config.DNS.DNSimple.AccountID),
+			zap.String("zone", config.DNS.DNSimple.Zone),
+			zap.Bool("sandbox", config.DNS.DNSimple.Sandbox))
+		return providers.NewDNSimpleProvider(config.DNS.DNSimple.APIToken, config.DNS.DNSimple.AccountID, config.DNS.DNSimple.Zone, config.DNS.DNSimple.Sandbox)
+	case "gandi":
+		logger.Info("Initializing Gandi LiveDNS DNS provider", zap.String("domain", config.DNS.Gandi.Domain))
+		return providers.NewGandiLiveDNSProvider(config.DNS.Gandi.PersonalAccessToken, config.DNS.Gandi.Domain, config.DNS.Gandi.Endpoint)
+	case "technitium":
+		logger.Info("Initializing Technitium DNS provider",
+			zap.String("url", config.DNS.Technitium.URL),
+			zap.String("zone", config.DNS.Technitium.Zone))
+		return providers.NewTechnitiumProvider(config.DNS.Technitium.URL, config.DNS.Technitium.APIToken, config.DNS.Technitium.Zone)
+	case "etcd":
+		logger.Info("Initializing etcd DNS provider",
+			zap.Strings("endpoints", config.DNS.Etcd.Endpoints),
+			zap.String("prefix", config.DNS.Etcd.Prefix),
+			zap.String("zone", config.DNS.Etcd.Zone),
+			zap.Duration("lease_ttl", config.DNS.Etcd.LeaseTTL))
+		return providers.NewEtcdProvider(providers.EtcdConfig{
+			Endpoints: config.DNS.Etcd.Endpoints,
+			Username:  config.DNS.Etcd.Username,
+			Password:  config.DNS.Etcd.Password,
+			CertFile:  config.DNS.Etcd.CertFile,
+			KeyFile:   config.DNS.Etcd.KeyFile,
+			CAFile:    config.DNS.Etcd.CAFile,
+			Prefix:    config.DNS.Etcd.Prefix,
+			Zone:      config.DNS.Etcd.Zone,
+			Timeout:   config.DNS.Etcd.Timeout,
+			LeaseTTL:  config.DNS.Etcd.LeaseTTL,
+		})
+	case "coredns":
+		logger.Info("Initializing CoreDNS ConfigMap DNS provider",
+			zap.String("namespace", config.DNS.CoreDNS.Namespace),
+			zap.String("configmap", config.DNS.CoreDNS.ConfigMap),
+			zap.String("key", config.DNS.CoreDNS.Key))
+		return providers.NewCoreDNSConfigMapProvider(providers.CoreDNSConfigMapConfig{
+			Kubeconfig: config.DNS.CoreDNS.Kubeconfig,
+			Context:    config.DNS.CoreDNS.Context,
+			Namespace:  config.DNS.CoreDNS.Namespace,
+			ConfigMap:  config.DNS.CoreDNS.ConfigMap,
+			Key:        config.DNS.CoreDNS.Key,
+			Marker:     config.DNS.CoreDNS.Marker,
+		})
+	case "routeros":
+		logger.Info("Initializing RouterOS DNS provider",
+			zap.String("url", config.DNS.RouterOS.URL),
+			zap.String("zone", config.DNS.RouterOS.Zone))
+		return providers.NewRouterOSProvider(providers.RouterOSConfig{
+			URL:                config.DNS.RouterOS.URL,
+			Username:           config.DNS.RouterOS.Username,
+			Password:           config.DNS.RouterOS.Password,
+			Zone:               config.DNS.RouterOS.Zone,
+			InsecureSkipVerify: config.DNS.RouterOS.InsecureSkipVerify,
+			CAFile:             config.DNS.RouterOS.CAFile,
+		})
+	case "builtin":
+		logger.Info("Initializing built-in DNS server",
+			zap.Strings("listen", config.DNS.Builtin.Listen),
+			zap.String("zone", config.DNS.Builtin.Zone))
+		provider, err := providers.NewBuiltinProvider(providers.BuiltinConfig{
+			Zone:          config.DNS.Builtin.Zone,
+			Listen:        config.DNS.Builtin.Listen,
+			PrimaryNS:     config.DNS.Builtin.PrimaryNS,
+			Hostmaster:    config.DNS.Builtin.Hostmaster,
+			Nameservers:   config.DNS.Builtin.Nameservers,
+			AllowTransfer: config.DNS.Builtin.AllowTransfer,
+			Notify:        config.DNS.Builtin.Notify,
+			Logger:        logger,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := provider.Start(ctx); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case "exec":
+		logger.Info("Initializing exec DNS provider",
+			zap.String("command", config.DNS.Exec.Command),
+			zap.Strings("args", config.DNS.Exec.Args))
+		return providers.NewExecProvider(providers.ExecConfig{
+			Command: config.DNS.Exec.Command,
+			Args:    config.DNS.Exec.Args,
+			Env:     config.DNS.Exec.Env,
+			Dir:     config.DNS.Exec.Dir,
+			Timeout: config.DNS.Exec.Timeout,
+			Logger:  logger,
+		})
 	default:
 		return nil, fmt.Errorf("unsupported DNS provider: %s", config.DNS.Provider)
 	}
@@ -531,6 +932,22 @@ func runDNSScale(config *Config) error {
 		logger.Info("Added required tag filter", zap.String("tag", tag))
 	}
 
+	reconciler.settings = nodeSettings{
+		addressFamily:      config.App.AddressFamily,
+		primaryAddressOnly: config.App.PrimaryAddressOnly,
+		wildcard:           config.App.Wildcard,
+	}
+	reconciler.tagOverrides = config.App.TagOverrides
+	reconciler.groups = config.App.Groups
+	reconciler.services = config.App.Services
+	if servicesUseAttributes(config.App.Services) {
+		tsClient.attributesFor = reconciler.needsAttributes
+	}
+	reconciler.statusAddr = config.App.StatusAddress
+	if len(config.App.HealthChecks) > 0 {
+		reconciler.health = newHealthChecker(config.App.HealthChecks, logger)
+	}
+
 	if err := reconciler.Run(ctx, config.App.Workers); err != nil {
 		logger.Fatal("Reconciler failed", zap.Error(err))
 	}
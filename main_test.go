@@ -0,0 +1,699 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jaxxstorm/dnsscale/providers"
+	"go.uber.org/zap"
+)
+
+// fakeProvider is an in-memory DNSProvider with the value-level semantics the
+// reconciler expects: create adds a value, update replaces every value for the
+// name and type, and delete removes a single value
+type fakeProvider struct {
+	mu      sync.Mutex
+	records []providers.DNSRecord
+	calls   []string
+}
+
+func (f *fakeProvider) ListRecords(ctx context.Context, zone string) ([]providers.DNSRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]providers.DNSRecord(nil), f.records...), nil
+}
+
+func (f *fakeProvider) CreateRecord(ctx context.Context, zone string, record providers.DNSRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "create "+fakeKey(record))
+	for _, existing := range f.records {
+		if fakeKey(existing) == fakeKey(record) {
+			return nil
+		}
+	}
+	f.records = append(f.records, record)
+	return nil
+}
+
+func (f *fakeProvider) UpdateRecord(ctx context.Context, zone string, record providers.DNSRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "update "+fakeKey(record))
+	var kept []providers.DNSRecord
+	for _, existing := range f.records {
+		if !recordNamesEqual(existing.Name, record.Name) || existing.Type != record.Type {
+			kept = append(kept, existing)
+		}
+	}
+	f.records = append(kept, record)
+	return nil
+}
+
+func (f *fakeProvider) DeleteRecord(ctx context.Context, zone string, record providers.DNSRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, "delete "+fakeKey(record))
+	var kept []providers.DNSRecord
+	for _, existing := range f.records {
+		if fakeKey(existing) != fakeKey(record) {
+			kept = append(kept, existing)
+		}
+	}
+	f.records = kept
+	return nil
+}
+
+func fakeKey(record providers.DNSRecord) string {
+	return fmt.Sprintf("%s %s %s", strings.ToLower(strings.TrimSuffix(record.Name, ".")), record.Type, recordValueKey(record))
+}
+
+// snapshot returns the stored records as sorted keys
+func (f *fakeProvider) snapshot() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for _, record := range f.records {
+		keys = append(keys, fakeKey(record))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resetCalls returns the recorded calls and clears the log
+func (f *fakeProvider) resetCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := f.calls
+	f.calls = nil
+	return calls
+}
+
+func newTestReconciler(provider providers.DNSProvider) *DNSReconciler {
+	return NewDNSReconciler(nil, provider, "example.com", time.Minute, zap.NewNop())
+}
+
+func (r *DNSReconciler) setNode(node TailscaleNode) {
+	r.cacheMutex.Lock()
+	defer r.cacheMutex.Unlock()
+	r.nodeCache[node.ID] = node
+}
+
+func testNode() TailscaleNode {
+	return TailscaleNode{
+		ID:        "n1",
+		Name:      "web1",
+		Addresses: []string{"100.64.0.1", "fd7a:115c:a1e0::1"},
+		Tags:      []string{"tag:web"},
+		Online:    true,
+	}
+}
+
+func assertRecords(t *testing.T, provider *fakeProvider, want ...string) {
+	t.Helper()
+	sort.Strings(want)
+	got := provider.snapshot()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("records:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+const testOwner = `web1.example.com TXT "dnsscale-managed node_id=n1"`
+
+func TestSyncRecordsReplacesLoneValueInPlace(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+
+	existing, _ := provider.ListRecords(ctx, "example.com")
+	desired := []providers.DNSRecord{{Name: "web1.example.com", Type: "A", Value: "100.64.0.2", TTL: 300}}
+	if err := r.syncRecords(ctx, existing, "web1.example.com", []string{"A", "AAAA"}, desired); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := provider.resetCalls()
+	if len(calls) != 1 || calls[0] != "update web1.example.com A 100.64.0.2" {
+		t.Fatalf("calls = %q, want a single update", calls)
+	}
+	assertRecords(t, provider, "web1.example.com A 100.64.0.2")
+
+	// An unchanged value makes no calls at all
+	existing, _ = provider.ListRecords(ctx, "example.com")
+	if err := r.syncRecords(ctx, existing, "web1.example.com", []string{"A", "AAAA"}, desired); err != nil {
+		t.Fatal(err)
+	}
+	if calls := provider.resetCalls(); len(calls) != 0 {
+		t.Fatalf("calls = %q, want none", calls)
+	}
+}
+
+func TestSyncRecordsMultipleValues(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "web.example.com", Type: "A", Value: "100.64.0.1", TTL: 300},
+		{Name: "web.example.com", Type: "A", Value: "100.64.0.2", TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+
+	existing, _ := provider.ListRecords(ctx, "example.com")
+	desired := []providers.DNSRecord{
+		{Name: "web.example.com", Type: "A", Value: "100.64.0.2", TTL: 300},
+		{Name: "web.example.com", Type: "A", Value: "100.64.0.3", TTL: 300},
+	}
+	if err := r.syncRecords(ctx, existing, "web.example.com", []string{"A"}, desired); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider, "web.example.com A 100.64.0.2", "web.example.com A 100.64.0.3")
+}
+
+func TestReconcileAddressFamilies(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web1.example.com A 100.64.0.1",
+		"web1.example.com AAAA fd7a:115c:a1e0::1",
+		testOwner)
+
+	// Dropping IPv6 removes the AAAA record
+	r.settings.addressFamily = addressFamilyIPv4
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider, "web1.example.com A 100.64.0.1", testOwner)
+
+	// A tag override switching the node to IPv6 only swaps the families
+	r.tagOverrides = []TagOverride{{Tag: "tag:web", AddressFamily: addressFamilyIPv6}}
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider, "web1.example.com AAAA fd7a:115c:a1e0::1", testOwner)
+
+	r.tagOverrides = nil
+	r.settings.addressFamily = addressFamilyBoth
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web1.example.com A 100.64.0.1",
+		"web1.example.com AAAA fd7a:115c:a1e0::1",
+		testOwner)
+}
+
+func TestReconcileWithdrawsUnhealthyNode(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+	r.health = newHealthChecker([]HealthCheckConfig{
+		{Name: "http", Tags: []string{"tag:web"}, Type: healthCheckTCP, Port: 80, NodeRecords: true},
+	}, zap.NewNop())
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+
+	r.health.results[probeKey("n1", "http")] = probeResult{Check: "http", Healthy: false}
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	// The ownership record stays so the name can be cleaned up later
+	assertRecords(t, provider, testOwner)
+
+	r.health.results[probeKey("n1", "http")] = probeResult{Check: "http", Healthy: true}
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web1.example.com A 100.64.0.1",
+		"web1.example.com AAAA fd7a:115c:a1e0::1",
+		testOwner)
+}
+
+func TestReconcileWildcard(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+	r.settings.addressFamily = addressFamilyIPv4
+	r.settings.wildcard = true
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web1.example.com A 100.64.0.1",
+		testOwner,
+		"*.web1.example.com A 100.64.0.1",
+		`*.web1.example.com TXT "dnsscale-managed node_id=n1"`)
+
+	r.settings.wildcard = false
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider, "web1.example.com A 100.64.0.1", testOwner)
+}
+
+func TestReconcileWildcardLeavesForeignRecords(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "*.web1.example.com", Type: "A", Value: "192.0.2.1", TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+	r.settings.addressFamily = addressFamilyIPv4
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"*.web1.example.com A 192.0.2.1",
+		"web1.example.com A 100.64.0.1",
+		testOwner)
+}
+
+func TestDeleteNodeDNSRemovesEveryOwnedName(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "other.example.com", Type: "A", Value: "192.0.2.1", TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+	r.settings.wildcard = true
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.deleteNodeDNS(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider, "other.example.com A 192.0.2.1")
+}
+
+func TestRemoveStaleGroups(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "web.example.com", Type: "A", Value: "100.64.0.1", TTL: 300},
+		{Name: "web.example.com", Type: "TXT", Value: groupOwnershipValue("web"), TTL: 300},
+		{Name: "db.example.com", Type: "A", Value: "100.64.0.2", TTL: 300},
+		{Name: "db.example.com", Type: "TXT", Value: groupOwnershipValue("db"), TTL: 300},
+		{Name: "manual.example.com", Type: "A", Value: "192.0.2.1", TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+	r.groups = []GroupConfig{{Tag: "tag:web"}}
+
+	if err := r.reconcile(ctx, staleGroupsKey); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"manual.example.com A 192.0.2.1",
+		"web.example.com A 100.64.0.1",
+		`web.example.com TXT "dnsscale-managed group=web"`)
+}
+
+func TestRemoveStaleServices(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{records: []providers.DNSRecord{
+		{Name: "_http._tcp.example.com", Type: "SRV", Value: "web1.example.com", TTL: 300, Port: 80},
+		{Name: "_http._tcp.example.com", Type: "TXT", Value: serviceOwnershipValue("_http._tcp"), TTL: 300},
+		{Name: "_ssh._tcp.example.com", Type: "SRV", Value: "web1.example.com", TTL: 300, Port: 22},
+		{Name: "_ssh._tcp.example.com", Type: "TXT", Value: serviceOwnershipValue("_ssh._tcp"), TTL: 300},
+	}}
+	r := newTestReconciler(provider)
+	r.services = []ServiceConfig{{Service: "http", Port: 80}}
+
+	if err := r.reconcile(ctx, staleServicesKey); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"_http._tcp.example.com SRV 0 0 80 web1.example.com",
+		`_http._tcp.example.com TXT "dnsscale-managed service=_http._tcp"`)
+}
+
+func TestResyncRepublishesLostRecords(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(testNode())
+	r.groups = []GroupConfig{{Tag: "tag:web"}}
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcile(ctx, groupKeyPrefix+"web"); err != nil {
+		t.Fatal(err)
+	}
+	before := provider.snapshot()
+
+	// Records vanish behind dnsscale's back, like keys attached to a lost
+	// etcd lease
+	provider.mu.Lock()
+	provider.records = nil
+	provider.mu.Unlock()
+
+	resync := make(chan struct{}, 1)
+	go r.watchResync(ctx, resync)
+	resync <- struct{}{}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for r.queue.Len() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("resync queued %d items, want the node and the group", r.queue.Len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	for r.queue.Len() > 0 {
+		item, _ := r.queue.Get()
+		if err := r.reconcile(ctx, item.(string)); err != nil {
+			t.Fatal(err)
+		}
+		r.queue.Done(item)
+	}
+
+	assertRecords(t, provider, before...)
+}
+
+func TestListNodesKeepsAttributesOnFailure(t *testing.T) {
+	var failing bool
+	var attributeCalls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/v2/tailnet/example.com/devices":
+			json.NewEncoder(w).Encode(TailscaleDevicesResponse{Devices: []TailscaleDevice{
+				{ID: "n1", Name: "web1.tailnet.ts.net", Authorized: true, Tags: []string{"tag:web"}},
+				{ID: "n2", Name: "db1.tailnet.ts.net", Authorized: true, Tags: []string{"tag:db"}},
+			}})
+		case "/api/v2/device/n1/attributes", "/api/v2/device/n2/attributes":
+			attributeCalls = append(attributeCalls, req.URL.Path)
+			if failing {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			json.NewEncoder(w).Encode(TailscaleDeviceAttributesResponse{Attributes: map[string]interface{}{"custom:tier": "prod"}})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	client := NewTailscaleClient("key", "example.com", zap.NewNop())
+	client.baseURL = server.URL
+	r := newTestReconciler(&fakeProvider{})
+	r.services = []ServiceConfig{{Service: "http", Port: 80, Tags: []string{"tag:web"}, Attributes: map[string]string{"custom:tier": "prod"}}}
+	client.attributesFor = r.needsAttributes
+
+	nodes, err := client.ListNodes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the node the service could match needs its attributes
+	if len(attributeCalls) != 1 || attributeCalls[0] != "/api/v2/device/n1/attributes" {
+		t.Fatalf("attribute calls = %q", attributeCalls)
+	}
+	if nodes[0].Attributes["custom:tier"] != "prod" {
+		t.Fatalf("attributes = %v", nodes[0].Attributes)
+	}
+
+	failing = true
+	nodes, err = client.ListNodes(context.Background())
+	if err != nil {
+		t.Fatalf("ListNodes failed with a failing attributes call: %v", err)
+	}
+	if len(nodes) != 2 || nodes[0].Attributes["custom:tier"] != "prod" {
+		t.Fatalf("nodes = %+v, want previous attributes kept", nodes)
+	}
+}
+
+// taggedNode returns an online node with a single IPv4 address
+func taggedNode(id, name, address string, tags ...string) TailscaleNode {
+	return TailscaleNode{ID: id, Name: name, Addresses: []string{address}, Tags: tags, Online: true}
+}
+
+func TestReconcileGroupMembership(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(taggedNode("n1", "web1", "100.64.0.1", "tag:web"))
+	offline := taggedNode("n2", "web2", "100.64.0.2", "tag:web")
+	offline.Online = false
+	r.setNode(offline)
+	r.setNode(taggedNode("n3", "web3", "100.64.0.3", "tag:web", "tag:probed"))
+	r.setNode(taggedNode("n4", "db1", "100.64.0.4", "tag:db"))
+	r.groups = []GroupConfig{{Tag: "tag:web", OnlineOnly: true}}
+	r.health = newHealthChecker([]HealthCheckConfig{
+		{Name: "http", Tags: []string{"tag:probed"}, Type: healthCheckTCP, Port: 80},
+	}, zap.NewNop())
+	r.health.results[probeKey("n3", "http")] = probeResult{Check: "http", Healthy: false}
+
+	// Offline and unhealthy members are left out
+	if err := r.reconcile(ctx, groupKeyPrefix+"web"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web.example.com A 100.64.0.1",
+		`web.example.com TXT "dnsscale-managed group=web"`)
+
+	r.groups[0].OnlineOnly = false
+	r.health.results[probeKey("n3", "http")] = probeResult{Check: "http", Healthy: true}
+	if err := r.reconcile(ctx, groupKeyPrefix+"web"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web.example.com A 100.64.0.1",
+		"web.example.com A 100.64.0.2",
+		"web.example.com A 100.64.0.3",
+		`web.example.com TXT "dnsscale-managed group=web"`)
+}
+
+func TestReconcileGroupSkipsNodeNameCollision(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.settings.addressFamily = addressFamilyIPv4
+	r.setNode(taggedNode("n1", "web", "100.64.0.1", "tag:web"))
+	r.setNode(taggedNode("n2", "web2", "100.64.0.2", "tag:web"))
+	r.groups = []GroupConfig{{Tag: "tag:web"}}
+
+	if err := r.reconcile(ctx, "n1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.reconcile(ctx, groupKeyPrefix+"web"); err != nil {
+		t.Fatal(err)
+	}
+	// The node keeps its name and the group publishes nothing
+	assertRecords(t, provider,
+		"web.example.com A 100.64.0.1",
+		`web.example.com TXT "dnsscale-managed node_id=n1"`)
+
+	// A differently named group for the same tag is published as usual
+	r.groups = []GroupConfig{{Tag: "tag:web", Name: "pool"}}
+	if err := r.reconcile(ctx, groupKeyPrefix+"pool"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"web.example.com A 100.64.0.1",
+		`web.example.com TXT "dnsscale-managed node_id=n1"`,
+		"pool.example.com A 100.64.0.1",
+		"pool.example.com A 100.64.0.2",
+		`pool.example.com TXT "dnsscale-managed group=pool"`)
+}
+
+func TestReconcileServiceTargets(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	prod := taggedNode("n1", "web1", "100.64.0.1", "tag:web")
+	prod.Attributes = map[string]string{"custom:tier": "prod"}
+	r.setNode(prod)
+	// Attribute keys match regardless of case
+	upper := taggedNode("n2", "web2", "100.64.0.2", "tag:web")
+	upper.Attributes = map[string]string{"Custom:Tier": "prod"}
+	r.setNode(upper)
+	dev := taggedNode("n3", "web3", "100.64.0.3", "tag:web")
+	dev.Attributes = map[string]string{"custom:tier": "dev"}
+	r.setNode(dev)
+	untagged := taggedNode("n4", "db1", "100.64.0.4", "tag:db")
+	untagged.Attributes = map[string]string{"custom:tier": "prod"}
+	r.setNode(untagged)
+	r.services = []ServiceConfig{{
+		Service:    "http",
+		Port:       8080,
+		Priority:   10,
+		Weight:     5,
+		Tags:       []string{"tag:web"},
+		Attributes: map[string]string{"custom:tier": "prod"},
+	}}
+
+	if err := r.reconcile(ctx, serviceKeyPrefix+"_http._tcp"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"_http._tcp.example.com SRV 10 5 8080 web1.example.com",
+		"_http._tcp.example.com SRV 10 5 8080 web2.example.com",
+		`_http._tcp.example.com TXT "dnsscale-managed service=_http._tcp"`)
+
+	// A new port replaces every target's record
+	r.services[0].Port = 8443
+	if err := r.reconcile(ctx, serviceKeyPrefix+"_http._tcp"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"_http._tcp.example.com SRV 10 5 8443 web1.example.com",
+		"_http._tcp.example.com SRV 10 5 8443 web2.example.com",
+		`_http._tcp.example.com TXT "dnsscale-managed service=_http._tcp"`)
+
+	// A target whose attributes stop matching is dropped
+	dev.Attributes["custom:tier"] = "prod"
+	prod.Attributes = map[string]string{"custom:tier": "staging"}
+	r.setNode(dev)
+	r.setNode(prod)
+	if err := r.reconcile(ctx, serviceKeyPrefix+"_http._tcp"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"_http._tcp.example.com SRV 10 5 8443 web2.example.com",
+		"_http._tcp.example.com SRV 10 5 8443 web3.example.com",
+		`_http._tcp.example.com TXT "dnsscale-managed service=_http._tcp"`)
+}
+
+func TestReconcileServiceWithoutTags(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeProvider{}
+	r := newTestReconciler(provider)
+	r.setNode(taggedNode("n1", "web1", "100.64.0.1", "tag:web"))
+	r.setNode(taggedNode("n2", "db1", "100.64.0.2", "tag:db"))
+	r.services = []ServiceConfig{{Service: "_dns", Protocol: "udp", Port: 53}}
+
+	// A service without tags or attributes targets every node
+	if err := r.reconcile(ctx, serviceKeyPrefix+"_dns._udp"); err != nil {
+		t.Fatal(err)
+	}
+	assertRecords(t, provider,
+		"_dns._udp.example.com SRV 0 0 53 db1.example.com",
+		"_dns._udp.example.com SRV 0 0 53 web1.example.com",
+		`_dns._udp.example.com TXT "dnsscale-managed service=_dns._udp"`)
+}
+
+// listenTCP opens a loopback listener for probes to connect to
+func listenTCP(t *testing.T) (net.Listener, uint16) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return listener, uint16(listener.Addr().(*net.TCPAddr).Port)
+}
+
+// probeNow schedules probes and waits for the given one to complete
+func probeNow(t *testing.T, ctx context.Context, r *DNSReconciler, key string) probeResult {
+	t.Helper()
+
+	start := time.Now()
+	r.scheduleProbes(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		r.health.mu.RLock()
+		result, ok := r.health.results[key]
+		done := ok && !r.health.inFlight[key] && !result.CheckedAt.Before(start)
+		r.health.mu.RUnlock()
+		if done {
+			return result
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("probe %s didn't complete", key)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// drainQueue returns the queued items, sorted, and empties the queue
+func drainQueue(r *DNSReconciler) []string {
+	var items []string
+	for r.queue.Len() > 0 {
+		item, _ := r.queue.Get()
+		items = append(items, item.(string))
+		r.queue.Done(item)
+	}
+	sort.Strings(items)
+	return items
+}
+
+func TestProbesQueueHealthChanges(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	listener, port := listenTCP(t)
+	r := newTestReconciler(&fakeProvider{})
+	r.setNode(taggedNode("n1", "web1", "127.0.0.1", "tag:web"))
+	r.groups = []GroupConfig{{Tag: "tag:web"}}
+	r.services = []ServiceConfig{{Service: "http", Port: 80, Tags: []string{"tag:web"}}}
+	r.health = newHealthChecker([]HealthCheckConfig{
+		{Name: "tcp", Tags: []string{"tag:web"}, Type: healthCheckTCP, Port: port, Timeout: time.Second, NodeRecords: true},
+	}, zap.NewNop())
+	key := probeKey("n1", "tcp")
+
+	// Nodes start out healthy, so a passing first probe changes nothing
+	if result := probeNow(t, ctx, r, key); !result.Healthy {
+		t.Fatalf("first probe failed: %s", result.Error)
+	}
+	if items := drainQueue(r); len(items) != 0 {
+		t.Fatalf("queued %q after a passing probe, want nothing", items)
+	}
+
+	listener.Close()
+	if result := probeNow(t, ctx, r, key); result.Healthy {
+		t.Fatal("probe of a closed port passed")
+	}
+	want := []string{"group:web", "n1", "service:_http._tcp"}
+	if items := drainQueue(r); strings.Join(items, ",") != strings.Join(want, ",") {
+		t.Fatalf("queued %q after a failure, want %q", items, want)
+	}
+
+	// A repeated failure isn't a change
+	probeNow(t, ctx, r, key)
+	if items := drainQueue(r); len(items) != 0 {
+		t.Fatalf("queued %q after a repeated failure, want nothing", items)
+	}
+
+	// Recovery of a check that doesn't gate node records only queues the
+	// groups and services
+	_, port = listenTCP(t)
+	r.health.checks[0].Port = port
+	r.health.checks[0].NodeRecords = false
+	if result := probeNow(t, ctx, r, key); !result.Healthy {
+		t.Fatalf("probe after recovery failed: %s", result.Error)
+	}
+	want = []string{"group:web", "service:_http._tcp"}
+	if items := drainQueue(r); strings.Join(items, ",") != strings.Join(want, ",") {
+		t.Fatalf("queued %q after a recovery, want %q", items, want)
+	}
+
+	// Results for nodes that are gone are forgotten
+	r.cacheMutex.Lock()
+	delete(r.nodeCache, "n1")
+	r.cacheMutex.Unlock()
+	r.scheduleProbes(ctx)
+	if results := r.health.nodeResults("n1"); len(results) != 0 {
+		t.Fatalf("results for a removed node = %+v", results)
+	}
+}
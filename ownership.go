@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jaxxstorm/dnsscale/providers"
+	"go.uber.org/zap"
+)
+
+// nodeOwnershipValue returns the TXT value marking a name as managed by
+// dnsscale on behalf of a node
+func nodeOwnershipValue(nodeID string) string {
+	return fmt.Sprintf("\"%s node_id=%s\"", providers.OwnershipPrefix, nodeID)
+}
+
+// isOwnershipRecord reports whether record is a TXT record carrying value.
+// Providers differ in whether they return TXT content quoted, so quotes are
+// ignored when comparing.
+func isOwnershipRecord(record providers.DNSRecord, value string) bool {
+	return record.Type == "TXT" && strings.Trim(record.Value, "\"") == strings.Trim(value, "\"")
+}
+
+// ownsName reports whether existing contains an ownership record with value
+// at name
+func ownsName(existing []providers.DNSRecord, name, value string) bool {
+	for _, record := range existing {
+		if recordNamesEqual(record.Name, name) && isOwnershipRecord(record, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateOwnershipRecord writes the TXT ownership record for name. Failures are
+// logged but don't fail reconciliation.
+func (r *DNSReconciler) updateOwnershipRecord(ctx context.Context, name, value string) {
+	txtRecord := providers.DNSRecord{
+		Name:  name,
+		Type:  "TXT",
+		Value: value,
+		TTL:   defaultRecordTTL,
+	}
+
+	if err := r.dnsProvider.UpdateRecord(ctx, r.domain, txtRecord); err != nil {
+		r.logger.Warn("Failed to create TXT ownership record",
+			zap.String("record_name", txtRecord.Name),
+			zap.Error(err))
+		return
+	}
+
+	r.logger.Info("Updated TXT ownership record",
+		zap.String("record_name", txtRecord.Name),
+		zap.String("record_value", txtRecord.Value))
+}
+
+// deleteRecordsNamed deletes every record in records at name, including the
+// ownership record. Individual failures are logged and the rest attempted.
+func (r *DNSReconciler) deleteRecordsNamed(ctx context.Context, records []providers.DNSRecord, name string) {
+	for _, recordToDelete := range records {
+		if !recordNamesEqual(recordToDelete.Name, name) {
+			continue
+		}
+
+		if err := r.dnsProvider.DeleteRecord(ctx, r.domain, recordToDelete); err != nil {
+			r.logger.Error("Failed to delete DNS record",
+				zap.String("record_name", recordToDelete.Name),
+				zap.String("record_type", recordToDelete.Type),
+				zap.Error(err))
+		} else {
+			r.logger.Info("Deleted DNS record",
+				zap.String("record_name", recordToDelete.Name),
+				zap.String("record_type", recordToDelete.Type))
+		}
+	}
+}
+
+// removeStaleOwners deletes every name marked with an ownership value starting
+// with prefix that isn't in configured, such as the records of a group that
+// was removed from the configuration
+func (r *DNSReconciler) removeStaleOwners(ctx context.Context, prefix string, configured map[string]bool) error {
+	records, err := r.dnsProvider.ListRecords(ctx, r.domain)
+	if err != nil {
+		return fmt.Errorf("failed to list existing DNS records: %w", err)
+	}
+
+	for _, record := range records {
+		value := strings.Trim(record.Value, "\"")
+		if record.Type != "TXT" || !strings.HasPrefix(value, providers.OwnershipPrefix+" "+prefix) || configured[value] {
+			continue
+		}
+
+		r.logger.Info("Removing records no longer in the configuration",
+			zap.String("record_name", record.Name),
+			zap.String("owner", value))
+		r.deleteRecordsNamed(ctx, records, record.Name)
+	}
+	return nil
+}
+
+// syncOwnedRecords publishes desired at name and marks the name with owner.
+// Once nothing is desired the name is removed entirely, but only if owner
+// created it.
+func (r *DNSReconciler) syncOwnedRecords(ctx context.Context, name, owner string, recordTypes []string, desired []providers.DNSRecord) error {
+	existing, err := r.dnsProvider.ListRecords(ctx, r.domain)
+	if err != nil {
+		return fmt.Errorf("failed to list existing DNS records: %w", err)
+	}
+
+	if len(desired) == 0 {
+		if ownsName(existing, name, owner) {
+			r.logger.Info("Removing records with no remaining members",
+				zap.String("record_name", name),
+				zap.String("owner", owner))
+			r.deleteRecordsNamed(ctx, existing, name)
+		}
+		return nil
+	}
+
+	if err := r.syncRecords(ctx, existing, name, recordTypes, desired); err != nil {
+		return err
+	}
+	r.updateOwnershipRecord(ctx, name, owner)
+	return nil
+}
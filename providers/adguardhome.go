@@ -0,0 +1,254 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// AdGuardHomeProvider implements DNSProvider for AdGuard Home DNS rewrites.
+// A rewrite answering with an address becomes an A or AAAA record, and one
+// answering with a name becomes a CNAME record.
+//
+// Rewrites can't carry TXT records, so the ones dnsscale uses to track
+// ownership are kept in a local state file.
+type AdGuardHomeProvider struct {
+	username   string
+	password   string
+	httpClient *http.Client
+	baseURL    string
+	state      *txtStateFile
+}
+
+// AdGuardHomeRewrite represents a DNS rewrite in AdGuard Home's API
+type AdGuardHomeRewrite struct {
+	Domain string `json:"domain"`
+	Answer string `json:"answer"`
+}
+
+// NewAdGuardHomeProvider creates a provider for the AdGuard Home instance at
+// baseURL, e.g. http://127.0.0.1:3000
+func NewAdGuardHomeProvider(baseURL, username, password, stateFile string) (*AdGuardHomeProvider, error) {
+	if baseURL == "" || stateFile == "" {
+		return nil, fmt.Errorf("URL and state file are required")
+	}
+
+	return &AdGuardHomeProvider{
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/control",
+		state:      newTXTStateFile(stateFile),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the AdGuard Home API and decodes the
+// response into out when it is non-nil
+func (a *AdGuardHomeProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := a.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if a.username != "" {
+		req.SetBasicAuth(a.username, a.password)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// AdGuard Home reports errors as plain text
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if text := strings.TrimSpace(string(message)); text != "" {
+			return fmt.Errorf("adguard home API error: %s (code: %d)", text, resp.StatusCode)
+		}
+		return fmt.Errorf("adguard home API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// listRewrites fetches every DNS rewrite
+func (a *AdGuardHomeProvider) listRewrites(ctx context.Context) ([]AdGuardHomeRewrite, error) {
+	var rewrites []AdGuardHomeRewrite
+	if err := a.makeRequest(ctx, "GET", "/rewrite/list", nil, &rewrites); err != nil {
+		return nil, err
+	}
+	return rewrites, nil
+}
+
+// fromAdGuardHomeRewrite converts a rewrite to our internal format
+func fromAdGuardHomeRewrite(rewrite AdGuardHomeRewrite) DNSRecord {
+	record := DNSRecord{
+		Name:  strings.ToLower(rewrite.Domain),
+		Type:  "CNAME",
+		Value: strings.ToLower(rewrite.Answer),
+	}
+	if addr, err := netip.ParseAddr(rewrite.Answer); err == nil {
+		record.Type = "A"
+		if addr.Is6() {
+			record.Type = "AAAA"
+		}
+		record.Value = addr.String()
+	}
+	return record
+}
+
+// toAdGuardHomeRewrite converts a record to a rewrite
+func toAdGuardHomeRewrite(record DNSRecord) (AdGuardHomeRewrite, error) {
+	rewrite := AdGuardHomeRewrite{Domain: strings.ToLower(strings.TrimSuffix(record.Name, "."))}
+
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil {
+			return AdGuardHomeRewrite{}, fmt.Errorf("invalid %s record value %q: %w", record.Type, record.Value, err)
+		}
+		rewrite.Answer = addr.String()
+	case "CNAME":
+		rewrite.Answer = strings.ToLower(strings.TrimSuffix(record.Value, "."))
+	default:
+		return AdGuardHomeRewrite{}, fmt.Errorf("adguard home rewrites can't hold %s records", record.Type)
+	}
+	return rewrite, nil
+}
+
+func (a *AdGuardHomeProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	rewrites, err := a.listRewrites(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for _, rewrite := range rewrites {
+		records = append(records, fromAdGuardHomeRewrite(rewrite))
+	}
+
+	txtRecords, err := a.state.list()
+	if err != nil {
+		return nil, err
+	}
+	return append(records, txtRecords...), nil
+}
+
+func (a *AdGuardHomeProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return a.state.add(record)
+	}
+
+	rewrite, err := toAdGuardHomeRewrite(record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.listRewrites(ctx)
+	if err != nil {
+		return err
+	}
+	for _, candidate := range existing {
+		if fromAdGuardHomeRewrite(candidate) == fromAdGuardHomeRewrite(rewrite) {
+			return nil
+		}
+	}
+
+	return a.makeRequest(ctx, "POST", "/rewrite/add", rewrite, nil)
+}
+
+func (a *AdGuardHomeProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return a.state.replace(record)
+	}
+
+	rewrite, err := toAdGuardHomeRewrite(record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.listRewrites(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add the new value before removing the old ones so the name stays
+	// resolvable
+	wanted := fromAdGuardHomeRewrite(rewrite)
+	found := false
+	var stale []AdGuardHomeRewrite
+	for _, candidate := range existing {
+		converted := fromAdGuardHomeRewrite(candidate)
+		if converted.Type != wanted.Type || converted.Name != wanted.Name {
+			continue
+		}
+		if converted == wanted && !found {
+			found = true
+			continue
+		}
+		stale = append(stale, candidate)
+	}
+
+	if !found {
+		if err := a.makeRequest(ctx, "POST", "/rewrite/add", rewrite, nil); err != nil {
+			return err
+		}
+	}
+	for _, staleRewrite := range stale {
+		if err := a.makeRequest(ctx, "POST", "/rewrite/delete", staleRewrite, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *AdGuardHomeProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return a.state.remove(record)
+	}
+
+	rewrite, err := toAdGuardHomeRewrite(record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.listRewrites(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Delete the rewrite as AdGuard Home stores it, which may differ in case
+	for _, candidate := range existing {
+		if fromAdGuardHomeRewrite(candidate) == fromAdGuardHomeRewrite(rewrite) {
+			return a.makeRequest(ctx, "POST", "/rewrite/delete", candidate, nil)
+		}
+	}
+
+	// Record doesn't exist, nothing to delete
+	return nil
+}
@@ -0,0 +1,497 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+const (
+	azureAPIVersion      = "2018-05-01"
+	azureManagementScope = "https://management.azure.com/.default"
+	azureIMDSEndpoint    = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// AzureDNSConfig holds the settings needed to create an AzureDNSProvider
+type AzureDNSConfig struct {
+	SubscriptionID string
+	ResourceGroup  string
+	ZoneName       string
+
+	// Client secret authentication, used unless UseManagedIdentity is set
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+
+	// UseManagedIdentity authenticates through the instance metadata
+	// service. ClientID optionally selects a user-assigned identity.
+	UseManagedIdentity bool
+
+	// Endpoint overrides the Azure Resource Manager URL and AuthorityHost the
+	// Microsoft Entra login URL, or the instance metadata service URL when
+	// using managed identity, e.g. for sovereign clouds or a stand-in server
+	Endpoint      string
+	AuthorityHost string
+}
+
+// AzureDNSProvider implements DNSProvider for Azure DNS
+type AzureDNSProvider struct {
+	subscriptionID string
+	resourceGroup  string
+	zoneName       string
+	httpClient     *http.Client
+	baseURL        string
+}
+
+// AzureRecordSet represents a record set in the Azure DNS API
+type AzureRecordSet struct {
+	ID         string                   `json:"id,omitempty"`
+	Name       string                   `json:"name,omitempty"`
+	Type       string                   `json:"type,omitempty"`
+	Etag       string                   `json:"etag,omitempty"`
+	Properties AzureRecordSetProperties `json:"properties"`
+}
+
+// AzureRecordSetProperties holds the values of an Azure record set. Only the
+// field matching the record set's type is populated.
+type AzureRecordSetProperties struct {
+	TTL         int64             `json:"TTL"`
+	FQDN        string            `json:"fqdn,omitempty"`
+	ARecords    []AzureARecord    `json:"ARecords,omitempty"`
+	AAAARecords []AzureAAAARecord `json:"AAAARecords,omitempty"`
+	TXTRecords  []AzureTXTRecord  `json:"TXTRecords,omitempty"`
+	SRVRecords  []AzureSRVRecord  `json:"SRVRecords,omitempty"`
+}
+
+// AzureARecord represents an A record value
+type AzureARecord struct {
+	IPv4Address string `json:"ipv4Address"`
+}
+
+// AzureAAAARecord represents an AAAA record value
+type AzureAAAARecord struct {
+	IPv6Address string `json:"ipv6Address"`
+}
+
+// AzureTXTRecord represents a TXT record value, split into strings
+type AzureTXTRecord struct {
+	Value []string `json:"value"`
+}
+
+// AzureSRVRecord represents an SRV record value
+type AzureSRVRecord struct {
+	Priority uint16 `json:"priority"`
+	Weight   uint16 `json:"weight"`
+	Port     uint16 `json:"port"`
+	Target   string `json:"target"`
+}
+
+// AzureRecordSetListResponse represents a page of record sets
+type AzureRecordSetListResponse struct {
+	Value    []AzureRecordSet `json:"value"`
+	NextLink string           `json:"nextLink,omitempty"`
+}
+
+// AzureErrorResponse represents an error returned by Azure Resource Manager
+type AzureErrorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// errAzureNotFound is returned by makeRequest for 404 responses
+var errAzureNotFound = errors.New("azure resource not found")
+
+func NewAzureDNSProvider(ctx context.Context, cfg AzureDNSConfig) (*AzureDNSProvider, error) {
+	if cfg.SubscriptionID == "" || cfg.ResourceGroup == "" || cfg.ZoneName == "" {
+		return nil, fmt.Errorf("subscription ID, resource group and zone name are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://management.azure.com"
+	}
+
+	var tokenSource oauth2.TokenSource
+	if cfg.UseManagedIdentity {
+		imdsEndpoint := azureIMDSEndpoint
+		if cfg.AuthorityHost != "" {
+			imdsEndpoint = strings.TrimSuffix(cfg.AuthorityHost, "/") + "/metadata/identity/oauth2/token"
+		}
+		tokenSource = oauth2.ReuseTokenSource(nil, &azureManagedIdentityTokenSource{
+			endpoint:   imdsEndpoint,
+			resource:   strings.TrimSuffix(azureManagementScope, ".default"),
+			clientID:   cfg.ClientID,
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		})
+	} else {
+		if cfg.TenantID == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, fmt.Errorf("tenant ID, client ID and client secret are required unless using managed identity")
+		}
+		authority := cfg.AuthorityHost
+		if authority == "" {
+			authority = "https://login.microsoftonline.com"
+		}
+		credentials := clientcredentials.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			TokenURL:     fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authority, "/"), url.PathEscape(cfg.TenantID)),
+			Scopes:       []string{azureManagementScope},
+		}
+		tokenSource = credentials.TokenSource(ctx)
+	}
+
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+	httpClient.Timeout = 30 * time.Second
+
+	return &AzureDNSProvider{
+		subscriptionID: cfg.SubscriptionID,
+		resourceGroup:  cfg.ResourceGroup,
+		zoneName:       strings.TrimSuffix(cfg.ZoneName, "."),
+		httpClient:     httpClient,
+		baseURL:        strings.TrimSuffix(endpoint, "/"),
+	}, nil
+}
+
+// azureManagedIdentityTokenSource fetches tokens for the resource manager
+// from the Azure instance metadata service
+type azureManagedIdentityTokenSource struct {
+	endpoint   string
+	resource   string
+	clientID   string
+	httpClient *http.Client
+}
+
+// azureManagedIdentityToken is the instance metadata service token response.
+// Its numeric fields are encoded as strings.
+type azureManagedIdentityToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   string `json:"expires_in"`
+	TokenType   string `json:"token_type"`
+}
+
+func (s *azureManagedIdentityTokenSource) Token() (*oauth2.Token, error) {
+	query := url.Values{}
+	query.Set("api-version", "2018-02-01")
+	query.Set("resource", s.resource)
+	if s.clientID != "" {
+		query.Set("client_id", s.clientID)
+	}
+
+	req, err := http.NewRequest("GET", s.endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Metadata", "true")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("managed identity token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("managed identity token request failed with status %d", resp.StatusCode)
+	}
+
+	var token azureManagedIdentityToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("failed to decode managed identity token: %w", err)
+	}
+
+	expiresIn, _ := strconv.Atoi(token.ExpiresIn)
+	return &oauth2.Token{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Expiry:      time.Now().Add(time.Duration(expiresIn) * time.Second),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the Azure DNS API. endpoint is
+// relative to the zone, or an absolute URL when following a nextLink.
+func (a *AzureDNSProvider) makeRequest(ctx context.Context, method, endpoint string, headers map[string]string, body, out interface{}) error {
+	reqURL := endpoint
+	if !strings.HasPrefix(endpoint, "http") {
+		reqURL = fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Network/dnsZones/%s%s?api-version=%s",
+			a.baseURL, url.PathEscape(a.subscriptionID), url.PathEscape(a.resourceGroup), url.PathEscape(a.zoneName), endpoint, azureAPIVersion)
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errAzureNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp AzureErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error.Message != "" {
+			return fmt.Errorf("azure dns API error: %s (code: %s)", errResp.Error.Message, errResp.Error.Code)
+		}
+		return fmt.Errorf("azure dns API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordSetPath returns the zone relative path of a record set
+func (a *AzureDNSProvider) recordSetPath(record DNSRecord) (string, error) {
+	switch record.Type {
+	case "A", "AAAA", "TXT", "SRV":
+	default:
+		return "", fmt.Errorf("unsupported record type for azure dns: %s", record.Type)
+	}
+
+	name, err := relativeName(record.Name, a.zoneName)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/%s/%s", record.Type, url.PathEscape(name)), nil
+}
+
+// azureRecordValues converts the values of a record set into DNSRecords
+func azureRecordValues(name, recordType string, props AzureRecordSetProperties) []DNSRecord {
+	var records []DNSRecord
+	base := DNSRecord{Name: name, Type: recordType, TTL: props.TTL}
+
+	switch recordType {
+	case "A":
+		for _, rr := range props.ARecords {
+			record := base
+			record.Value = rr.IPv4Address
+			records = append(records, record)
+		}
+	case "AAAA":
+		for _, rr := range props.AAAARecords {
+			record := base
+			record.Value = rr.IPv6Address
+			records = append(records, record)
+		}
+	case "TXT":
+		// Azure stores TXT values unquoted, so quote them like other providers
+		for _, rr := range props.TXTRecords {
+			record := base
+			record.Value = "\"" + strings.Join(rr.Value, "") + "\""
+			records = append(records, record)
+		}
+	case "SRV":
+		for _, rr := range props.SRVRecords {
+			record := base
+			record.Value = rr.Target
+			record.Priority = rr.Priority
+			record.Weight = rr.Weight
+			record.Port = rr.Port
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// azureRecordSetProperties builds record set properties holding records,
+// which must all share a type
+func azureRecordSetProperties(ttl int64, records []DNSRecord) AzureRecordSetProperties {
+	props := AzureRecordSetProperties{TTL: ttl}
+	for _, record := range records {
+		switch record.Type {
+		case "A":
+			props.ARecords = append(props.ARecords, AzureARecord{IPv4Address: record.Value})
+		case "AAAA":
+			props.AAAARecords = append(props.AAAARecords, AzureAAAARecord{IPv6Address: record.Value})
+		case "TXT":
+			props.TXTRecords = append(props.TXTRecords, AzureTXTRecord{Value: []string{strings.Trim(record.Value, "\"")}})
+		case "SRV":
+			props.SRVRecords = append(props.SRVRecords, AzureSRVRecord{
+				Priority: record.Priority,
+				Weight:   record.Weight,
+				Port:     record.Port,
+				Target:   record.Value,
+			})
+		}
+	}
+	return props
+}
+
+// azureRecordKey identifies a record value for comparison within a set
+func azureRecordKey(record DNSRecord) string {
+	switch record.Type {
+	case "TXT":
+		return strings.Trim(record.Value, "\"")
+	case "SRV":
+		record.Value = strings.TrimSuffix(record.Value, ".")
+		return record.SRVValue()
+	}
+	return record.Value
+}
+
+func (a *AzureDNSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	endpoint := "/recordsets"
+
+	for endpoint != "" {
+		var page AzureRecordSetListResponse
+		if err := a.makeRequest(ctx, "GET", endpoint, nil, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, rrset := range page.Value {
+			// Types look like "Microsoft.Network/dnszones/A"
+			recordType := rrset.Type[strings.LastIndex(rrset.Type, "/")+1:]
+			name := strings.TrimSuffix(rrset.Properties.FQDN, ".")
+			if name == "" {
+				name = absoluteName(rrset.Name, a.zoneName)
+			}
+			records = append(records, azureRecordValues(name, recordType, rrset.Properties)...)
+		}
+
+		endpoint = page.NextLink
+	}
+
+	return records, nil
+}
+
+// getRecordSet returns the record set at path, or nil if it doesn't exist
+func (a *AzureDNSProvider) getRecordSet(ctx context.Context, path string) (*AzureRecordSet, error) {
+	var rrset AzureRecordSet
+	if err := a.makeRequest(ctx, "GET", path, nil, nil, &rrset); err != nil {
+		if errors.Is(err, errAzureNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rrset, nil
+}
+
+// putRecordSet writes a record set. When replacing an existing set its etag
+// guards against overwriting concurrent changes, otherwise the write only
+// succeeds if the set still doesn't exist.
+func (a *AzureDNSProvider) putRecordSet(ctx context.Context, path string, existing *AzureRecordSet, props AzureRecordSetProperties) error {
+	headers := map[string]string{"If-None-Match": "*"}
+	if existing != nil {
+		headers = etagHeaders(existing.Etag)
+	}
+	return a.makeRequest(ctx, "PUT", path, headers, AzureRecordSet{Properties: props}, nil)
+}
+
+// etagHeaders returns an If-Match header for etag, if there is one
+func etagHeaders(etag string) map[string]string {
+	if etag == "" {
+		return nil
+	}
+	return map[string]string{"If-Match": etag}
+}
+
+func (a *AzureDNSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	path, err := a.recordSetPath(record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.getRecordSet(ctx, path)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return a.putRecordSet(ctx, path, nil, azureRecordSetProperties(record.TTL, []DNSRecord{record}))
+	}
+
+	// Azure holds every value for a name and type in one record set, so an
+	// additional value is merged into the existing set
+	values := azureRecordValues(record.Name, record.Type, existing.Properties)
+	for _, value := range values {
+		if azureRecordKey(value) == azureRecordKey(record) {
+			return nil
+		}
+	}
+	values = append(values, record)
+
+	return a.putRecordSet(ctx, path, existing, azureRecordSetProperties(existing.Properties.TTL, values))
+}
+
+func (a *AzureDNSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	path, err := a.recordSetPath(record)
+	if err != nil {
+		return err
+	}
+
+	// A plain PUT creates the set or replaces all of its values
+	body := AzureRecordSet{Properties: azureRecordSetProperties(record.TTL, []DNSRecord{record})}
+	return a.makeRequest(ctx, "PUT", path, nil, body, nil)
+}
+
+func (a *AzureDNSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	path, err := a.recordSetPath(record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := a.getRecordSet(ctx, path)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other values in the set and only drop this one
+	values := azureRecordValues(record.Name, record.Type, existing.Properties)
+	var remaining []DNSRecord
+	for _, value := range values {
+		if azureRecordKey(value) != azureRecordKey(record) {
+			remaining = append(remaining, value)
+		}
+	}
+
+	if len(remaining) == len(values) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		err := a.makeRequest(ctx, "DELETE", path, etagHeaders(existing.Etag), nil, nil)
+		if errors.Is(err, errAzureNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	return a.putRecordSet(ctx, path, existing, azureRecordSetProperties(existing.Properties.TTL, remaining))
+}
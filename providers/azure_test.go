@@ -0,0 +1,116 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeAzureDNS is a minimal stand-in for Azure Resource Manager serving one
+// DNS zone, plus the Microsoft Entra token endpoint. Record sets carry etags
+// that are checked against If-Match and If-None-Match like the real API.
+type fakeAzureDNS struct {
+	mu     sync.Mutex
+	rrsets map[string]AzureRecordSet // Keyed by type and relative name
+	etag   int
+}
+
+func (f *fakeAzureDNS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.URL.Path == "/tenant/oauth2/v2.0/token" {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`)
+		return
+	}
+	if req.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	zonePath := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/dnsZones/example.com"
+	path, ok := strings.CutPrefix(req.URL.Path, zonePath+"/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if path == "recordsets" && req.Method == "GET" {
+		var resp AzureRecordSetListResponse
+		for _, rrset := range f.rrsets {
+			resp.Value = append(resp.Value, rrset)
+		}
+		json.NewEncoder(w).Encode(resp)
+		return
+	}
+
+	recordType, name, ok := strings.Cut(path, "/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	current, exists := f.rrsets[path]
+
+	if match := req.Header.Get("If-Match"); match != "" && (!exists || current.Etag != match) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if req.Header.Get("If-None-Match") == "*" && exists {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		if !exists {
+			http.NotFound(w, req)
+			return
+		}
+		json.NewEncoder(w).Encode(current)
+	case "PUT":
+		var rrset AzureRecordSet
+		if err := json.NewDecoder(req.Body).Decode(&rrset); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.etag++
+		rrset.Name = name
+		rrset.Type = "Microsoft.Network/dnszones/" + recordType
+		rrset.Etag = fmt.Sprintf("etag-%d", f.etag)
+		rrset.Properties.FQDN = absoluteName(name, "example.com") + "."
+		f.rrsets[path] = rrset
+		json.NewEncoder(w).Encode(rrset)
+	case "DELETE":
+		delete(f.rrsets, path)
+		w.WriteHeader(http.StatusOK)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestAzureDNSProvider(t *testing.T) {
+	server := httptest.NewServer(&fakeAzureDNS{rrsets: map[string]AzureRecordSet{}})
+	defer server.Close()
+
+	provider, err := NewAzureDNSProvider(context.Background(), AzureDNSConfig{
+		SubscriptionID: "sub",
+		ResourceGroup:  "rg",
+		ZoneName:       "example.com",
+		TenantID:       "tenant",
+		ClientID:       "client",
+		ClientSecret:   "secret",
+		Endpoint:       server.URL,
+		AuthorityHost:  server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testProviderSemantics(t, provider, "example.com")
+}
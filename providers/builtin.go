@@ -0,0 +1,595 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+)
+
+// BuiltinConfig holds the settings needed to create a BuiltinProvider
+type BuiltinConfig struct {
+	Zone string
+
+	// Addresses to answer queries on, over both UDP and TCP. Defaults to :53.
+	Listen []string
+
+	// Used for the zone's SOA and NS records
+	PrimaryNS   string   // Defaults to ns1.<zone>
+	Hostmaster  string   // Defaults to hostmaster.<zone>
+	Nameservers []string // Defaults to PrimaryNS
+
+	// Secondaries allowed to transfer the zone, as addresses or CIDRs
+	AllowTransfer []string
+	// Secondaries sent a NOTIFY after every change, as host or host:port
+	Notify []string
+
+	Logger *zap.Logger
+}
+
+// BuiltinProvider implements DNSProvider by keeping the records in memory and
+// serving the zone from an embedded authoritative name server, so the zone can
+// be delegated to dnsscale itself.
+//
+// The SOA and NS records are generated from the configuration. Every change
+// bumps the SOA serial and notifies the configured secondaries, which can then
+// transfer the zone with AXFR. Records aren't persisted: after a restart the
+// reconciler republishes them from the tailnet.
+type BuiltinProvider struct {
+	zone          string
+	listen        []string
+	primaryNS     string
+	hostmaster    string
+	nameservers   []string
+	allowTransfer []netip.Prefix
+	notify        []string
+	logger        *zap.Logger
+
+	mu     sync.RWMutex
+	rrs    []dns.RR
+	serial uint32
+
+	// Signalled after each change, coalescing changes made while a round of
+	// NOTIFY messages is in flight
+	changed chan struct{}
+}
+
+func NewBuiltinProvider(cfg BuiltinConfig) (*BuiltinProvider, error) {
+	if cfg.Zone == "" {
+		return nil, fmt.Errorf("zone is required")
+	}
+
+	zone := dns.CanonicalName(cfg.Zone)
+	provider := &BuiltinProvider{
+		zone:       zone,
+		listen:     cfg.Listen,
+		primaryNS:  cfg.PrimaryNS,
+		hostmaster: cfg.Hostmaster,
+		logger:     cfg.Logger,
+		serial:     nextBuiltinSerial(0, time.Now()),
+		changed:    make(chan struct{}, 1),
+	}
+
+	if len(provider.listen) == 0 {
+		provider.listen = []string{":53"}
+	}
+	if provider.logger == nil {
+		provider.logger = zap.NewNop()
+	}
+
+	if provider.primaryNS == "" {
+		provider.primaryNS = "ns1." + zone
+	}
+	if provider.hostmaster == "" {
+		provider.hostmaster = "hostmaster." + zone
+	}
+	provider.primaryNS = dns.CanonicalName(provider.primaryNS)
+	provider.hostmaster = dns.CanonicalName(provider.hostmaster)
+
+	for _, ns := range cfg.Nameservers {
+		provider.nameservers = append(provider.nameservers, dns.CanonicalName(ns))
+	}
+	if len(provider.nameservers) == 0 {
+		provider.nameservers = []string{provider.primaryNS}
+	}
+
+	for _, allowed := range cfg.AllowTransfer {
+		prefix, err := parseBuiltinPrefix(allowed)
+		if err != nil {
+			return nil, err
+		}
+		provider.allowTransfer = append(provider.allowTransfer, prefix)
+	}
+
+	for _, target := range cfg.Notify {
+		if _, _, err := net.SplitHostPort(target); err != nil {
+			target = net.JoinHostPort(strings.Trim(target, "[]"), "53")
+		}
+		provider.notify = append(provider.notify, target)
+	}
+
+	return provider, nil
+}
+
+// parseBuiltinPrefix parses an allow_transfer entry, either a single address
+// or a CIDR
+func parseBuiltinPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid transfer CIDR %q: %w", value, err)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid transfer address %q: %w", value, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// nextBuiltinSerial returns the serial following current. Serials are based on
+// the Unix time rather than the date so that they keep increasing across
+// restarts, when the records are republished from scratch.
+func nextBuiltinSerial(current uint32, now time.Time) uint32 {
+	if timestamp := uint32(now.Unix()); timestamp > current {
+		return timestamp
+	}
+	return current + 1
+}
+
+// Start binds every listen address and serves the zone until ctx is done. It
+// returns an error if any address can't be bound.
+func (b *BuiltinProvider) Start(ctx context.Context) error {
+	var servers []*dns.Server
+	closeAll := func() {
+		for _, server := range servers {
+			if server.PacketConn != nil {
+				server.PacketConn.Close()
+			}
+			if server.Listener != nil {
+				server.Listener.Close()
+			}
+		}
+	}
+
+	// Bind everything up front so a busy port fails startup rather than
+	// being logged from a goroutine
+	for _, addr := range b.listen {
+		packetConn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to listen on udp %s: %w", addr, err)
+		}
+		servers = append(servers, &dns.Server{PacketConn: packetConn, Handler: b})
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("failed to listen on tcp %s: %w", addr, err)
+		}
+		servers = append(servers, &dns.Server{Listener: listener, Handler: b})
+	}
+
+	for _, server := range servers {
+		go func(server *dns.Server) {
+			network, addr := "udp", ""
+			if server.Listener != nil {
+				network, addr = "tcp", server.Listener.Addr().String()
+			} else {
+				addr = server.PacketConn.LocalAddr().String()
+			}
+
+			b.logger.Info("Starting DNS server",
+				zap.String("zone", b.zone),
+				zap.String("network", network),
+				zap.String("address", addr))
+			if err := server.ActivateAndServe(); err != nil && ctx.Err() == nil {
+				b.logger.Error("DNS server failed",
+					zap.String("network", network),
+					zap.String("address", addr),
+					zap.Error(err))
+			}
+		}(server)
+	}
+
+	go func() {
+		<-ctx.Done()
+		for _, server := range servers {
+			server.Shutdown()
+		}
+	}()
+
+	if len(b.notify) > 0 {
+		go b.sendNotifies(ctx)
+	}
+
+	return nil
+}
+
+// soa returns the zone's SOA record with the current serial. Callers must hold
+// the lock.
+func (b *BuiltinProvider) soa() *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: b.zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+		Ns:      b.primaryNS,
+		Mbox:    b.hostmaster,
+		Serial:  b.serial,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  604800,
+		Minttl:  60,
+	}
+}
+
+// apex returns the SOA and NS records of the zone. Callers must hold the lock.
+func (b *BuiltinProvider) apex() []dns.RR {
+	rrs := []dns.RR{b.soa()}
+	for _, ns := range b.nameservers {
+		rrs = append(rrs, &dns.NS{
+			Hdr: dns.RR_Header{Name: b.zone, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600},
+			Ns:  ns,
+		})
+	}
+	return rrs
+}
+
+// lookup returns copies of the records owned by name, including the SOA and
+// NS records at the apex. Callers must hold the lock.
+func (b *BuiltinProvider) lookup(name string) []dns.RR {
+	var rrs []dns.RR
+	if name == b.zone {
+		rrs = b.apex()
+	}
+	for _, rr := range b.rrs {
+		if rr.Header().Name == name {
+			rrs = append(rrs, dns.Copy(rr))
+		}
+	}
+	return rrs
+}
+
+// exists reports whether name owns records or is an empty non-terminal above
+// names that do. Callers must hold the lock.
+func (b *BuiltinProvider) exists(name string) bool {
+	if name == b.zone {
+		return true
+	}
+	for _, rr := range b.rrs {
+		if dns.IsSubDomain(name, rr.Header().Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// negative returns the SOA placed in the authority section of NXDOMAIN and
+// NODATA answers, with its TTL capped to the negative caching TTL
+func (b *BuiltinProvider) negative() dns.RR {
+	soa := b.soa()
+	soa.Hdr.Ttl = soa.Minttl
+	return soa
+}
+
+// ServeDNS answers queries for the zone and zone transfers from allowed
+// secondaries
+func (b *BuiltinProvider) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	resp.SetReply(req)
+
+	switch {
+	case req.Opcode != dns.OpcodeQuery:
+		resp.SetRcode(req, dns.RcodeNotImplemented)
+	case len(req.Question) != 1:
+		resp.SetRcode(req, dns.RcodeFormatError)
+	case !dns.IsSubDomain(b.zone, dns.CanonicalName(req.Question[0].Name)):
+		resp.SetRcode(req, dns.RcodeRefused)
+	case req.Question[0].Qtype == dns.TypeAXFR || req.Question[0].Qtype == dns.TypeIXFR:
+		b.transfer(w, req)
+		return
+	default:
+		resp.Authoritative = true
+		b.answer(resp, req.Question[0])
+	}
+
+	size := dns.MinMsgSize
+	if opt := req.IsEdns0(); opt != nil {
+		size = int(opt.UDPSize())
+		resp.SetEdns0(4096, false)
+	}
+	if _, ok := w.RemoteAddr().(*net.UDPAddr); ok {
+		resp.Truncate(size)
+	}
+
+	if err := w.WriteMsg(resp); err != nil {
+		b.logger.Debug("Failed to write DNS response", zap.Error(err))
+	}
+}
+
+// answer fills in the answer for a question inside the zone, synthesizing
+// records from wildcards as described in RFC 4592
+func (b *BuiltinProvider) answer(resp *dns.Msg, question dns.Question) {
+	name := dns.CanonicalName(question.Name)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	rrs := b.lookup(name)
+	if len(rrs) == 0 && !b.exists(name) {
+		// Look for a wildcard below the closest existing ancestor
+		encloser := name
+		for encloser != b.zone && !b.exists(encloser) {
+			encloser = dns.CanonicalName(encloser[dns.Split(encloser)[1]:])
+		}
+		wildcard := b.lookup("*." + encloser)
+		if len(wildcard) == 0 {
+			resp.Rcode = dns.RcodeNameError
+			resp.Ns = []dns.RR{b.negative()}
+			return
+		}
+		for _, rr := range wildcard {
+			rr.Header().Name = question.Name
+		}
+		rrs = wildcard
+	}
+
+	for _, rr := range rrs {
+		rrtype := rr.Header().Rrtype
+		if question.Qtype == dns.TypeANY || rrtype == question.Qtype || rrtype == dns.TypeCNAME {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	if len(resp.Answer) == 0 {
+		resp.Ns = []dns.RR{b.negative()}
+		return
+	}
+
+	// Include the addresses of name servers inside the zone
+	for _, rr := range resp.Answer {
+		ns, ok := rr.(*dns.NS)
+		if !ok || !dns.IsSubDomain(b.zone, ns.Ns) {
+			continue
+		}
+		for _, glue := range b.lookup(ns.Ns) {
+			if rrtype := glue.Header().Rrtype; rrtype == dns.TypeA || rrtype == dns.TypeAAAA {
+				resp.Extra = append(resp.Extra, glue)
+			}
+		}
+	}
+}
+
+// transferAllowed reports whether addr may transfer the zone
+func (b *BuiltinProvider) transferAllowed(addr *net.TCPAddr) bool {
+	ip, ok := netip.AddrFromSlice(addr.IP)
+	if !ok {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range b.allowTransfer {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// transfer sends the whole zone to an allowed secondary. IXFR requests are
+// answered with the full zone as well, which RFC 1995 permits.
+func (b *BuiltinProvider) transfer(w dns.ResponseWriter, req *dns.Msg) {
+	// Transfers are only served over TCP
+	tcpAddr, ok := w.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeFormatError)
+		w.WriteMsg(resp)
+		return
+	}
+
+	if !dns.IsSubDomain(dns.CanonicalName(req.Question[0].Name), b.zone) || !b.transferAllowed(tcpAddr) {
+		b.logger.Warn("Refused zone transfer",
+			zap.String("zone", req.Question[0].Name),
+			zap.String("remote", w.RemoteAddr().String()))
+		resp := new(dns.Msg)
+		resp.SetRcode(req, dns.RcodeRefused)
+		w.WriteMsg(resp)
+		return
+	}
+
+	b.mu.RLock()
+	rrs := b.apex()
+	for _, rr := range b.rrs {
+		rrs = append(rrs, dns.Copy(rr))
+	}
+	rrs = append(rrs, b.soa())
+	serial := b.serial
+	b.mu.RUnlock()
+
+	ch := make(chan *dns.Envelope)
+	done := make(chan error, 1)
+	transfer := new(dns.Transfer)
+	go func() {
+		done <- transfer.Out(w, req, ch)
+	}()
+
+	// Keep each message well below the 64KiB limit of a TCP message. Out
+	// stops reading from ch if writing to the secondary fails.
+	var err error
+	for len(rrs) > 0 && err == nil {
+		chunk := rrs[:min(len(rrs), 200)]
+		rrs = rrs[len(chunk):]
+		select {
+		case ch <- &dns.Envelope{RR: chunk}:
+		case err = <-done:
+		}
+	}
+	close(ch)
+	if err == nil {
+		err = <-done
+	}
+
+	if err != nil {
+		b.logger.Warn("Zone transfer failed",
+			zap.String("remote", w.RemoteAddr().String()),
+			zap.Error(err))
+		return
+	}
+	b.logger.Info("Served zone transfer",
+		zap.String("zone", b.zone),
+		zap.Uint32("serial", serial),
+		zap.String("remote", w.RemoteAddr().String()))
+}
+
+// sendNotifies sends a NOTIFY to every configured secondary after each change
+func (b *BuiltinProvider) sendNotifies(ctx context.Context) {
+	client := &dns.Client{Net: "udp", Timeout: 5 * time.Second}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.changed:
+		}
+
+		b.mu.RLock()
+		soa := b.soa()
+		b.mu.RUnlock()
+
+		msg := new(dns.Msg)
+		msg.SetNotify(b.zone)
+		msg.Answer = []dns.RR{soa}
+
+		for _, target := range b.notify {
+			if err := notifySecondary(ctx, client, msg, target); err != nil {
+				b.logger.Warn("Failed to notify secondary",
+					zap.String("secondary", target),
+					zap.Uint32("serial", soa.Serial),
+					zap.Error(err))
+				continue
+			}
+			b.logger.Debug("Notified secondary",
+				zap.String("secondary", target),
+				zap.Uint32("serial", soa.Serial))
+		}
+	}
+}
+
+// notifySecondary sends msg to target, retrying a few times since NOTIFY is
+// sent over UDP
+func notifySecondary(ctx context.Context, client *dns.Client, msg *dns.Msg, target string) error {
+	var err error
+	for attempt := 0; attempt < 3; attempt++ {
+		var resp *dns.Msg
+		resp, _, err = client.ExchangeContext(ctx, msg.Copy(), target)
+		if err == nil {
+			if resp.Rcode != dns.RcodeSuccess {
+				return fmt.Errorf("secondary answered %s", dns.RcodeToString[resp.Rcode])
+			}
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		}
+	}
+	return err
+}
+
+// modify applies change to the records and, if change reports that anything
+// changed, bumps the serial and notifies the secondaries
+func (b *BuiltinProvider) modify(record DNSRecord, change func([]dns.RR, dns.RR) ([]dns.RR, bool)) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+	rr.Header().Name = dns.CanonicalName(rr.Header().Name)
+	if !dns.IsSubDomain(b.zone, rr.Header().Name) {
+		return fmt.Errorf("record %s is not in zone %s", record.Name, b.zone)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rrs, changed := change(b.rrs, rr)
+	if !changed {
+		return nil
+	}
+	b.rrs = rrs
+	b.serial = nextBuiltinSerial(b.serial, time.Now())
+
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (b *BuiltinProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var records []DNSRecord
+	for _, rr := range b.rrs {
+		if record, ok := fromRR(rr); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (b *BuiltinProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return b.modify(record, func(rrs []dns.RR, rr dns.RR) ([]dns.RR, bool) {
+		for _, existing := range rrs {
+			if dns.IsDuplicate(existing, rr) {
+				return rrs, false
+			}
+		}
+		return append(rrs, rr), true
+	})
+}
+
+func (b *BuiltinProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return b.modify(record, func(rrs []dns.RR, rr dns.RR) ([]dns.RR, bool) {
+		// Drop every value for the name and type, then add the new one,
+		// unless that value is already the only one
+		var kept []dns.RR
+		unchanged := true
+		matches := 0
+		for _, existing := range rrs {
+			header := existing.Header()
+			if header.Rrtype == rr.Header().Rrtype && header.Name == rr.Header().Name {
+				matches++
+				if !dns.IsDuplicate(existing, rr) || header.Ttl != rr.Header().Ttl {
+					unchanged = false
+				}
+				continue
+			}
+			kept = append(kept, existing)
+		}
+		if matches == 1 && unchanged {
+			return rrs, false
+		}
+		return append(kept, rr), true
+	})
+}
+
+func (b *BuiltinProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return b.modify(record, func(rrs []dns.RR, rr dns.RR) ([]dns.RR, bool) {
+		var kept []dns.RR
+		for _, existing := range rrs {
+			if !dns.IsDuplicate(existing, rr) {
+				kept = append(kept, existing)
+			}
+		}
+		return kept, len(kept) != len(rrs)
+	})
+}
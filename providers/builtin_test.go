@@ -0,0 +1,307 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startTestBuiltin starts a built-in server for example.com on a loopback
+// port, returning the provider and the address it answers on over UDP and TCP
+func startTestBuiltin(t *testing.T, cfg BuiltinConfig) (*BuiltinProvider, string) {
+	t.Helper()
+
+	cfg.Zone = "example.com"
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// Start needs the same port free for UDP and TCP, so retry if the UDP
+	// port turns out to be taken
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		addr := freeURL(t).Host
+		cfg.Listen = []string{addr}
+
+		var provider *BuiltinProvider
+		provider, err = NewBuiltinProvider(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = provider.Start(ctx); err == nil {
+			waitForBuiltin(t, addr)
+			return provider, addr
+		}
+	}
+	t.Fatal(err)
+	return nil, ""
+}
+
+// waitForBuiltin waits until the server at addr answers over UDP
+func waitForBuiltin(t *testing.T, addr string) {
+	t.Helper()
+
+	client := &dns.Client{Timeout: 100 * time.Millisecond}
+	for attempt := 0; attempt < 50; attempt++ {
+		msg := new(dns.Msg)
+		msg.SetQuestion("example.com.", dns.TypeSOA)
+		if _, _, err := client.Exchange(msg, addr); err == nil {
+			return
+		}
+	}
+	t.Fatal("built-in server didn't start answering")
+}
+
+// queryBuiltin asks the server at addr for name and qtype over UDP
+func queryBuiltin(t *testing.T, addr, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	resp, _, err := (&dns.Client{Timeout: 5 * time.Second}).Exchange(msg, addr)
+	if err != nil {
+		t.Fatalf("query %s %s: %v", name, dns.TypeToString[qtype], err)
+	}
+	return resp
+}
+
+// builtinSerial returns the SOA serial the server at addr answers with
+func builtinSerial(t *testing.T, addr string) uint32 {
+	t.Helper()
+
+	resp := queryBuiltin(t, addr, "example.com", dns.TypeSOA)
+	if len(resp.Answer) != 1 {
+		t.Fatalf("SOA query answered %v", resp.Answer)
+	}
+	return resp.Answer[0].(*dns.SOA).Serial
+}
+
+// expectNegative checks resp is a negative answer with rcode and the SOA in
+// the authority section
+func expectNegative(t *testing.T, resp *dns.Msg, rcode int) {
+	t.Helper()
+
+	if resp.Rcode != rcode || len(resp.Answer) != 0 {
+		t.Fatalf("got %s with answer %v, want %s without an answer", dns.RcodeToString[resp.Rcode], resp.Answer, dns.RcodeToString[rcode])
+	}
+	if len(resp.Ns) != 1 || resp.Ns[0].Header().Rrtype != dns.TypeSOA {
+		t.Fatalf("authority section = %v, want the SOA", resp.Ns)
+	}
+}
+
+func TestBuiltinProvider(t *testing.T) {
+	provider, _ := startTestBuiltin(t, BuiltinConfig{})
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestBuiltinAnswers(t *testing.T) {
+	ctx := context.Background()
+	provider, addr := startTestBuiltin(t, BuiltinConfig{Nameservers: []string{"ns1.example.com", "ns2.example.net"}})
+
+	for _, record := range []DNSRecord{
+		{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300},
+		{Name: "web1.example.com", Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300},
+		{Name: "web1.example.com", Type: "TXT", Value: "\"" + OwnershipPrefix + " node_id=n1\"", TTL: 300},
+		{Name: "ns1.example.com", Type: "A", Value: "100.64.0.53", TTL: 300},
+	} {
+		if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp := queryBuiltin(t, addr, "web1.example.com", dns.TypeA)
+	if !resp.Authoritative || len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "100.64.0.1" {
+		t.Fatalf("A answer = %v", resp.Answer)
+	}
+	resp = queryBuiltin(t, addr, "web1.example.com", dns.TypeAAAA)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.AAAA).AAAA.String() != "fd7a:115c:a1e0::1" {
+		t.Fatalf("AAAA answer = %v", resp.Answer)
+	}
+	resp = queryBuiltin(t, addr, "web1.example.com", dns.TypeTXT)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.TXT).Txt[0] != OwnershipPrefix+" node_id=n1" {
+		t.Fatalf("TXT answer = %v", resp.Answer)
+	}
+
+	resp = queryBuiltin(t, addr, "example.com", dns.TypeSOA)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.SOA).Ns != "ns1.example.com." {
+		t.Fatalf("SOA answer = %v", resp.Answer)
+	}
+	resp = queryBuiltin(t, addr, "example.com", dns.TypeNS)
+	if len(resp.Answer) != 2 {
+		t.Fatalf("NS answer = %v, want both name servers", resp.Answer)
+	}
+	// Only the name server inside the zone has glue
+	if len(resp.Extra) != 1 || resp.Extra[0].Header().Name != "ns1.example.com." {
+		t.Fatalf("NS glue = %v", resp.Extra)
+	}
+
+	expectNegative(t, queryBuiltin(t, addr, "missing.example.com", dns.TypeA), dns.RcodeNameError)
+	expectNegative(t, queryBuiltin(t, addr, "web1.example.com", dns.TypeSRV), dns.RcodeSuccess)
+
+	resp = queryBuiltin(t, addr, "web1.example.org", dns.TypeA)
+	if resp.Rcode != dns.RcodeRefused {
+		t.Fatalf("query outside the zone answered %s, want REFUSED", dns.RcodeToString[resp.Rcode])
+	}
+}
+
+func TestBuiltinWildcards(t *testing.T) {
+	ctx := context.Background()
+	provider, addr := startTestBuiltin(t, BuiltinConfig{})
+
+	for _, record := range []DNSRecord{
+		{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300},
+		{Name: "*.web1.example.com", Type: "A", Value: "100.64.0.9", TTL: 300},
+		{Name: "api.web1.example.com", Type: "TXT", Value: "\"hello\"", TTL: 300},
+	} {
+		if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Names below the wildcard that don't exist are synthesized
+	resp := queryBuiltin(t, addr, "app.web1.example.com", dns.TypeA)
+	if len(resp.Answer) != 1 || resp.Answer[0].Header().Name != "app.web1.example.com." || resp.Answer[0].(*dns.A).A.String() != "100.64.0.9" {
+		t.Fatalf("wildcard answer = %v", resp.Answer)
+	}
+	resp = queryBuiltin(t, addr, "deep.app.web1.example.com", dns.TypeA)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "100.64.0.9" {
+		t.Fatalf("wildcard answer for a deeper name = %v", resp.Answer)
+	}
+
+	// A name that exists isn't, even for a type it doesn't have
+	expectNegative(t, queryBuiltin(t, addr, "api.web1.example.com", dns.TypeA), dns.RcodeSuccess)
+
+	// The wildcard doesn't cover its own parent or names elsewhere
+	resp = queryBuiltin(t, addr, "web1.example.com", dns.TypeA)
+	if len(resp.Answer) != 1 || resp.Answer[0].(*dns.A).A.String() != "100.64.0.1" {
+		t.Fatalf("A answer = %v", resp.Answer)
+	}
+	expectNegative(t, queryBuiltin(t, addr, "app.web2.example.com", dns.TypeA), dns.RcodeNameError)
+}
+
+func TestBuiltinTransfer(t *testing.T) {
+	ctx := context.Background()
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+
+	provider, addr := startTestBuiltin(t, BuiltinConfig{AllowTransfer: []string{"127.0.0.0/8"}})
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := new(dns.Msg)
+	msg.SetAxfr("example.com.")
+	envelopes, err := new(dns.Transfer).In(msg, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rrs []dns.RR
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			t.Fatal(envelope.Error)
+		}
+		rrs = append(rrs, envelope.RR...)
+	}
+	// SOA, NS, the record and the closing SOA
+	if len(rrs) != 4 || rrs[0].Header().Rrtype != dns.TypeSOA || rrs[3].Header().Rrtype != dns.TypeSOA {
+		t.Fatalf("transfer = %v", rrs)
+	}
+	if a, ok := rrs[2].(*dns.A); !ok || a.A.String() != "100.64.0.1" {
+		t.Fatalf("transfer didn't include the record: %v", rrs)
+	}
+
+	// Transfers aren't served over UDP, even to allowed secondaries
+	resp, _, err := (&dns.Client{Timeout: 5 * time.Second}).Exchange(msg, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Rcode != dns.RcodeFormatError || len(resp.Answer) != 0 {
+		t.Fatalf("AXFR over UDP answered %s with %v, want FORMERR", dns.RcodeToString[resp.Rcode], resp.Answer)
+	}
+
+	// Secondaries outside allow_transfer are refused
+	_, addr = startTestBuiltin(t, BuiltinConfig{AllowTransfer: []string{"10.0.0.1"}})
+	envelopes, err = new(dns.Transfer).In(msg, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope := <-envelopes
+	if envelope == nil || envelope.Error == nil {
+		t.Fatal("transfer from an address outside allow_transfer wasn't refused")
+	}
+}
+
+func TestBuiltinSerialBumpsOnChange(t *testing.T) {
+	ctx := context.Background()
+	provider, addr := startTestBuiltin(t, BuiltinConfig{})
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+
+	serial := builtinSerial(t, addr)
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	changed := builtinSerial(t, addr)
+	if changed <= serial {
+		t.Fatalf("serial %d after a change, want more than %d", changed, serial)
+	}
+
+	// Writing what's already there changes nothing
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.DeleteRecord(ctx, "example.com", DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.2", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if got := builtinSerial(t, addr); got != changed {
+		t.Fatalf("serial %d after no-op writes, want %d", got, changed)
+	}
+
+	if err := provider.UpdateRecord(ctx, "example.com", DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.2", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if got := builtinSerial(t, addr); got <= changed {
+		t.Fatalf("serial %d after an update, want more than %d", got, changed)
+	}
+}
+
+func TestBuiltinNotifiesSecondaries(t *testing.T) {
+	ctx := context.Background()
+
+	// A stub secondary acknowledging NOTIFY messages
+	packetConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifies := make(chan *dns.Msg, 10)
+	secondary := &dns.Server{PacketConn: packetConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		if req.Opcode == dns.OpcodeNotify {
+			notifies <- req
+		}
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		w.WriteMsg(resp)
+	})}
+	go secondary.ActivateAndServe()
+	t.Cleanup(func() { secondary.Shutdown() })
+
+	provider, addr := startTestBuiltin(t, BuiltinConfig{Notify: []string{packetConn.LocalAddr().String()}})
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case notify := <-notifies:
+		if len(notify.Question) != 1 || notify.Question[0].Name != "example.com." {
+			t.Fatalf("NOTIFY question = %v", notify.Question)
+		}
+		if len(notify.Answer) != 1 || notify.Answer[0].(*dns.SOA).Serial != builtinSerial(t, addr) {
+			t.Fatalf("NOTIFY didn't carry the current serial: %v", notify.Answer)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("secondary wasn't notified")
+	}
+}
@@ -24,7 +24,7 @@ type CloudflareRecord struct {
 	ZoneName string                 `json:"zone_name,omitempty"`
 	Name     string                 `json:"name"`
 	Type     string                 `json:"type"`
-	Content  string                 `json:"content"`
+	Content  string                 `json:"content,omitempty"`
 	TTL      int                    `json:"ttl"`
 	Priority *int                   `json:"priority,omitempty"`
 	Proxied  *bool                  `json:"proxied,omitempty"`
@@ -141,113 +141,152 @@ func (c *CloudflareProvider) ListRecords(ctx context.Context, zone string) ([]DN
 	// Convert to our internal format
 	var dnsRecords []DNSRecord
 	for _, record := range records {
-		// Include A, AAAA, and TXT records
-		if record.Type == "A" || record.Type == "AAAA" || record.Type == "TXT" {
-			dnsRecords = append(dnsRecords, DNSRecord{
-				Name:  record.Name,
-				Type:  record.Type,
-				Value: record.Content,
-				TTL:   int64(record.TTL),
-			})
+		if dnsRecord, ok := fromCloudflareRecord(record); ok {
+			dnsRecords = append(dnsRecords, dnsRecord)
 		}
 	}
 
 	return dnsRecords, nil
 }
 
-func (c *CloudflareProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
-	endpoint := fmt.Sprintf("/zones/%s/dns_records", c.zoneID)
+// fromCloudflareRecord converts a Cloudflare record to our internal format,
+// returning false for types dnsscale doesn't manage
+func fromCloudflareRecord(record CloudflareRecord) (DNSRecord, bool) {
+	switch record.Type {
+	case "A", "AAAA", "TXT":
+		return DNSRecord{
+			Name:  record.Name,
+			Type:  record.Type,
+			Value: record.Content,
+			TTL:   int64(record.TTL),
+		}, true
+	case "SRV":
+		return cloudflareSRVRecord(record), true
+	}
+	return DNSRecord{}, false
+}
+
+// cloudflareSRVRecord converts a Cloudflare SRV record, whose fields are held
+// in its data object, to our internal format
+func cloudflareSRVRecord(record CloudflareRecord) DNSRecord {
+	dnsRecord := DNSRecord{
+		Name: record.Name,
+		Type: record.Type,
+		TTL:  int64(record.TTL),
+	}
+
+	number := func(key string) uint16 {
+		if v, ok := record.Data[key].(float64); ok {
+			return uint16(v)
+		}
+		return 0
+	}
+	dnsRecord.Priority = number("priority")
+	dnsRecord.Weight = number("weight")
+	dnsRecord.Port = number("port")
+	if target, ok := record.Data["target"].(string); ok {
+		dnsRecord.Value = target
+	}
+
+	return dnsRecord
+}
 
+// toCloudflareRecord converts a record to the body used to create or update it
+func toCloudflareRecord(record DNSRecord) CloudflareRecord {
 	cfRecord := CloudflareRecord{
-		Name:    record.Name,
-		Type:    record.Type,
-		Content: record.Value,
-		TTL:     int(record.TTL),
+		Name: record.Name,
+		Type: record.Type,
+		TTL:  int(record.TTL),
 	}
 
-	// Don't proxy A/AAAA records for Tailscale IPs (they're private)
-	proxied := false
-	cfRecord.Proxied = &proxied
+	switch record.Type {
+	case "SRV":
+		cfRecord.Data = map[string]interface{}{
+			"priority": record.Priority,
+			"weight":   record.Weight,
+			"port":     record.Port,
+			"target":   record.Value,
+		}
+	case "A", "AAAA":
+		cfRecord.Content = record.Value
+		// Don't proxy A/AAAA records for Tailscale IPs (they're private)
+		proxied := false
+		cfRecord.Proxied = &proxied
+	default:
+		cfRecord.Content = record.Value
+	}
+
+	return cfRecord
+}
+
+func (c *CloudflareProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	endpoint := fmt.Sprintf("/zones/%s/dns_records", c.zoneID)
 
-	_, err := c.makeRequest(ctx, "POST", endpoint, cfRecord)
+	_, err := c.makeRequest(ctx, "POST", endpoint, toCloudflareRecord(record))
 	return err
 }
 
-func (c *CloudflareProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
-	// First, find the existing record
-	existingRecords, err := c.ListRecords(ctx, zone)
+// findRecords returns the records with the record's name and type
+func (c *CloudflareProvider) findRecords(ctx context.Context, record DNSRecord) ([]CloudflareRecord, error) {
+	endpoint := fmt.Sprintf("/zones/%s/dns_records?name=%s&type=%s", c.zoneID, record.Name, record.Type)
+	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("failed to list existing records: %w", err)
+		return nil, err
 	}
 
-	var recordID string
-	var found bool
-	for _, existing := range existingRecords {
-		if existing.Name == record.Name && existing.Type == record.Type {
-			// We need to get the actual Cloudflare record ID
-			// Let's get it via the API
-			endpoint := fmt.Sprintf("/zones/%s/dns_records?name=%s&type=%s", c.zoneID, record.Name, record.Type)
-			resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
-			if err != nil {
-				return err
-			}
-
-			var records []CloudflareRecord
-			if err := json.Unmarshal(resp.Result, &records); err != nil {
-				return fmt.Errorf("failed to unmarshal DNS records: %w", err)
-			}
-
-			if len(records) > 0 {
-				recordID = records[0].ID
-				found = true
-				break
-			}
-		}
+	var records []CloudflareRecord
+	if err := json.Unmarshal(resp.Result, &records); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal DNS records: %w", err)
+	}
+	return records, nil
+}
+
+func (c *CloudflareProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := c.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
 	}
 
-	if !found {
+	if len(existing) == 0 {
 		// Record doesn't exist, create it
 		return c.CreateRecord(ctx, zone, record)
 	}
 
-	// Update the existing record
-	endpoint := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, recordID)
-
-	cfRecord := CloudflareRecord{
-		Name:    record.Name,
-		Type:    record.Type,
-		Content: record.Value,
-		TTL:     int(record.TTL),
+	// Update the record already holding the value, or the first one, and
+	// remove the others so a single value is left
+	keep := max(firstMatch(existing, record, fromCloudflareRecord), 0)
+	endpoint := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, existing[keep].ID)
+	if _, err := c.makeRequest(ctx, "PUT", endpoint, toCloudflareRecord(record)); err != nil {
+		return err
 	}
 
-	// Don't proxy A/AAAA records for Tailscale IPs
-	proxied := false
-	cfRecord.Proxied = &proxied
-
-	_, err = c.makeRequest(ctx, "PUT", endpoint, cfRecord)
-	return err
+	for i, extra := range existing {
+		if i == keep {
+			continue
+		}
+		deleteEndpoint := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, extra.ID)
+		if _, err := c.makeRequest(ctx, "DELETE", deleteEndpoint, nil); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (c *CloudflareProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
 	// First, find the record ID
-	endpoint := fmt.Sprintf("/zones/%s/dns_records?name=%s&type=%s", c.zoneID, record.Name, record.Type)
-	resp, err := c.makeRequest(ctx, "GET", endpoint, nil)
+	records, err := c.findRecords(ctx, record)
 	if err != nil {
 		return err
 	}
 
-	var records []CloudflareRecord
-	if err := json.Unmarshal(resp.Result, &records); err != nil {
-		return fmt.Errorf("failed to unmarshal DNS records: %w", err)
-	}
-
-	if len(records) == 0 {
+	// Only delete the record holding this value when several share a name
+	i := firstMatch(records, record, fromCloudflareRecord)
+	if i < 0 {
 		// Record doesn't exist, nothing to delete
 		return nil
 	}
 
-	// Delete the record
-	deleteEndpoint := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, records[0].ID)
+	deleteEndpoint := fmt.Sprintf("/zones/%s/dns_records/%s", c.zoneID, records[i].ID)
 	_, err = c.makeRequest(ctx, "DELETE", deleteEndpoint, nil)
 	return err
 }
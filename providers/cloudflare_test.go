@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCloudflare is a minimal stand-in for the Cloudflare DNS records API
+// serving one zone
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	records []CloudflareRecord
+	nextID  int
+}
+
+func newFakeCloudflare(t *testing.T) *CloudflareProvider {
+	t.Helper()
+
+	server := httptest.NewServer(&fakeCloudflare{})
+	t.Cleanup(server.Close)
+
+	provider, err := NewCloudflareProvider("token", "zone1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.baseURL = server.URL
+	return provider
+}
+
+func (f *fakeCloudflare) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	reply := func(result interface{}) {
+		raw, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(CloudflareResponse{Success: true, Result: raw})
+	}
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(CloudflareResponse{Errors: []CloudflareError{{Code: 1000, Message: message}}})
+	}
+
+	if req.Header.Get("Authorization") != "Bearer token" {
+		fail(http.StatusForbidden, "Authentication error")
+		return
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, "/zones/zone1/dns_records")
+	if !ok {
+		fail(http.StatusNotFound, "Could not route to "+req.URL.Path)
+		return
+	}
+
+	if path == "" {
+		switch req.Method {
+		case "GET":
+			query := req.URL.Query()
+			records := []CloudflareRecord{}
+			for _, record := range f.records {
+				if query.Has("name") && record.Name != query.Get("name") {
+					continue
+				}
+				if query.Has("type") && record.Type != query.Get("type") {
+					continue
+				}
+				records = append(records, record)
+			}
+			reply(records)
+		case "POST":
+			var record CloudflareRecord
+			if err := json.NewDecoder(req.Body).Decode(&record); err != nil {
+				fail(http.StatusBadRequest, err.Error())
+				return
+			}
+			f.nextID++
+			record.ID = fmt.Sprintf("rec%d", f.nextID)
+			f.records = append(f.records, record)
+			reply(record)
+		default:
+			fail(http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	id := strings.TrimPrefix(path, "/")
+	for i, record := range f.records {
+		if record.ID != id {
+			continue
+		}
+		switch req.Method {
+		case "PUT":
+			var updated CloudflareRecord
+			if err := json.NewDecoder(req.Body).Decode(&updated); err != nil {
+				fail(http.StatusBadRequest, err.Error())
+				return
+			}
+			updated.ID = id
+			f.records[i] = updated
+			reply(updated)
+		case "DELETE":
+			f.records = append(f.records[:i], f.records[i+1:]...)
+			reply(map[string]string{"id": id})
+		default:
+			fail(http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+	fail(http.StatusNotFound, "Record not found")
+}
+
+func TestCloudflareUpdateCollapsesValues(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeCloudflare(t)
+	name := "web1.example.com"
+
+	for _, value := range []string{"100.64.0.1", "100.64.0.2", "100.64.0.3"} {
+		if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "A", Value: value, TTL: 300}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := provider.UpdateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "A", Value: "100.64.0.2", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "A", "100.64.0.2")
+
+	if err := provider.UpdateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "A", Value: "100.64.0.4", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "A", "100.64.0.4")
+}
+
+func TestCloudflareDeleteMatchesValue(t *testing.T) {
+	ctx := context.Background()
+	provider := newFakeCloudflare(t)
+	name := "web1.example.com"
+
+	for _, value := range []string{"fd7a:115c:a1e0::1", "fd7a:115c:a1e0::2"} {
+		if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "AAAA", Value: value, TTL: 300}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Any spelling of the address identifies the record
+	if err := provider.DeleteRecord(ctx, "example.com", DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0:0:0:0:0:2", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "AAAA", "fd7a:115c:a1e0::1")
+}
@@ -0,0 +1,191 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+)
+
+// CoreDNSConfigMapConfig holds the settings needed to create a
+// CoreDNSConfigMapProvider
+type CoreDNSConfigMapConfig struct {
+	// Kubeconfig used to reach the cluster. The in-cluster configuration is
+	// used if empty.
+	Kubeconfig string
+	Context    string
+
+	Namespace string // Defaults to kube-system
+	ConfigMap string // Defaults to coredns
+	// ConfigMap key holding the hosts lines, defaults to Corefile
+	Key string
+	// Name of the managed block, written as "# BEGIN <name>" and "# END <name>"
+	Marker string
+
+	// Client overrides the clientset built from Kubeconfig, e.g. with a fake
+	// clientset in tests
+	Client kubernetes.Interface
+}
+
+// CoreDNSConfigMapProvider implements DNSProvider by maintaining a block of
+// hosts lines in a ConfigMap read by the CoreDNS hosts plugin. The block is
+// either inside a hosts block of the Corefile itself or in a dedicated key
+// mounted as the plugin's hosts file.
+//
+// Like HostsFileProvider, TXT records used to track ownership are kept as
+// comments inside the block. Writes carry the resourceVersion they were based
+// on, so a concurrent change to the ConfigMap makes them retry rather than be
+// lost.
+type CoreDNSConfigMapProvider struct {
+	client    kubernetes.Interface
+	namespace string
+	name      string
+	key       string
+	marker    string
+
+	mu sync.Mutex
+}
+
+func NewCoreDNSConfigMapProvider(cfg CoreDNSConfigMapConfig) (*CoreDNSConfigMapProvider, error) {
+	if cfg.Namespace == "" {
+		cfg.Namespace = "kube-system"
+	}
+	if cfg.ConfigMap == "" {
+		cfg.ConfigMap = "coredns"
+	}
+	if cfg.Key == "" {
+		cfg.Key = "Corefile"
+	}
+	if cfg.Marker == "" {
+		cfg.Marker = "dnsscale"
+	}
+
+	client := cfg.Client
+	if client == nil {
+		restConfig, err := kubernetesRESTConfig(cfg.Kubeconfig, cfg.Context)
+		if err != nil {
+			return nil, err
+		}
+		client, err = kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+		}
+	}
+
+	return &CoreDNSConfigMapProvider{
+		client:    client,
+		namespace: cfg.Namespace,
+		name:      cfg.ConfigMap,
+		key:       cfg.Key,
+		marker:    cfg.Marker,
+	}, nil
+}
+
+// kubernetesRESTConfig loads the client configuration from kubeconfig, or the
+// in-cluster configuration if kubeconfig is empty
+func kubernetesRESTConfig(kubeconfig, kubeContext string) (*rest.Config, error) {
+	if kubeconfig == "" {
+		restConfig, err := rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load in-cluster Kubernetes configuration: %w", err)
+		}
+		return restConfig, nil
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig %s: %w", kubeconfig, err)
+	}
+	return restConfig, nil
+}
+
+// read fetches the ConfigMap and parses the hosts lines in its key
+func (c *CoreDNSConfigMapProvider) read(ctx context.Context) (*corev1.ConfigMap, *hostsFile, error) {
+	configMap, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get configmap %s/%s: %w", c.namespace, c.name, err)
+	}
+
+	file, err := parseHostsFile([]byte(configMap.Data[c.key]), c.marker)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse key %s of configmap %s/%s: %w", c.key, c.namespace, c.name, err)
+	}
+	return configMap, file, nil
+}
+
+// modify applies change to the managed records and updates the ConfigMap if
+// change reports that anything changed, retrying on conflicting writes
+func (c *CoreDNSConfigMapProvider) modify(ctx context.Context, record DNSRecord, change func([]DNSRecord, DNSRecord) ([]DNSRecord, bool)) error {
+	record, err := hostsRecord(record)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, file, err := c.read(ctx)
+		if err != nil {
+			return err
+		}
+
+		var changed bool
+		file.records, changed = change(file.records, record)
+		if !changed {
+			return nil
+		}
+
+		// Appending the block to a Corefile would put hosts lines outside
+		// of any hosts block, so its markers have to be added by hand
+		if c.key == "Corefile" && !file.hasBlock {
+			return fmt.Errorf("key %s of configmap %s/%s has no %q line; add the markers inside a hosts block",
+				c.key, c.namespace, c.name, hostsBeginLine(c.marker))
+		}
+
+		// The ConfigMap keeps the resourceVersion it was read with, so the
+		// update fails with a conflict if it changed since
+		updated := configMap.DeepCopy()
+		if updated.Data == nil {
+			updated.Data = map[string]string{}
+		}
+		updated.Data[c.key] = string(renderHostsFile(file, c.marker))
+
+		if _, err := c.client.CoreV1().ConfigMaps(c.namespace).Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update configmap %s/%s: %w", c.namespace, c.name, err)
+		}
+		return nil
+	})
+}
+
+func (c *CoreDNSConfigMapProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, file, err := c.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return file.records, nil
+}
+
+func (c *CoreDNSConfigMapProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return c.modify(ctx, record, addHostsRecord)
+}
+
+func (c *CoreDNSConfigMapProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return c.modify(ctx, record, replaceHostsRecords)
+}
+
+func (c *CoreDNSConfigMapProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return c.modify(ctx, record, removeHostsRecord)
+}
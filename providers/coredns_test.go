@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestCoreDNSProvider returns a provider managing key of the coredns
+// ConfigMap in a fake clientset holding data
+func newTestCoreDNSProvider(t *testing.T, key string, data map[string]string) (*CoreDNSConfigMapProvider, *fake.Clientset) {
+	t.Helper()
+
+	client := fake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system", ResourceVersion: "1"},
+		Data:       data,
+	})
+	provider, err := NewCoreDNSConfigMapProvider(CoreDNSConfigMapConfig{Key: key, Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider, client
+}
+
+// configMapKey returns the current contents of a key of the coredns ConfigMap
+func configMapKey(t *testing.T, client *fake.Clientset, key string) string {
+	t.Helper()
+
+	configMap, err := client.CoreV1().ConfigMaps("kube-system").Get(context.Background(), "coredns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return configMap.Data[key]
+}
+
+func TestCoreDNSConfigMapProvider(t *testing.T) {
+	provider, _ := newTestCoreDNSProvider(t, "dnsscale.hosts", map[string]string{"Corefile": ".:53 {\n    forward . /etc/resolv.conf\n}\n"})
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestCoreDNSConfigMapCorefile(t *testing.T) {
+	ctx := context.Background()
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+
+	// Without markers there is nowhere in the Corefile to put hosts lines
+	provider, _ := newTestCoreDNSProvider(t, "Corefile", map[string]string{"Corefile": ".:53 {\n    forward . /etc/resolv.conf\n}\n"})
+	if err := provider.CreateRecord(ctx, "example.com", record); err == nil {
+		t.Fatal("CreateRecord succeeded on a Corefile without markers")
+	}
+
+	corefile := ".:53 {\n    hosts {\n        # BEGIN dnsscale\n        # END dnsscale\n        fallthrough\n    }\n    forward . /etc/resolv.conf\n}\n"
+	provider, client := newTestCoreDNSProvider(t, "Corefile", map[string]string{"Corefile": corefile})
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+
+	got := configMapKey(t, client, "Corefile")
+	begin := strings.Index(got, "# BEGIN dnsscale")
+	line := strings.Index(got, "100.64.0.1 web1.example.com")
+	end := strings.Index(got, "# END dnsscale")
+	if begin < 0 || line < begin || end < line {
+		t.Fatalf("record wasn't written between the markers:\n%s", got)
+	}
+	if !strings.Contains(got, "fallthrough") || !strings.Contains(got, "forward . /etc/resolv.conf") {
+		t.Fatalf("lines outside the block weren't preserved:\n%s", got)
+	}
+}
+
+func TestCoreDNSConfigMapRetriesConflicts(t *testing.T) {
+	provider, client := newTestCoreDNSProvider(t, "dnsscale.hosts", nil)
+
+	conflicts := 0
+	client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts < 2 {
+			conflicts++
+			return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "coredns", nil)
+		}
+		return false, nil, nil
+	})
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.CreateRecord(context.Background(), "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if conflicts != 2 {
+		t.Fatalf("saw %d conflicts, want 2", conflicts)
+	}
+	expectValues(t, provider, "example.com", "web1.example.com", "A", "100.64.0.1")
+}
@@ -0,0 +1,278 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// digitalOceanPageSize is the number of records requested per page, the
+// maximum the API allows
+const digitalOceanPageSize = 200
+
+// DigitalOceanProvider implements DNSProvider for DigitalOcean DNS
+type DigitalOceanProvider struct {
+	apiToken   string
+	domain     string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// DigitalOceanRecord represents a domain record in DigitalOcean's API
+type DigitalOceanRecord struct {
+	ID       int64   `json:"id,omitempty"`
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Data     string  `json:"data"`
+	TTL      int64   `json:"ttl,omitempty"`
+	Priority *uint16 `json:"priority"`
+	Port     *uint16 `json:"port"`
+	Weight   *uint16 `json:"weight"`
+}
+
+// DigitalOceanRecordsResponse represents a page of domain records
+type DigitalOceanRecordsResponse struct {
+	DomainRecords []DigitalOceanRecord `json:"domain_records"`
+	Links         struct {
+		Pages struct {
+			Next string `json:"next,omitempty"`
+		} `json:"pages"`
+	} `json:"links"`
+}
+
+// DigitalOceanErrorResponse represents an error returned by DigitalOcean's API
+type DigitalOceanErrorResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func NewDigitalOceanProvider(apiToken, domain string) (*DigitalOceanProvider, error) {
+	if apiToken == "" || domain == "" {
+		return nil, fmt.Errorf("API token and domain are required")
+	}
+
+	return &DigitalOceanProvider{
+		apiToken:   apiToken,
+		domain:     strings.TrimSuffix(domain, "."),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    "https://api.digitalocean.com/v2",
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the DigitalOcean API and decodes the
+// response into out when it is non-nil. endpoint is relative to the API base
+// URL, or an absolute URL when following pagination links.
+func (d *DigitalOceanProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := endpoint
+	if !strings.HasPrefix(endpoint, "http") {
+		reqURL = d.baseURL + endpoint
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+d.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp DigitalOceanErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Message != "" {
+			return fmt.Errorf("digitalocean API error: %s (id: %s)", errResp.Message, errResp.ID)
+		}
+		return fmt.Errorf("digitalocean API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// listDomainRecords fetches every page of records matching query
+func (d *DigitalOceanProvider) listDomainRecords(ctx context.Context, query url.Values) ([]DigitalOceanRecord, error) {
+	query.Set("per_page", fmt.Sprint(digitalOceanPageSize))
+	endpoint := fmt.Sprintf("/domains/%s/records?%s", url.PathEscape(d.domain), query.Encode())
+
+	var records []DigitalOceanRecord
+	for endpoint != "" {
+		var page DigitalOceanRecordsResponse
+		if err := d.makeRequest(ctx, "GET", endpoint, nil, &page); err != nil {
+			return nil, err
+		}
+		records = append(records, page.DomainRecords...)
+		endpoint = page.Links.Pages.Next
+	}
+
+	return records, nil
+}
+
+// fromDigitalOceanRecord converts a DigitalOcean record to our internal
+// format, returning false for types dnsscale doesn't manage
+func (d *DigitalOceanProvider) fromDigitalOceanRecord(record DigitalOceanRecord) (DNSRecord, bool) {
+	dnsRecord := DNSRecord{
+		Name:  absoluteName(record.Name, d.domain),
+		Type:  record.Type,
+		Value: record.Data,
+		TTL:   record.TTL,
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+	case "TXT":
+		// DigitalOcean stores TXT data unquoted, so quote it like other providers
+		dnsRecord.Value = "\"" + record.Data + "\""
+	case "SRV":
+		if record.Priority != nil {
+			dnsRecord.Priority = *record.Priority
+		}
+		if record.Weight != nil {
+			dnsRecord.Weight = *record.Weight
+		}
+		if record.Port != nil {
+			dnsRecord.Port = *record.Port
+		}
+		// Targets are returned fully qualified, or as "@" for the apex
+		target := record.Data
+		if target == "@" {
+			target = d.domain
+		}
+		dnsRecord.Value = strings.TrimSuffix(target, ".")
+	default:
+		return DNSRecord{}, false
+	}
+
+	return dnsRecord, true
+}
+
+// toDigitalOceanRecord converts a record to the body used to create or update it
+func (d *DigitalOceanProvider) toDigitalOceanRecord(record DNSRecord) (DigitalOceanRecord, error) {
+	name, err := relativeName(record.Name, d.domain)
+	if err != nil {
+		return DigitalOceanRecord{}, err
+	}
+
+	doRecord := DigitalOceanRecord{
+		Type: record.Type,
+		Name: name,
+		Data: record.Value,
+		TTL:  record.TTL,
+	}
+
+	switch record.Type {
+	case "TXT":
+		doRecord.Data = strings.Trim(record.Value, "\"")
+	case "SRV":
+		doRecord.Data = fqdn(record.Value)
+		doRecord.Priority = &record.Priority
+		doRecord.Weight = &record.Weight
+		doRecord.Port = &record.Port
+	}
+
+	return doRecord, nil
+}
+
+// findRecords returns the DigitalOcean records with the record's name and type
+func (d *DigitalOceanProvider) findRecords(ctx context.Context, record DNSRecord) ([]DigitalOceanRecord, error) {
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(record.Name, "."))
+	query.Set("type", record.Type)
+	return d.listDomainRecords(ctx, query)
+}
+
+func (d *DigitalOceanProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	records, err := d.listDomainRecords(ctx, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var dnsRecords []DNSRecord
+	for _, record := range records {
+		if dnsRecord, ok := d.fromDigitalOceanRecord(record); ok {
+			dnsRecords = append(dnsRecords, dnsRecord)
+		}
+	}
+
+	return dnsRecords, nil
+}
+
+func (d *DigitalOceanProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	doRecord, err := d.toDigitalOceanRecord(record)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("/domains/%s/records", url.PathEscape(d.domain))
+	return d.makeRequest(ctx, "POST", endpoint, doRecord, nil)
+}
+
+func (d *DigitalOceanProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := d.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	if len(existing) == 0 {
+		// Record doesn't exist, create it
+		return d.CreateRecord(ctx, zone, record)
+	}
+
+	doRecord, err := d.toDigitalOceanRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// Update the first record and remove any others, leaving a single value
+	endpoint := fmt.Sprintf("/domains/%s/records/%d", url.PathEscape(d.domain), existing[0].ID)
+	if err := d.makeRequest(ctx, "PUT", endpoint, doRecord, nil); err != nil {
+		return err
+	}
+	for _, extra := range existing[1:] {
+		endpoint := fmt.Sprintf("/domains/%s/records/%d", url.PathEscape(d.domain), extra.ID)
+		if err := d.makeRequest(ctx, "DELETE", endpoint, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DigitalOceanProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := d.findRecords(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	i := firstMatch(existing, record, d.fromDigitalOceanRecord)
+	if i < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("/domains/%s/records/%d", url.PathEscape(d.domain), existing[i].ID)
+	return d.makeRequest(ctx, "DELETE", endpoint, nil, nil)
+}
@@ -0,0 +1,324 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnsimplePageSize is the number of records requested per page, the maximum
+// the API allows
+const dnsimplePageSize = 100
+
+// DNSimpleProvider implements DNSProvider for DNSimple.
+//
+// DNSimple returns record names relative to the zone, with an empty name for
+// the apex, so names are converted in both directions.
+type DNSimpleProvider struct {
+	apiToken   string
+	accountID  string
+	zone       string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// DNSimpleRecord represents a zone record in DNSimple's API
+type DNSimpleRecord struct {
+	ID       int64   `json:"id,omitempty"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Content  string  `json:"content"`
+	TTL      int64   `json:"ttl,omitempty"`
+	Priority *uint16 `json:"priority,omitempty"`
+}
+
+// DNSimpleRecordsResponse represents a page of zone records
+type DNSimpleRecordsResponse struct {
+	Data       []DNSimpleRecord `json:"data"`
+	Pagination struct {
+		CurrentPage int `json:"current_page"`
+		TotalPages  int `json:"total_pages"`
+	} `json:"pagination"`
+}
+
+// DNSimpleErrorResponse represents an error returned by DNSimple's API
+type DNSimpleErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// NewDNSimpleProvider creates a provider for a DNSimple zone. sandbox selects
+// DNSimple's sandbox environment, which needs its own account and token.
+func NewDNSimpleProvider(apiToken, accountID, zone string, sandbox bool) (*DNSimpleProvider, error) {
+	if apiToken == "" || accountID == "" || zone == "" {
+		return nil, fmt.Errorf("API token, account ID and zone are required")
+	}
+
+	baseURL := "https://api.dnsimple.com/v2"
+	if sandbox {
+		baseURL = "https://api.sandbox.dnsimple.com/v2"
+	}
+
+	return &DNSimpleProvider{
+		apiToken:   apiToken,
+		accountID:  accountID,
+		zone:       strings.TrimSuffix(zone, "."),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    baseURL,
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the zone's records in the DNSimple API
+// and decodes the response into out when it is non-nil
+func (d *DNSimpleProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := fmt.Sprintf("%s/%s/zones/%s/records%s", d.baseURL, url.PathEscape(d.accountID), url.PathEscape(d.zone), endpoint)
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+d.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp DNSimpleErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Message != "" {
+			return fmt.Errorf("dnsimple API error: %s (code: %d)", errResp.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("dnsimple API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// listZoneRecords fetches every page of records matching query
+func (d *DNSimpleProvider) listZoneRecords(ctx context.Context, query url.Values) ([]DNSimpleRecord, error) {
+	query.Set("per_page", fmt.Sprint(dnsimplePageSize))
+
+	var records []DNSimpleRecord
+	for page := 1; ; page++ {
+		query.Set("page", fmt.Sprint(page))
+
+		var resp DNSimpleRecordsResponse
+		if err := d.makeRequest(ctx, "GET", "?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		records = append(records, resp.Data...)
+
+		if page >= resp.Pagination.TotalPages {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// dnsimpleName converts a fully qualified name to DNSimple's relative form
+func (d *DNSimpleProvider) dnsimpleName(name string) (string, error) {
+	relative, err := relativeName(name, d.zone)
+	if err != nil {
+		return "", err
+	}
+	if relative == "@" {
+		return "", nil
+	}
+	return relative, nil
+}
+
+// fromDNSimpleRecord converts a DNSimple record to our internal format,
+// returning false for types dnsscale doesn't manage
+func (d *DNSimpleProvider) fromDNSimpleRecord(record DNSimpleRecord) (DNSRecord, bool) {
+	dnsRecord := DNSRecord{
+		Name:  absoluteName(record.Name, d.zone),
+		Type:  record.Type,
+		Value: record.Content,
+		TTL:   record.TTL,
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+	case "TXT":
+		// Content may or may not be quoted depending on how it was created
+		dnsRecord.Value = "\"" + strings.Trim(record.Content, "\"") + "\""
+	case "SRV":
+		// Content is "<weight> <port> <target>", the priority is separate
+		fields := strings.Fields(record.Content)
+		if len(fields) != 3 {
+			return DNSRecord{}, false
+		}
+		weight, errWeight := strconv.ParseUint(fields[0], 10, 16)
+		port, errPort := strconv.ParseUint(fields[1], 10, 16)
+		if errWeight != nil || errPort != nil {
+			return DNSRecord{}, false
+		}
+		if record.Priority != nil {
+			dnsRecord.Priority = *record.Priority
+		}
+		dnsRecord.Weight = uint16(weight)
+		dnsRecord.Port = uint16(port)
+		dnsRecord.Value = strings.TrimSuffix(fields[2], ".")
+	default:
+		return DNSRecord{}, false
+	}
+
+	return dnsRecord, true
+}
+
+// toDNSimpleRecord converts a record to the body used to create or update it
+func (d *DNSimpleProvider) toDNSimpleRecord(record DNSRecord) (DNSimpleRecord, error) {
+	name, err := d.dnsimpleName(record.Name)
+	if err != nil {
+		return DNSimpleRecord{}, err
+	}
+
+	dnsimpleRecord := DNSimpleRecord{
+		Name:    name,
+		Type:    record.Type,
+		Content: record.Value,
+		TTL:     record.TTL,
+	}
+
+	switch record.Type {
+	case "TXT":
+		dnsimpleRecord.Content = strings.Trim(record.Value, "\"")
+	case "SRV":
+		dnsimpleRecord.Content = fmt.Sprintf("%d %d %s", record.Weight, record.Port, strings.TrimSuffix(record.Value, "."))
+		dnsimpleRecord.Priority = &record.Priority
+	}
+
+	return dnsimpleRecord, nil
+}
+
+// findRecords returns the DNSimple records with the record's name and type
+func (d *DNSimpleProvider) findRecords(ctx context.Context, record DNSRecord) ([]DNSimpleRecord, error) {
+	name, err := d.dnsimpleName(record.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("type", record.Type)
+	return d.listZoneRecords(ctx, query)
+}
+
+func (d *DNSimpleProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	records, err := d.listZoneRecords(ctx, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+
+	var dnsRecords []DNSRecord
+	for _, record := range records {
+		if dnsRecord, ok := d.fromDNSimpleRecord(record); ok {
+			dnsRecords = append(dnsRecords, dnsRecord)
+		}
+	}
+
+	return dnsRecords, nil
+}
+
+func (d *DNSimpleProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	dnsimpleRecord, err := d.toDNSimpleRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// DNSimple refuses to create a record that already exists
+	existing, err := d.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+	if firstMatch(existing, record, d.fromDNSimpleRecord) >= 0 {
+		return nil
+	}
+
+	return d.makeRequest(ctx, "POST", "", dnsimpleRecord, nil)
+}
+
+func (d *DNSimpleProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := d.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	if len(existing) == 0 {
+		// Record doesn't exist, create it
+		dnsimpleRecord, err := d.toDNSimpleRecord(record)
+		if err != nil {
+			return err
+		}
+		return d.makeRequest(ctx, "POST", "", dnsimpleRecord, nil)
+	}
+	if len(existing) == 1 && existing[0].TTL == record.TTL && firstMatch(existing, record, d.fromDNSimpleRecord) == 0 {
+		// Already holds only this value
+		return nil
+	}
+
+	dnsimpleRecord, err := d.toDNSimpleRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// Update the first record and remove any others, leaving a single value.
+	// The type can't be changed, so it isn't sent.
+	update := map[string]interface{}{
+		"content": dnsimpleRecord.Content,
+		"ttl":     dnsimpleRecord.TTL,
+	}
+	if dnsimpleRecord.Priority != nil {
+		update["priority"] = *dnsimpleRecord.Priority
+	}
+	if err := d.makeRequest(ctx, "PATCH", fmt.Sprintf("/%d", existing[0].ID), update, nil); err != nil {
+		return err
+	}
+	for _, extra := range existing[1:] {
+		if err := d.makeRequest(ctx, "DELETE", fmt.Sprintf("/%d", extra.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DNSimpleProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := d.findRecords(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	i := firstMatch(existing, record, d.fromDNSimpleRecord)
+	if i < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+	return d.makeRequest(ctx, "DELETE", fmt.Sprintf("/%d", existing[i].ID), nil, nil)
+}
@@ -0,0 +1,160 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDNSimple is a minimal stand-in for the DNSimple sandbox serving the
+// records of one zone
+type fakeDNSimple struct {
+	mu      sync.Mutex
+	records map[int64]DNSimpleRecord
+	nextID  int64
+	writes  int
+}
+
+func newFakeDNSimple(t *testing.T) (*fakeDNSimple, *DNSimpleProvider) {
+	t.Helper()
+
+	fake := &fakeDNSimple{records: map[int64]DNSimpleRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewDNSimpleProvider("token", "1010", "example.com", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.baseURL = server.URL + "/v2"
+	return fake, provider
+}
+
+func (f *fakeDNSimple) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/1010/zones/example.com/records")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if path == "" {
+		switch req.Method {
+		case "GET":
+			query := req.URL.Query()
+			resp := DNSimpleRecordsResponse{Data: []DNSimpleRecord{}}
+			for _, record := range f.records {
+				if query.Has("name") && record.Name != query.Get("name") {
+					continue
+				}
+				if query.Has("type") && record.Type != query.Get("type") {
+					continue
+				}
+				resp.Data = append(resp.Data, record)
+			}
+			resp.Pagination.CurrentPage = 1
+			resp.Pagination.TotalPages = 1
+			json.NewEncoder(w).Encode(resp)
+		case "POST":
+			var record DNSimpleRecord
+			if err := json.NewDecoder(req.Body).Decode(&record); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, existing := range f.records {
+				if existing.Name == record.Name && existing.Type == record.Type && existing.Content == record.Content {
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(DNSimpleErrorResponse{Message: "Zone record already exists"})
+					return
+				}
+			}
+			f.nextID++
+			f.writes++
+			record.ID = f.nextID
+			f.records[record.ID] = record
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]DNSimpleRecord{"data": record})
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(path, "/"), 10, 64)
+	record, exists := f.records[id]
+	if err != nil || !exists {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch req.Method {
+	case "PATCH":
+		var update struct {
+			Content  *string `json:"content"`
+			TTL      *int64  `json:"ttl"`
+			Priority *uint16 `json:"priority"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if update.Content != nil {
+			record.Content = *update.Content
+		}
+		if update.TTL != nil {
+			record.TTL = *update.TTL
+		}
+		if update.Priority != nil {
+			record.Priority = update.Priority
+		}
+		f.writes++
+		f.records[id] = record
+		json.NewEncoder(w).Encode(map[string]DNSimpleRecord{"data": record})
+	case "DELETE":
+		f.writes++
+		delete(f.records, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestDNSimpleProvider(t *testing.T) {
+	_, provider := newFakeDNSimple(t)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestDNSimpleUpdateUnchanged(t *testing.T) {
+	ctx := context.Background()
+	fake, provider := newFakeDNSimple(t)
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.mu.Lock()
+	writes := fake.writes
+	fake.mu.Unlock()
+
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.writes != writes {
+		t.Fatalf("updating to the current value made %d writes, want none", fake.writes-writes)
+	}
+}
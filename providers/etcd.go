@@ -0,0 +1,457 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/client/pkg/v3/transport"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// etcdKeyPrefix starts the last path segment of every key dnsscale writes.
+// Keys are derived from the record's type and value so writing a value twice
+// leaves a single key.
+const etcdKeyPrefix = "dnsscale-"
+
+// EtcdProvider implements DNSProvider for CoreDNS's etcd plugin. Records are
+// stored as SkyDNS messages under a path built from the reversed labels of
+// their name, e.g. /skydns/com/example/node/dnsscale-<hash> for
+// node.example.com.
+//
+// SkyDNS messages don't carry a record type, so it's inferred when reading
+// them back: text makes a TXT record, an address host an A or AAAA record, a
+// host with a port an SRV record and any other host a CNAME record.
+type EtcdProvider struct {
+	client   *clientv3.Client
+	prefix   string
+	zone     string
+	timeout  time.Duration
+	leaseTTL time.Duration
+
+	mu    sync.Mutex
+	lease clientv3.LeaseID
+
+	// Signalled when the lease is lost along with every key attached to it
+	resync chan struct{}
+}
+
+// EtcdConfig holds the settings for connecting to etcd
+type EtcdConfig struct {
+	Endpoints []string
+	Username  string
+	Password  string
+	// Client certificate and CA used for TLS, all optional
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// Path prefix configured in CoreDNS, defaults to /skydns
+	Prefix string
+	Zone   string
+	// Timeout for each etcd request, defaults to 10 seconds
+	Timeout time.Duration
+	// When set, keys are attached to a lease kept alive while dnsscale runs
+	// so records expire if it stops
+	LeaseTTL time.Duration
+}
+
+// EtcdMessage represents a SkyDNS message as read by CoreDNS
+type EtcdMessage struct {
+	Host     string `json:"host,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+	Priority uint16 `json:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty"`
+	Text     string `json:"text,omitempty"`
+	TTL      uint32 `json:"ttl,omitempty"`
+}
+
+// NewEtcdProvider creates a provider writing SkyDNS messages to etcd
+func NewEtcdProvider(cfg EtcdConfig) (*EtcdProvider, error) {
+	if len(cfg.Endpoints) == 0 || cfg.Zone == "" {
+		return nil, fmt.Errorf("endpoints and zone are required")
+	}
+
+	if cfg.Prefix == "" {
+		cfg.Prefix = "/skydns"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.LeaseTTL > 0 && cfg.LeaseTTL < 5*time.Second {
+		return nil, fmt.Errorf("lease TTL must be at least 5s")
+	}
+
+	clientConfig := clientv3.Config{
+		Endpoints:   cfg.Endpoints,
+		Username:    cfg.Username,
+		Password:    cfg.Password,
+		DialTimeout: cfg.Timeout,
+	}
+
+	if cfg.CertFile != "" || cfg.CAFile != "" {
+		tlsInfo := transport.TLSInfo{
+			CertFile:      cfg.CertFile,
+			KeyFile:       cfg.KeyFile,
+			TrustedCAFile: cfg.CAFile,
+		}
+		tlsConfig, err := tlsInfo.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load etcd TLS configuration: %w", err)
+		}
+		clientConfig.TLS = tlsConfig
+	}
+
+	client, err := clientv3.New(clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd client: %w", err)
+	}
+
+	return &EtcdProvider{
+		client:   client,
+		prefix:   "/" + strings.Trim(cfg.Prefix, "/"),
+		zone:     strings.TrimSuffix(cfg.Zone, "."),
+		timeout:  cfg.Timeout,
+		leaseTTL: cfg.LeaseTTL,
+		lease:    clientv3.NoLease,
+		resync:   make(chan struct{}, 1),
+	}, nil
+}
+
+// namePath returns the etcd path of a name in SkyDNS's reversed label form
+func (e *EtcdProvider) namePath(name string) string {
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(name, ".")), ".")
+	segments := []string{e.prefix}
+	for i := len(labels) - 1; i >= 0; i-- {
+		segments = append(segments, labels[i])
+	}
+	return path.Join(segments...)
+}
+
+// pathName returns the name a key is served under, or false if the key isn't
+// below the prefix. Keys dnsscale wrote belong to the name of their parent.
+func (e *EtcdProvider) pathName(key string) (string, bool) {
+	if !strings.HasPrefix(key, e.prefix+"/") {
+		return "", false
+	}
+
+	segments := strings.Split(strings.TrimPrefix(key, e.prefix+"/"), "/")
+	if strings.HasPrefix(segments[len(segments)-1], etcdKeyPrefix) {
+		segments = segments[:len(segments)-1]
+	}
+	if len(segments) == 0 {
+		return "", false
+	}
+
+	labels := make([]string, 0, len(segments))
+	for i := len(segments) - 1; i >= 0; i-- {
+		labels = append(labels, segments[i])
+	}
+	return strings.Join(labels, "."), true
+}
+
+// recordKey returns the key holding a record's value
+func (e *EtcdProvider) recordKey(record DNSRecord) string {
+	sum := sha256.Sum256([]byte(record.Type + " " + etcdValue(record)))
+	return e.namePath(record.Name) + "/" + etcdKeyPrefix + hex.EncodeToString(sum[:8])
+}
+
+// etcdValue returns a record's value in the form used to derive its key
+func etcdValue(record DNSRecord) string {
+	switch record.Type {
+	case "TXT":
+		return strings.Trim(record.Value, "\"")
+	case "SRV":
+		record.Value = strings.ToLower(strings.TrimSuffix(record.Value, "."))
+		return record.SRVValue()
+	case "CNAME":
+		return strings.ToLower(strings.TrimSuffix(record.Value, "."))
+	}
+	if addr, err := netip.ParseAddr(record.Value); err == nil {
+		return addr.String()
+	}
+	return record.Value
+}
+
+// toEtcdMessage converts a record to a SkyDNS message
+func toEtcdMessage(record DNSRecord) (EtcdMessage, error) {
+	if strings.Contains(record.Name, "*") {
+		return EtcdMessage{}, fmt.Errorf("etcd can't hold wildcard records: %s", record.Name)
+	}
+
+	msg := EtcdMessage{TTL: uint32(record.TTL)}
+
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil {
+			return EtcdMessage{}, fmt.Errorf("invalid %s record value %q: %w", record.Type, record.Value, err)
+		}
+		msg.Host = addr.String()
+	case "CNAME":
+		msg.Host = strings.TrimSuffix(record.Value, ".")
+	case "TXT":
+		msg.Text = strings.Trim(record.Value, "\"")
+	case "SRV":
+		msg.Host = strings.TrimSuffix(record.Value, ".")
+		msg.Port = record.Port
+		msg.Priority = record.Priority
+		msg.Weight = record.Weight
+	default:
+		return EtcdMessage{}, fmt.Errorf("etcd can't hold %s records", record.Type)
+	}
+
+	return msg, nil
+}
+
+// fromEtcdMessage converts a SkyDNS message to our internal format
+func fromEtcdMessage(name string, msg EtcdMessage) (DNSRecord, bool) {
+	record := DNSRecord{Name: name, TTL: int64(msg.TTL)}
+
+	switch {
+	case msg.Text != "":
+		record.Type = "TXT"
+		record.Value = "\"" + msg.Text + "\""
+	case msg.Host == "":
+		return DNSRecord{}, false
+	case msg.Port != 0:
+		record.Type = "SRV"
+		record.Value = strings.TrimSuffix(msg.Host, ".")
+		record.Port = msg.Port
+		record.Priority = msg.Priority
+		record.Weight = msg.Weight
+	default:
+		if addr, err := netip.ParseAddr(msg.Host); err == nil {
+			record.Type = "A"
+			if addr.Is6() {
+				record.Type = "AAAA"
+			}
+			record.Value = addr.String()
+		} else {
+			record.Type = "CNAME"
+			record.Value = strings.TrimSuffix(msg.Host, ".")
+		}
+	}
+
+	return record, true
+}
+
+// leaseID returns the lease new keys are attached to, granting one and
+// keeping it alive in the background if needed
+func (e *EtcdProvider) leaseID(ctx context.Context) (clientv3.LeaseID, error) {
+	if e.leaseTTL <= 0 {
+		return clientv3.NoLease, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.lease != clientv3.NoLease {
+		return e.lease, nil
+	}
+
+	grant, err := e.client.Grant(ctx, int64(e.leaseTTL/time.Second))
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("failed to grant etcd lease: %w", err)
+	}
+
+	// The lease lives as long as dnsscale does, so it isn't tied to ctx
+	keepAlive, err := e.client.KeepAlive(context.Background(), grant.ID)
+	if err != nil {
+		return clientv3.NoLease, fmt.Errorf("failed to keep etcd lease alive: %w", err)
+	}
+	go func() {
+		for range keepAlive {
+		}
+		// The lease was lost along with its keys, which are recreated under a
+		// new lease once every record is resynced
+		e.mu.Lock()
+		if e.lease == grant.ID {
+			e.lease = clientv3.NoLease
+		}
+		e.mu.Unlock()
+
+		select {
+		case e.resync <- struct{}{}:
+		default:
+			// A resync is already pending
+		}
+	}()
+
+	e.lease = grant.ID
+	return e.lease, nil
+}
+
+// Resync returns a channel signalled when the lease is lost, so every record
+// has to be written again
+func (e *EtcdProvider) Resync() <-chan struct{} {
+	return e.resync
+}
+
+// putOp returns the operation writing a record's message
+func (e *EtcdProvider) putOp(ctx context.Context, record DNSRecord) (clientv3.Op, error) {
+	msg, err := toEtcdMessage(record)
+	if err != nil {
+		return clientv3.Op{}, err
+	}
+	value, err := json.Marshal(msg)
+	if err != nil {
+		return clientv3.Op{}, fmt.Errorf("failed to encode SkyDNS message: %w", err)
+	}
+
+	lease, err := e.leaseID(ctx)
+	if err != nil {
+		return clientv3.Op{}, err
+	}
+	if lease == clientv3.NoLease {
+		return clientv3.OpPut(e.recordKey(record), string(value)), nil
+	}
+	return clientv3.OpPut(e.recordKey(record), string(value), clientv3.WithLease(lease)), nil
+}
+
+func (e *EtcdProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	resp, err := e.client.Get(ctx, e.namePath(e.zone)+"/", clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd keys: %w", err)
+	}
+
+	var records []DNSRecord
+	for _, kv := range resp.Kvs {
+		name, ok := e.pathName(string(kv.Key))
+		if !ok {
+			continue
+		}
+
+		var msg EtcdMessage
+		if err := json.Unmarshal(kv.Value, &msg); err != nil {
+			// Not a SkyDNS message, CoreDNS ignores it too
+			continue
+		}
+		if record, ok := fromEtcdMessage(name, msg); ok {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func (e *EtcdProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	op, err := e.putOp(ctx, record)
+	if err != nil {
+		return err
+	}
+	if _, err := e.client.Do(ctx, op); err != nil {
+		return fmt.Errorf("failed to write etcd key: %w", err)
+	}
+	return nil
+}
+
+// nameValues returns the keys holding values of the record's name and type:
+// the ones dnsscale wrote below the name's path and a message stored at the
+// path itself
+func (e *EtcdProvider) nameValues(ctx context.Context, record DNSRecord) ([]*mvccpb.KeyValue, error) {
+	namePath := e.namePath(record.Name)
+	resp, err := e.client.Get(ctx, namePath, clientv3.WithPrefix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list etcd keys: %w", err)
+	}
+
+	var values []*mvccpb.KeyValue
+	for _, kv := range resp.Kvs {
+		key := string(kv.Key)
+		if key != namePath && !strings.HasPrefix(key, namePath+"/"+etcdKeyPrefix) {
+			continue
+		}
+		var msg EtcdMessage
+		if err := json.Unmarshal(kv.Value, &msg); err != nil {
+			continue
+		}
+		if existing, ok := fromEtcdMessage(record.Name, msg); ok && existing.Type == record.Type {
+			values = append(values, kv)
+		}
+	}
+	return values, nil
+}
+
+// etcdKeyMatches reports whether a key holds record's value
+func etcdKeyMatches(kv *mvccpb.KeyValue, record DNSRecord) bool {
+	var msg EtcdMessage
+	if err := json.Unmarshal(kv.Value, &msg); err != nil {
+		return false
+	}
+	existing, ok := fromEtcdMessage(record.Name, msg)
+	return ok && existing.Type == record.Type && etcdValue(existing) == etcdValue(record)
+}
+
+func (e *EtcdProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	put, err := e.putOp(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	existing, err := e.nameValues(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	// Remove the other values of the type at the name, leaving a single value
+	key := e.recordKey(record)
+	ops := []clientv3.Op{put}
+	var cmps []clientv3.Cmp
+	for _, kv := range existing {
+		if string(kv.Key) == key {
+			continue
+		}
+		ops = append(ops, clientv3.OpDelete(string(kv.Key)))
+		cmps = append(cmps, clientv3.Compare(clientv3.ModRevision(string(kv.Key)), "=", kv.ModRevision))
+	}
+
+	// Apply the changes together so the name is never left without a value,
+	// failing if another writer changed the keys being removed
+	txn, err := e.client.Txn(ctx).If(cmps...).Then(ops...).Commit()
+	if err != nil {
+		return fmt.Errorf("failed to update etcd keys: %w", err)
+	}
+	if !txn.Succeeded {
+		return fmt.Errorf("etcd keys for %s changed during update", record.Name)
+	}
+	return nil
+}
+
+func (e *EtcdProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	existing, err := e.nameValues(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range existing {
+		if !etcdKeyMatches(kv, record) {
+			continue
+		}
+		if _, err := e.client.Delete(ctx, string(kv.Key)); err != nil {
+			return fmt.Errorf("failed to delete etcd key: %w", err)
+		}
+	}
+
+	// Record doesn't exist, nothing to delete
+	return nil
+}
@@ -0,0 +1,125 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+	"go.uber.org/zap"
+)
+
+// freeURL returns a local http URL on a port that was free a moment ago
+func freeURL(t *testing.T) url.URL {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return url.URL{Scheme: "http", Host: listener.Addr().String()}
+}
+
+// startEmbeddedEtcd runs a single member etcd cluster for the test and
+// returns its client endpoint
+func startEmbeddedEtcd(t *testing.T) string {
+	t.Helper()
+
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.ZapLoggerBuilder = embed.NewZapLoggerBuilder(zap.NewNop())
+	clientURL, peerURL := freeURL(t), freeURL(t)
+	cfg.ListenClientUrls = []url.URL{clientURL}
+	cfg.AdvertiseClientUrls = []url.URL{clientURL}
+	cfg.ListenPeerUrls = []url.URL{peerURL}
+	cfg.AdvertisePeerUrls = []url.URL{peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	server, err := embed.StartEtcd(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(30 * time.Second):
+		t.Fatal("embedded etcd didn't become ready")
+	}
+	return clientURL.String()
+}
+
+func newTestEtcdProvider(t *testing.T, endpoint string, leaseTTL time.Duration) *EtcdProvider {
+	t.Helper()
+
+	provider, err := NewEtcdProvider(EtcdConfig{
+		Endpoints: []string{endpoint},
+		Zone:      "example.com",
+		LeaseTTL:  leaseTTL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { provider.client.Close() })
+	return provider
+}
+
+func TestEtcdProvider(t *testing.T) {
+	endpoint := startEmbeddedEtcd(t)
+	testProviderSemantics(t, newTestEtcdProvider(t, endpoint, 0), "example.com")
+}
+
+func TestEtcdLeaseLossRequestsResync(t *testing.T) {
+	ctx := context.Background()
+	endpoint := startEmbeddedEtcd(t)
+	provider := newTestEtcdProvider(t, endpoint, 5*time.Second)
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", "web1.example.com", "A", "100.64.0.1")
+
+	// Revoke the lease from another client, as if etcd had expired it while
+	// dnsscale was partitioned away
+	client, err := clientv3.New(clientv3.Config{Endpoints: []string{endpoint}, DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	leases, err := client.Leases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases.Leases) != 1 {
+		t.Fatalf("found %d leases, want 1", len(leases.Leases))
+	}
+	if _, err := client.Revoke(ctx, leases.Leases[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", "web1.example.com", "A")
+
+	select {
+	case <-provider.Resync():
+	case <-time.After(15 * time.Second):
+		t.Fatal("losing the lease didn't request a resync")
+	}
+
+	// Records written during the resync go under a new lease
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", "web1.example.com", "A", "100.64.0.1")
+
+	leases, err = client.Leases(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases.Leases) != 1 {
+		t.Fatalf("found %d leases after the resync, want 1", len(leases.Leases))
+	}
+}
@@ -0,0 +1,415 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ExecProtocolVersion is the version of the plugin protocol sent with the
+// capabilities request
+const ExecProtocolVersion = 1
+
+// execMaxLine bounds a single protocol message, which for list holds every
+// record in the zone
+const execMaxLine = 64 * 1024 * 1024
+
+// ExecConfig holds the settings needed to create an ExecProvider
+type ExecConfig struct {
+	Command string
+	Args    []string
+	// Added to dnsscale's own environment, as KEY=value
+	Env []string
+	Dir string
+
+	// How long the plugin has to answer a request, defaults to 30s
+	Timeout time.Duration
+
+	Logger *zap.Logger
+}
+
+// ExecProvider implements DNSProvider by delegating to an external plugin
+// process. The plugin reads one JSON request per line on stdin and writes one
+// JSON response per line on stdout, so a backend can be written in any
+// language. Anything the plugin writes to stderr is logged.
+//
+// Requests are sent one at a time. The plugin is started when the provider is
+// created and restarted on the next request if it exits. A plugin that doesn't
+// answer in time is killed, since its responses can no longer be matched to
+// requests.
+type ExecProvider struct {
+	command string
+	args    []string
+	env     []string
+	dir     string
+	timeout time.Duration
+	logger  *zap.Logger
+
+	mu     sync.Mutex
+	proc   *execProcess
+	nextID uint64
+	// Record types the plugin accepts, nil if it didn't report any
+	recordTypes map[string]bool
+}
+
+// ExecRecord is a record as sent to and received from the plugin. Names are
+// fully qualified without a trailing dot and TXT values are unquoted.
+type ExecRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+	TTL      int64  `json:"ttl"`
+	Priority uint16 `json:"priority,omitempty"`
+	Weight   uint16 `json:"weight,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+}
+
+// ExecRequest is a single request written to the plugin's stdin
+type ExecRequest struct {
+	ID       uint64      `json:"id"`
+	Method   string      `json:"method"` // capabilities, list, create, update or delete
+	Protocol int         `json:"protocol,omitempty"`
+	Zone     string      `json:"zone,omitempty"`
+	Record   *ExecRecord `json:"record,omitempty"`
+}
+
+// ExecResponse is a single response read from the plugin's stdout. A
+// non-empty Error fails the request.
+type ExecResponse struct {
+	ID          uint64       `json:"id"`
+	Error       string       `json:"error,omitempty"`
+	Records     []ExecRecord `json:"records,omitempty"`
+	RecordTypes []string     `json:"record_types,omitempty"`
+}
+
+// execProcess is a running plugin
+type execProcess struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan []byte   // Lines read from stdout, closed at EOF
+	quit  chan struct{} // Closed when the process is abandoned
+	done  chan struct{} // Closed once the process has exited
+}
+
+func NewExecProvider(cfg ExecConfig) (*ExecProvider, error) {
+	if cfg.Command == "" {
+		return nil, fmt.Errorf("command is required")
+	}
+
+	provider := &ExecProvider{
+		command: cfg.Command,
+		args:    cfg.Args,
+		env:     cfg.Env,
+		dir:     cfg.Dir,
+		timeout: cfg.Timeout,
+		logger:  cfg.Logger,
+	}
+	if provider.timeout <= 0 {
+		provider.timeout = 30 * time.Second
+	}
+	if provider.logger == nil {
+		provider.logger = zap.NewNop()
+	}
+
+	// Start the plugin right away so a broken command fails startup
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if err := provider.start(context.Background()); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// start launches the plugin and asks for its capabilities. Callers must hold
+// the lock.
+func (e *ExecProvider) start(ctx context.Context) error {
+	cmd := exec.Command(e.command, e.args...)
+	cmd.Env = append(os.Environ(), e.env...)
+	cmd.Dir = e.dir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create plugin stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", e.command, err)
+	}
+
+	proc := &execProcess{
+		cmd:   cmd,
+		stdin: stdin,
+		lines: make(chan []byte),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	logger := e.logger.With(zap.String("plugin", e.command), zap.Int("pid", cmd.Process.Pid))
+	logger.Info("Started DNS provider plugin")
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		defer close(proc.lines)
+
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), execMaxLine)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case proc.lines <- line:
+			case <-proc.quit:
+				io.Copy(io.Discard, stdout)
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			logger.Warn("Failed to read plugin output", zap.Error(err))
+			io.Copy(io.Discard, stdout)
+		}
+	}()
+	go func() {
+		defer readers.Done()
+
+		scanner := bufio.NewScanner(stderr)
+		scanner.Buffer(make([]byte, 4*1024), execMaxLine)
+		for scanner.Scan() {
+			logger.Info("Plugin stderr", zap.String("line", scanner.Text()))
+		}
+		// Keep draining so the plugin never blocks writing to stderr
+		if err := scanner.Err(); err != nil {
+			logger.Warn("Failed to read plugin stderr", zap.Error(err))
+		}
+		io.Copy(io.Discard, stderr)
+	}()
+	go func() {
+		// Wait closes the pipes, so the readers have to finish first
+		readers.Wait()
+		err := cmd.Wait()
+		close(proc.done)
+
+		select {
+		case <-proc.quit:
+			logger.Debug("DNS provider plugin stopped", zap.Error(err))
+		default:
+			logger.Warn("DNS provider plugin exited, it will be restarted on the next request", zap.Error(err))
+		}
+	}()
+
+	e.proc = proc
+
+	// Plugins that don't implement capabilities are assumed to accept every
+	// record type
+	resp, err := e.roundTrip(ctx, ExecRequest{Method: "capabilities", Protocol: ExecProtocolVersion})
+	var pluginErr *execPluginError
+	switch {
+	case errors.As(err, &pluginErr):
+		logger.Debug("Plugin didn't report capabilities", zap.Error(err))
+		e.recordTypes = nil
+	case err != nil:
+		return fmt.Errorf("plugin failed to start: %w", err)
+	default:
+		e.recordTypes = nil
+		if len(resp.RecordTypes) > 0 {
+			e.recordTypes = map[string]bool{}
+			for _, recordType := range resp.RecordTypes {
+				e.recordTypes[strings.ToUpper(recordType)] = true
+			}
+		}
+	}
+	return nil
+}
+
+// stop kills the plugin. Callers must hold the lock.
+func (e *ExecProvider) stop() {
+	if e.proc == nil {
+		return
+	}
+	close(e.proc.quit)
+	e.proc.stdin.Close()
+	e.proc.cmd.Process.Kill()
+	e.proc = nil
+}
+
+// execPluginError is an error reported by the plugin in a response, as opposed
+// to a failure to talk to it
+type execPluginError struct {
+	method  string
+	message string
+}
+
+func (e *execPluginError) Error() string {
+	return fmt.Sprintf("plugin error: %s (method: %s)", e.message, e.method)
+}
+
+// roundTrip sends req to the running plugin and waits for its response,
+// killing the plugin if it doesn't answer in time or breaks the protocol.
+// Callers must hold the lock.
+func (e *ExecProvider) roundTrip(ctx context.Context, req ExecRequest) (*ExecResponse, error) {
+	proc := e.proc
+
+	e.nextID++
+	req.ID = e.nextID
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	// A plugin that stops reading stdin would block the write forever, so
+	// it's bounded by the timeout too. Killing the plugin unblocks it.
+	written := make(chan error, 1)
+	go func() {
+		_, err := proc.stdin.Write(append(data, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		if err != nil {
+			e.stop()
+			return nil, fmt.Errorf("failed to write to plugin: %w", err)
+		}
+	case <-ctx.Done():
+		e.stop()
+		return nil, fmt.Errorf("plugin didn't read %s request: %w", req.Method, ctx.Err())
+	}
+
+	select {
+	case line, ok := <-proc.lines:
+		if !ok {
+			e.stop()
+			return nil, fmt.Errorf("plugin exited before answering %s request", req.Method)
+		}
+
+		var resp ExecResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			e.stop()
+			return nil, fmt.Errorf("invalid response from plugin: %w", err)
+		}
+		if resp.ID != req.ID {
+			e.stop()
+			return nil, fmt.Errorf("plugin answered request %d, expected %d", resp.ID, req.ID)
+		}
+		if resp.Error != "" {
+			return nil, &execPluginError{method: req.Method, message: resp.Error}
+		}
+		return &resp, nil
+	case <-ctx.Done():
+		e.stop()
+		return nil, fmt.Errorf("plugin didn't answer %s request: %w", req.Method, ctx.Err())
+	}
+}
+
+// call sends a request, restarting the plugin first if it has exited
+func (e *ExecProvider) call(ctx context.Context, req ExecRequest) (*ExecResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.proc != nil {
+		select {
+		case <-e.proc.done:
+			e.stop()
+		default:
+		}
+	}
+	if e.proc == nil {
+		e.logger.Info("Restarting DNS provider plugin", zap.String("plugin", e.command))
+		if err := e.start(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Record != nil && e.recordTypes != nil && !e.recordTypes[req.Record.Type] {
+		return nil, fmt.Errorf("unsupported record type for exec plugin: %s", req.Record.Type)
+	}
+
+	return e.roundTrip(ctx, req)
+}
+
+// toExecRecord converts a record to the plugin's format
+func toExecRecord(record DNSRecord) *ExecRecord {
+	value := record.Value
+	switch record.Type {
+	case "TXT":
+		value = strings.Trim(value, "\"")
+	case "SRV", "CNAME":
+		value = strings.TrimSuffix(value, ".")
+	}
+
+	return &ExecRecord{
+		Name:     strings.TrimSuffix(record.Name, "."),
+		Type:     strings.ToUpper(record.Type),
+		Value:    value,
+		TTL:      record.TTL,
+		Priority: record.Priority,
+		Weight:   record.Weight,
+		Port:     record.Port,
+	}
+}
+
+// fromExecRecord converts a record returned by the plugin to our internal
+// format
+func fromExecRecord(record ExecRecord) DNSRecord {
+	dnsRecord := DNSRecord{
+		Name:     strings.TrimSuffix(record.Name, "."),
+		Type:     strings.ToUpper(record.Type),
+		Value:    record.Value,
+		TTL:      record.TTL,
+		Priority: record.Priority,
+		Weight:   record.Weight,
+		Port:     record.Port,
+	}
+	if dnsRecord.Type == "TXT" {
+		dnsRecord.Value = "\"" + strings.Trim(record.Value, "\"") + "\""
+	}
+	return dnsRecord
+}
+
+func (e *ExecProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	resp, err := e.call(ctx, ExecRequest{Method: "list", Zone: zone})
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]DNSRecord, 0, len(resp.Records))
+	for _, record := range resp.Records {
+		records = append(records, fromExecRecord(record))
+	}
+	return records, nil
+}
+
+func (e *ExecProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	_, err := e.call(ctx, ExecRequest{Method: "create", Zone: zone, Record: toExecRecord(record)})
+	return err
+}
+
+func (e *ExecProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	_, err := e.call(ctx, ExecRequest{Method: "update", Zone: zone, Record: toExecRecord(record)})
+	return err
+}
+
+func (e *ExecProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	_, err := e.call(ctx, ExecRequest{Method: "delete", Zone: zone, Record: toExecRecord(record)})
+	return err
+}
@@ -0,0 +1,254 @@
+package providers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestExecHelperPlugin isn't a real test. The exec tests run the test binary
+// again with DNSSCALE_TEST_PLUGIN set, which turns it into a plugin keeping
+// records in memory. Requests for a few special names misbehave on purpose.
+func TestExecHelperPlugin(t *testing.T) {
+	if os.Getenv("DNSSCALE_TEST_PLUGIN") == "" {
+		return
+	}
+
+	var records []ExecRecord
+	out := json.NewEncoder(os.Stdout)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var req ExecRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Fprintln(os.Stderr, "bad request:", err)
+			os.Exit(2)
+		}
+		resp := ExecResponse{ID: req.ID}
+
+		name := ""
+		if req.Record != nil {
+			name = req.Record.Name
+		}
+		switch name {
+		case "crash.example.com":
+			os.Exit(3)
+		case "hang.example.com":
+			time.Sleep(time.Hour)
+		case "badid.example.com":
+			resp.ID += 100
+		}
+
+		switch req.Method {
+		case "capabilities":
+			// A line longer than bufio.Scanner's default limit must not stop
+			// stderr from being read
+			fmt.Fprintln(os.Stderr, strings.Repeat("x", 128*1024))
+			fmt.Fprintln(os.Stderr, "plugin ready")
+
+			switch caps := os.Getenv("DNSSCALE_TEST_PLUGIN_CAPS"); caps {
+			case "error":
+				resp.Error = "unknown method capabilities"
+			case "":
+			default:
+				resp.RecordTypes = strings.Split(caps, ",")
+			}
+		case "list":
+			resp.Records = records
+		case "create":
+			exists := false
+			for _, record := range records {
+				if record.Name == req.Record.Name && record.Type == req.Record.Type && record.Value == req.Record.Value {
+					exists = true
+				}
+			}
+			if !exists {
+				records = append(records, *req.Record)
+			}
+		case "update", "delete":
+			var kept []ExecRecord
+			for _, record := range records {
+				if record.Name != req.Record.Name || record.Type != req.Record.Type {
+					kept = append(kept, record)
+					continue
+				}
+				if req.Method == "delete" && record.Value != req.Record.Value {
+					kept = append(kept, record)
+				}
+			}
+			if req.Method == "update" {
+				kept = append(kept, *req.Record)
+			}
+			records = kept
+		default:
+			resp.Error = "unknown method " + req.Method
+		}
+
+		if err := out.Encode(resp); err != nil {
+			os.Exit(2)
+		}
+
+		if name == "deaf.example.com" {
+			// Answer, then stop reading requests
+			time.Sleep(time.Hour)
+		}
+	}
+	os.Exit(0)
+}
+
+// newTestExecProvider starts the helper plugin, reporting caps as its record
+// types, or failing the capabilities request if caps is "error"
+func newTestExecProvider(t *testing.T, caps string, timeout time.Duration, logger *zap.Logger) *ExecProvider {
+	t.Helper()
+
+	provider, err := NewExecProvider(ExecConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestExecHelperPlugin$"},
+		Env:     []string{"DNSSCALE_TEST_PLUGIN=1", "DNSSCALE_TEST_PLUGIN_CAPS=" + caps},
+		Timeout: timeout,
+		Logger:  logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		provider.mu.Lock()
+		defer provider.mu.Unlock()
+		provider.stop()
+	})
+	return provider
+}
+
+// runningPlugin returns the plugin process currently serving requests
+func runningPlugin(t *testing.T, provider *ExecProvider) *execProcess {
+	t.Helper()
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.proc == nil {
+		t.Fatal("no plugin is running")
+	}
+	return provider.proc
+}
+
+// expectKilled waits for proc to exit
+func expectKilled(t *testing.T, proc *execProcess) {
+	t.Helper()
+
+	select {
+	case <-proc.done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("plugin wasn't killed")
+	}
+}
+
+func TestExecProvider(t *testing.T) {
+	provider := newTestExecProvider(t, "A,AAAA,TXT", 10*time.Second, nil)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestExecCapabilities(t *testing.T) {
+	ctx := context.Background()
+	srv := DNSRecord{Name: "_http._tcp.example.com", Type: "SRV", Value: "web1.example.com", TTL: 300, Priority: 10, Weight: 10, Port: 80}
+
+	provider := newTestExecProvider(t, "A,TXT", 10*time.Second, nil)
+	if err := provider.CreateRecord(ctx, "example.com", srv); err == nil || !strings.Contains(err.Error(), "unsupported record type") {
+		t.Fatalf("CreateRecord of a type the plugin doesn't accept = %v, want unsupported record type", err)
+	}
+
+	// A plugin failing capabilities is assumed to accept every type
+	provider = newTestExecProvider(t, "error", 10*time.Second, nil)
+	if err := provider.CreateRecord(ctx, "example.com", srv); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", srv.Name, "SRV", srv.SRVValue())
+}
+
+func TestExecIDMismatchKillsPlugin(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestExecProvider(t, "", 10*time.Second, nil)
+
+	proc := runningPlugin(t, provider)
+	err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "badid.example.com", Type: "A", Value: "100.64.0.1", TTL: 300})
+	if err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("CreateRecord with a mismatched response ID = %v, want an error", err)
+	}
+	expectKilled(t, proc)
+
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecTimeoutKillsPlugin(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestExecProvider(t, "", time.Second, nil)
+
+	proc := runningPlugin(t, provider)
+	err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "hang.example.com", Type: "A", Value: "100.64.0.1", TTL: 300})
+	if err == nil || !strings.Contains(err.Error(), "didn't answer") {
+		t.Fatalf("CreateRecord on a hung plugin = %v, want a timeout", err)
+	}
+	expectKilled(t, proc)
+
+	// A plugin that stops reading stdin can't block a request either, even
+	// one larger than the pipe buffer
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "deaf.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	proc = runningPlugin(t, provider)
+	large := DNSRecord{Name: "web1.example.com", Type: "TXT", Value: strings.Repeat("x", 1024*1024), TTL: 300}
+	err = provider.CreateRecord(ctx, "example.com", large)
+	if err == nil || !strings.Contains(err.Error(), "didn't read") {
+		t.Fatalf("CreateRecord on a plugin not reading stdin = %v, want a timeout", err)
+	}
+	expectKilled(t, proc)
+
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecRestartsAfterCrash(t *testing.T) {
+	ctx := context.Background()
+	provider := newTestExecProvider(t, "", 10*time.Second, nil)
+
+	proc := runningPlugin(t, provider)
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "crash.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err == nil {
+		t.Fatal("CreateRecord succeeded on a plugin that exited")
+	}
+	expectKilled(t, proc)
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if runningPlugin(t, provider) == proc {
+		t.Fatal("plugin wasn't restarted")
+	}
+	expectValues(t, provider, "example.com", record.Name, "A", record.Value)
+}
+
+func TestExecLogsStderr(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	newTestExecProvider(t, "", 10*time.Second, zap.New(core))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, entry := range logs.FilterMessage("Plugin stderr").All() {
+			if entry.ContextMap()["line"] == "plugin ready" {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("plugin stderr wasn't logged after a long line")
+}
@@ -0,0 +1,285 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// gandiPageSize is the number of rrsets requested per page
+const gandiPageSize = 500
+
+// gandiMinTTL is the lowest TTL LiveDNS accepts
+const gandiMinTTL = 300
+
+// errGandiNotFound is returned when LiveDNS reports that an rrset doesn't exist
+var errGandiNotFound = errors.New("gandi rrset not found")
+
+// GandiLiveDNSProvider implements DNSProvider for Gandi LiveDNS
+type GandiLiveDNSProvider struct {
+	apiToken   string
+	domain     string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// GandiRRSet represents a resource record set in LiveDNS's API
+type GandiRRSet struct {
+	Name   string   `json:"rrset_name,omitempty"`
+	Type   string   `json:"rrset_type,omitempty"`
+	TTL    int64    `json:"rrset_ttl,omitempty"`
+	Values []string `json:"rrset_values"`
+}
+
+// GandiErrorResponse represents an error returned by LiveDNS's API
+type GandiErrorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Cause   string `json:"cause"`
+}
+
+// NewGandiLiveDNSProvider creates a provider for a LiveDNS domain using a
+// personal access token. endpoint overrides the API base URL and may be left
+// empty.
+func NewGandiLiveDNSProvider(apiToken, domain, endpoint string) (*GandiLiveDNSProvider, error) {
+	if apiToken == "" || domain == "" {
+		return nil, fmt.Errorf("personal access token and domain are required")
+	}
+
+	if endpoint == "" {
+		endpoint = "https://api.gandi.net/v5/livedns"
+	}
+
+	return &GandiLiveDNSProvider{
+		apiToken:   apiToken,
+		domain:     strings.TrimSuffix(domain, "."),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(endpoint, "/"),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the domain's records in the LiveDNS
+// API and decodes the response into out when it is non-nil
+func (g *GandiLiveDNSProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := fmt.Sprintf("%s/domains/%s/records%s", g.baseURL, url.PathEscape(g.domain), endpoint)
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+g.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errGandiNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp GandiErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Message != "" {
+			return fmt.Errorf("gandi API error: %s (code: %d)", errResp.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("gandi API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// rrsetEndpoint returns the API path of the rrset for the record's name and
+// type
+func (g *GandiLiveDNSProvider) rrsetEndpoint(record DNSRecord) (string, error) {
+	name, err := relativeName(record.Name, g.domain)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("/%s/%s", url.PathEscape(name), record.Type), nil
+}
+
+// getRRSet returns the rrset for the record's name and type, or nil if it
+// doesn't exist
+func (g *GandiLiveDNSProvider) getRRSet(ctx context.Context, record DNSRecord) (*GandiRRSet, error) {
+	endpoint, err := g.rrsetEndpoint(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var rrset GandiRRSet
+	err = g.makeRequest(ctx, "GET", endpoint, nil, &rrset)
+	if errors.Is(err, errGandiNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rrset, nil
+}
+
+// putRRSet replaces the values of the rrset for the record's name and type,
+// creating it if needed, or deletes it when values is empty
+func (g *GandiLiveDNSProvider) putRRSet(ctx context.Context, record DNSRecord, ttl int64, values []string) error {
+	endpoint, err := g.rrsetEndpoint(record)
+	if err != nil {
+		return err
+	}
+
+	if len(values) == 0 {
+		err := g.makeRequest(ctx, "DELETE", endpoint, nil, nil)
+		if errors.Is(err, errGandiNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if ttl < gandiMinTTL {
+		ttl = gandiMinTTL
+	}
+	return g.makeRequest(ctx, "PUT", endpoint, GandiRRSet{TTL: ttl, Values: values}, nil)
+}
+
+// gandiValueMatches reports whether an rrset value holds record's value
+func (g *GandiLiveDNSProvider) gandiValueMatches(value string, record DNSRecord) bool {
+	existing, ok := g.fromGandiValue(record.Name, record.Type, record.TTL, value)
+	if !ok {
+		return false
+	}
+	return sameValue(existing, record)
+}
+
+// fromGandiValue converts a single rrset value to our internal format,
+// returning false for types dnsscale doesn't manage
+func (g *GandiLiveDNSProvider) fromGandiValue(name, recordType string, ttl int64, value string) (DNSRecord, bool) {
+	record, ok := fromRecordData(name, recordType, ttl, value)
+	if !ok {
+		return DNSRecord{}, false
+	}
+
+	switch recordType {
+	case "TXT":
+		record.Value = "\"" + strings.Trim(record.Value, "\"") + "\""
+	case "SRV":
+		// Targets without a trailing dot are relative to the domain
+		if record.Value == "@" {
+			record.Value = g.domain
+		} else if !strings.HasSuffix(record.Value, ".") {
+			record.Value = absoluteName(record.Value, g.domain)
+		}
+		record.Value = strings.TrimSuffix(record.Value, ".")
+	}
+	return record, true
+}
+
+func (g *GandiLiveDNSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	var records []DNSRecord
+
+	for page := 1; ; page++ {
+		var rrsets []GandiRRSet
+		endpoint := fmt.Sprintf("?page=%d&per_page=%d", page, gandiPageSize)
+		if err := g.makeRequest(ctx, "GET", endpoint, nil, &rrsets); err != nil {
+			return nil, err
+		}
+
+		for _, rrset := range rrsets {
+			name := absoluteName(rrset.Name, g.domain)
+			for _, value := range rrset.Values {
+				if record, ok := g.fromGandiValue(name, rrset.Type, rrset.TTL, value); ok {
+					records = append(records, record)
+				}
+			}
+		}
+
+		if len(rrsets) < gandiPageSize {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+func (g *GandiLiveDNSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	value := recordData(record)
+	if existing == nil {
+		return g.putRRSet(ctx, record, record.TTL, []string{value})
+	}
+
+	// LiveDNS holds every value for a name and type in one rrset, so an
+	// additional value is merged into the existing set
+	for _, existingValue := range existing.Values {
+		if g.gandiValueMatches(existingValue, record) {
+			return nil
+		}
+	}
+	values := append(append([]string{}, existing.Values...), value)
+	return g.putRRSet(ctx, record, existing.TTL, values)
+}
+
+func (g *GandiLiveDNSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	// LiveDNS raises low TTLs to its minimum, so compare against that
+	if existing != nil && len(existing.Values) == 1 && g.gandiValueMatches(existing.Values[0], record) &&
+		existing.TTL == max(record.TTL, gandiMinTTL) {
+		return nil
+	}
+
+	return g.putRRSet(ctx, record, record.TTL, []string{recordData(record)})
+}
+
+func (g *GandiLiveDNSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other values in the set and only drop this one
+	var remaining []string
+	for _, value := range existing.Values {
+		if !g.gandiValueMatches(value, record) {
+			remaining = append(remaining, value)
+		}
+	}
+
+	if len(remaining) == len(existing.Values) {
+		return nil
+	}
+	return g.putRRSet(ctx, record, existing.TTL, remaining)
+}
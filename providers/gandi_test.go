@@ -0,0 +1,164 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeLiveDNS is a minimal stand-in for the Gandi LiveDNS API serving the
+// rrsets of one domain. Like the real API it rejects TTLs below 300.
+type fakeLiveDNS struct {
+	mu     sync.Mutex
+	rrsets map[string]GandiRRSet // Keyed by name/type
+	writes int
+}
+
+func newFakeLiveDNS(t *testing.T, domain string) (*fakeLiveDNS, *GandiLiveDNSProvider) {
+	t.Helper()
+
+	fake := &fakeLiveDNS{rrsets: map[string]GandiRRSet{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewGandiLiveDNSProvider("token", domain, server.URL+"/v5/livedns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, provider
+}
+
+func (f *fakeLiveDNS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(GandiErrorResponse{Code: status, Message: message})
+	}
+
+	if req.Header.Get("Authorization") != "Bearer token" {
+		fail(http.StatusForbidden, "Access was denied to this resource.")
+		return
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, "/v5/livedns/domains/example.com/records")
+	if !ok {
+		fail(http.StatusNotFound, "The resource could not be found.")
+		return
+	}
+
+	if path == "" && req.Method == "GET" {
+		keys := make([]string, 0, len(f.rrsets))
+		for key := range f.rrsets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		rrsets := []GandiRRSet{}
+		for _, key := range keys {
+			rrsets = append(rrsets, f.rrsets[key])
+		}
+		json.NewEncoder(w).Encode(rrsets)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 {
+		fail(http.StatusNotFound, "The resource could not be found.")
+		return
+	}
+	key := parts[0] + "/" + parts[1]
+
+	switch req.Method {
+	case "GET":
+		rrset, ok := f.rrsets[key]
+		if !ok {
+			fail(http.StatusNotFound, "The resource could not be found.")
+			return
+		}
+		json.NewEncoder(w).Encode(rrset)
+	case "PUT":
+		var rrset GandiRRSet
+		if err := json.NewDecoder(req.Body).Decode(&rrset); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if rrset.TTL < 300 {
+			fail(http.StatusBadRequest, "rrset_ttl must be at least 300")
+			return
+		}
+		rrset.Name, rrset.Type = parts[0], parts[1]
+		f.rrsets[key] = rrset
+		f.writes++
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": "DNS Record Created"})
+	case "DELETE":
+		if _, ok := f.rrsets[key]; !ok {
+			fail(http.StatusNotFound, "The resource could not be found.")
+			return
+		}
+		delete(f.rrsets, key)
+		f.writes++
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fail(http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// rrset returns the rrset stored at name and type, and the number of writes
+func (f *fakeLiveDNS) rrset(name, recordType string) (GandiRRSet, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rrsets[name+"/"+recordType], f.writes
+}
+
+func TestGandiLiveDNSProvider(t *testing.T) {
+	_, provider := newFakeLiveDNS(t, "example.com")
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestGandiLiveDNSParentDomain(t *testing.T) {
+	// Records for ts.example.com are kept in the example.com domain
+	fake, provider := newFakeLiveDNS(t, "example.com")
+	testProviderSemantics(t, provider, "ts.example.com")
+
+	if err := provider.CreateRecord(context.Background(), "ts.example.com", DNSRecord{Name: "web1.ts.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if rrset, _ := fake.rrset("web1.ts", "A"); len(rrset.Values) != 1 {
+		t.Fatalf("record wasn't stored relative to the domain: %+v", rrset)
+	}
+}
+
+func TestGandiLiveDNSRaisesTTL(t *testing.T) {
+	ctx := context.Background()
+	fake, provider := newFakeLiveDNS(t, "example.com")
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 60}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	rrset, writes := fake.rrset("web1", "A")
+	if rrset.TTL != gandiMinTTL {
+		t.Fatalf("TTL = %d, want it raised to %d", rrset.TTL, gandiMinTTL)
+	}
+
+	// The raised TTL counts as unchanged, so rewriting the record is a no-op
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if _, got := fake.rrset("web1", "A"); got != writes {
+		t.Fatalf("updating to the current value made %d writes, want none", got-writes)
+	}
+
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: "web2.example.com", Type: "A", Value: "100.64.0.2", TTL: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if rrset, _ := fake.rrset("web2", "A"); rrset.TTL != gandiMinTTL {
+		t.Fatalf("created TTL = %d, want it raised to %d", rrset.TTL, gandiMinTTL)
+	}
+}
@@ -0,0 +1,306 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// googleCloudDNSScope is the OAuth scope needed to manage Cloud DNS records
+const googleCloudDNSScope = "https://www.googleapis.com/auth/ndev.clouddns.readwrite"
+
+// GoogleCloudDNSConfig holds the settings needed to create a
+// GoogleCloudDNSProvider
+type GoogleCloudDNSConfig struct {
+	Project     string
+	ManagedZone string
+
+	// Service account JSON key, application default credentials are used if
+	// empty
+	CredentialsFile string
+
+	// Endpoint overrides the API base URL, e.g. for a local fake server
+	Endpoint string
+
+	// HTTPClient is used as is instead of a client authenticating with
+	// Google credentials, e.g. for a fake server that doesn't check them
+	HTTPClient *http.Client
+}
+
+// GoogleCloudDNSProvider implements DNSProvider for Google Cloud DNS
+type GoogleCloudDNSProvider struct {
+	project     string
+	managedZone string
+	httpClient  *http.Client
+	baseURL     string
+}
+
+// GoogleCloudDNSRecordSet represents a resource record set in the Cloud DNS API
+type GoogleCloudDNSRecordSet struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	TTL     int64    `json:"ttl"`
+	RRDatas []string `json:"rrdatas"`
+}
+
+// GoogleCloudDNSChange represents a change submitted to a managed zone
+type GoogleCloudDNSChange struct {
+	Additions []GoogleCloudDNSRecordSet `json:"additions,omitempty"`
+	Deletions []GoogleCloudDNSRecordSet `json:"deletions,omitempty"`
+}
+
+// GoogleCloudDNSRecordSetsResponse represents a page of record sets
+type GoogleCloudDNSRecordSetsResponse struct {
+	RRSets        []GoogleCloudDNSRecordSet `json:"rrsets"`
+	NextPageToken string                    `json:"nextPageToken,omitempty"`
+}
+
+// GoogleCloudDNSErrorResponse represents an error returned by the Cloud DNS API
+type GoogleCloudDNSErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewGoogleCloudDNSProvider creates a provider for a Cloud DNS managed zone
+func NewGoogleCloudDNSProvider(ctx context.Context, cfg GoogleCloudDNSConfig) (*GoogleCloudDNSProvider, error) {
+	if cfg.Project == "" || cfg.ManagedZone == "" {
+		return nil, fmt.Errorf("project and managed zone are required")
+	}
+
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://dns.googleapis.com/dns/v1"
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		creds, err := googleCredentials(ctx, cfg.CredentialsFile)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = oauth2.NewClient(ctx, creds.TokenSource)
+		httpClient.Timeout = 30 * time.Second
+	}
+
+	return &GoogleCloudDNSProvider{
+		project:     cfg.Project,
+		managedZone: cfg.ManagedZone,
+		httpClient:  httpClient,
+		baseURL:     strings.TrimSuffix(endpoint, "/"),
+	}, nil
+}
+
+// googleCredentials loads credentials from a service account JSON key, or
+// application default credentials if credentialsFile is empty
+func googleCredentials(ctx context.Context, credentialsFile string) (*google.Credentials, error) {
+	var creds *google.Credentials
+	var err error
+	if credentialsFile != "" {
+		data, readErr := os.ReadFile(credentialsFile)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read credentials file: %w", readErr)
+		}
+		creds, err = google.CredentialsFromJSON(ctx, data, googleCloudDNSScope)
+	} else {
+		creds, err = google.FindDefaultCredentials(ctx, googleCloudDNSScope)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load google credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// makeRequest makes an HTTP request to the Cloud DNS API and decodes the
+// response into out when it is non-nil
+func (g *GoogleCloudDNSProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := fmt.Sprintf("%s/projects/%s/managedZones/%s%s", g.baseURL, g.project, g.managedZone, endpoint)
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp GoogleCloudDNSErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error.Message != "" {
+			return fmt.Errorf("google cloud dns API error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+		}
+		return fmt.Errorf("google cloud dns API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g *GoogleCloudDNSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	pageToken := ""
+
+	for {
+		endpoint := "/rrsets"
+		if pageToken != "" {
+			endpoint += "?pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		var page GoogleCloudDNSRecordSetsResponse
+		if err := g.makeRequest(ctx, "GET", endpoint, nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, rrset := range page.RRSets {
+			for _, data := range rrset.RRDatas {
+				if record, ok := fromRecordData(rrset.Name, rrset.Type, rrset.TTL, data); ok {
+					records = append(records, record)
+				}
+			}
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	return records, nil
+}
+
+// getRecordSet returns the record set for the record's name and type, or nil
+// if it doesn't exist
+func (g *GoogleCloudDNSProvider) getRecordSet(ctx context.Context, record DNSRecord) (*GoogleCloudDNSRecordSet, error) {
+	endpoint := fmt.Sprintf("/rrsets?name=%s&type=%s", url.QueryEscape(fqdn(record.Name)), url.QueryEscape(record.Type))
+
+	var page GoogleCloudDNSRecordSetsResponse
+	if err := g.makeRequest(ctx, "GET", endpoint, nil, &page); err != nil {
+		return nil, err
+	}
+
+	for _, rrset := range page.RRSets {
+		if rrset.Type == record.Type && strings.EqualFold(rrset.Name, fqdn(record.Name)) {
+			return &rrset, nil
+		}
+	}
+	return nil, nil
+}
+
+// replaceRecordSet swaps existing for replacement in a single change. Either
+// may be nil.
+func (g *GoogleCloudDNSProvider) replaceRecordSet(ctx context.Context, existing, replacement *GoogleCloudDNSRecordSet) error {
+	var change GoogleCloudDNSChange
+	if existing != nil {
+		change.Deletions = append(change.Deletions, *existing)
+	}
+	if replacement != nil {
+		change.Additions = append(change.Additions, *replacement)
+	}
+	if len(change.Additions) == 0 && len(change.Deletions) == 0 {
+		return nil
+	}
+	return g.makeRequest(ctx, "POST", "/changes", change, nil)
+}
+
+func (g *GoogleCloudDNSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	data := recordData(record)
+	replacement := GoogleCloudDNSRecordSet{
+		Name:    fqdn(record.Name),
+		Type:    record.Type,
+		TTL:     record.TTL,
+		RRDatas: []string{data},
+	}
+
+	// Cloud DNS holds every value for a name and type in one record set, so
+	// an additional value is merged into the existing set
+	if existing != nil {
+		for _, rrdata := range existing.RRDatas {
+			if sameData(rrdata, record) {
+				return nil
+			}
+		}
+		replacement.TTL = existing.TTL
+		replacement.RRDatas = append(append([]string{}, existing.RRDatas...), data)
+	}
+
+	return g.replaceRecordSet(ctx, existing, &replacement)
+}
+
+func (g *GoogleCloudDNSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	return g.replaceRecordSet(ctx, existing, &GoogleCloudDNSRecordSet{
+		Name:    fqdn(record.Name),
+		Type:    record.Type,
+		TTL:     record.TTL,
+		RRDatas: []string{recordData(record)},
+	})
+}
+
+func (g *GoogleCloudDNSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := g.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other values in the set and only drop this one
+	var remaining []string
+	for _, rrdata := range existing.RRDatas {
+		if !sameData(rrdata, record) {
+			remaining = append(remaining, rrdata)
+		}
+	}
+
+	if len(remaining) == len(existing.RRDatas) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return g.replaceRecordSet(ctx, existing, nil)
+	}
+
+	replacement := *existing
+	replacement.RRDatas = remaining
+	return g.replaceRecordSet(ctx, existing, &replacement)
+}
@@ -0,0 +1,92 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCloudDNS is a minimal stand-in for the Cloud DNS API serving a single
+// managed zone. Changes are rejected unless their deletions match the current
+// record sets exactly, like the real API.
+type fakeCloudDNS struct {
+	mu     sync.Mutex
+	rrsets map[string]GoogleCloudDNSRecordSet // Keyed by name and type
+}
+
+func newFakeCloudDNS(t *testing.T) *httptest.Server {
+	fake := &fakeCloudDNS{rrsets: map[string]GoogleCloudDNSRecordSet{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (f *fakeCloudDNS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	base := "/projects/test-project/managedZones/test-zone"
+	switch {
+	case req.Method == "GET" && req.URL.Path == base+"/rrsets":
+		name, recordType := req.URL.Query().Get("name"), req.URL.Query().Get("type")
+		var resp GoogleCloudDNSRecordSetsResponse
+		for _, rrset := range f.rrsets {
+			if (name == "" || rrset.Name == name) && (recordType == "" || rrset.Type == recordType) {
+				resp.RRSets = append(resp.RRSets, rrset)
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	case req.Method == "POST" && req.URL.Path == base+"/changes":
+		var change GoogleCloudDNSChange
+		if err := json.NewDecoder(req.Body).Decode(&change); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, deletion := range change.Deletions {
+			if current, ok := f.rrsets[deletion.Name+deletion.Type]; !ok || !reflect.DeepEqual(current, deletion) {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": 412, "message": "conditionNotMet"}})
+				return
+			}
+		}
+		for _, deletion := range change.Deletions {
+			delete(f.rrsets, deletion.Name+deletion.Type)
+		}
+		for _, addition := range change.Additions {
+			if _, ok := f.rrsets[addition.Name+addition.Type]; ok {
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]interface{}{"code": 409, "message": "alreadyExists"}})
+				return
+			}
+			if !strings.HasSuffix(addition.Name, ".") {
+				http.Error(w, "name must be fully qualified", http.StatusBadRequest)
+				return
+			}
+			f.rrsets[addition.Name+addition.Type] = addition
+		}
+		json.NewEncoder(w).Encode(change)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestGoogleCloudDNSProvider(t *testing.T) {
+	server := newFakeCloudDNS(t)
+
+	provider, err := NewGoogleCloudDNSProvider(context.Background(), GoogleCloudDNSConfig{
+		Project:     "test-project",
+		ManagedZone: "test-zone",
+		Endpoint:    server.URL,
+		HTTPClient:  server.Client(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testProviderSemantics(t, provider, "example.com")
+}
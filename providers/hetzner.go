@@ -0,0 +1,348 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// hetznerPageSize is the number of records requested per page
+const hetznerPageSize = 100
+
+// HetznerDNSProvider implements DNSProvider for Hetzner DNS
+type HetznerDNSProvider struct {
+	apiToken   string
+	zoneID     string
+	zoneName   string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// HetznerRecord represents a record in Hetzner DNS's API
+type HetznerRecord struct {
+	ID     string `json:"id,omitempty"`
+	ZoneID string `json:"zone_id"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	TTL    int64  `json:"ttl,omitempty"`
+}
+
+// HetznerZone represents a zone in Hetzner DNS's API
+type HetznerZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// HetznerPagination holds the pagination metadata of a list response
+type HetznerPagination struct {
+	Page     int `json:"page"`
+	LastPage int `json:"last_page"`
+}
+
+// HetznerZonesResponse represents a page of zones
+type HetznerZonesResponse struct {
+	Zones []HetznerZone `json:"zones"`
+}
+
+// HetznerRecordsResponse represents a page of records
+type HetznerRecordsResponse struct {
+	Records []HetznerRecord `json:"records"`
+	Meta    struct {
+		Pagination HetznerPagination `json:"pagination"`
+	} `json:"meta"`
+}
+
+// HetznerBulkRequest represents the body of a bulk update request
+type HetznerBulkRequest struct {
+	Records []HetznerRecord `json:"records"`
+}
+
+// HetznerBulkResponse represents the result of a bulk request. Records the
+// API refused are reported rather than failing the whole request.
+type HetznerBulkResponse struct {
+	InvalidRecords []HetznerRecord `json:"invalid_records"`
+}
+
+// HetznerErrorResponse represents an error returned by Hetzner DNS's API
+type HetznerErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"error"`
+	Message string `json:"message"`
+}
+
+// NewHetznerDNSProvider creates a provider for a Hetzner DNS zone. When
+// zoneID is empty the zone is looked up by zoneName.
+func NewHetznerDNSProvider(ctx context.Context, apiToken, zoneID, zoneName string) (*HetznerDNSProvider, error) {
+	if apiToken == "" || zoneName == "" {
+		return nil, fmt.Errorf("API token and zone name are required")
+	}
+
+	provider := &HetznerDNSProvider{
+		apiToken:   apiToken,
+		zoneID:     zoneID,
+		zoneName:   strings.TrimSuffix(zoneName, "."),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    "https://dns.hetzner.com/api/v1",
+	}
+
+	if provider.zoneID == "" {
+		id, err := provider.lookupZoneID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		provider.zoneID = id
+	}
+
+	return provider, nil
+}
+
+// makeRequest makes an HTTP request to the Hetzner DNS API and decodes the
+// response into out when it is non-nil
+func (h *HetznerDNSProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := h.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Auth-API-Token", h.apiToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp HetznerErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil {
+			if errResp.Error.Message != "" {
+				return fmt.Errorf("hetzner API error: %s (code: %d)", errResp.Error.Message, errResp.Error.Code)
+			}
+			if errResp.Message != "" {
+				return fmt.Errorf("hetzner API error: %s (code: %d)", errResp.Message, resp.StatusCode)
+			}
+		}
+		return fmt.Errorf("hetzner API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// lookupZoneID finds the ID of the zone named zoneName
+func (h *HetznerDNSProvider) lookupZoneID(ctx context.Context) (string, error) {
+	var resp HetznerZonesResponse
+	if err := h.makeRequest(ctx, "GET", "/zones?name="+url.QueryEscape(h.zoneName), nil, &resp); err != nil {
+		return "", fmt.Errorf("failed to look up zone: %w", err)
+	}
+
+	for _, zone := range resp.Zones {
+		if strings.EqualFold(zone.Name, h.zoneName) {
+			return zone.ID, nil
+		}
+	}
+	return "", fmt.Errorf("hetzner zone %s not found", h.zoneName)
+}
+
+// listZoneRecords fetches every page of records in the zone
+func (h *HetznerDNSProvider) listZoneRecords(ctx context.Context) ([]HetznerRecord, error) {
+	var records []HetznerRecord
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("zone_id", h.zoneID)
+		query.Set("page", fmt.Sprint(page))
+		query.Set("per_page", fmt.Sprint(hetznerPageSize))
+
+		var resp HetznerRecordsResponse
+		if err := h.makeRequest(ctx, "GET", "/records?"+query.Encode(), nil, &resp); err != nil {
+			return nil, err
+		}
+		records = append(records, resp.Records...)
+
+		if page >= resp.Meta.Pagination.LastPage || len(resp.Records) == 0 {
+			break
+		}
+	}
+
+	return records, nil
+}
+
+// fromHetznerRecord converts a Hetzner record to our internal format,
+// returning false for types dnsscale doesn't manage
+func (h *HetznerDNSProvider) fromHetznerRecord(record HetznerRecord) (DNSRecord, bool) {
+	name := absoluteName(record.Name, h.zoneName)
+
+	switch record.Type {
+	case "TXT":
+		value := record.Value
+		if !strings.HasPrefix(value, "\"") {
+			value = "\"" + value + "\""
+		}
+		return DNSRecord{Name: name, Type: record.Type, Value: value, TTL: record.TTL}, true
+	case "SRV":
+		dnsRecord, ok := fromRecordData(name, record.Type, record.TTL, record.Value)
+		if !ok {
+			return DNSRecord{}, false
+		}
+		// Targets without a trailing dot are relative to the zone
+		if !strings.HasSuffix(dnsRecord.Value, ".") {
+			dnsRecord.Value = absoluteName(dnsRecord.Value, h.zoneName)
+		}
+		dnsRecord.Value = strings.TrimSuffix(dnsRecord.Value, ".")
+		return dnsRecord, true
+	}
+	return fromRecordData(name, record.Type, record.TTL, record.Value)
+}
+
+// toHetznerRecord converts a record to the body used to create or update it
+func (h *HetznerDNSProvider) toHetznerRecord(record DNSRecord) (HetznerRecord, error) {
+	name, err := relativeName(record.Name, h.zoneName)
+	if err != nil {
+		return HetznerRecord{}, err
+	}
+
+	return HetznerRecord{
+		ZoneID: h.zoneID,
+		Type:   record.Type,
+		Name:   name,
+		Value:  recordData(record),
+		TTL:    record.TTL,
+	}, nil
+}
+
+// findRecords returns the Hetzner records with the record's name and type
+func (h *HetznerDNSProvider) findRecords(ctx context.Context, record DNSRecord) ([]HetznerRecord, error) {
+	records, err := h.listZoneRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []HetznerRecord
+	for _, candidate := range records {
+		if candidate.Type == record.Type && strings.EqualFold(absoluteName(candidate.Name, h.zoneName), strings.TrimSuffix(record.Name, ".")) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches, nil
+}
+
+// bulkRequest sends records to a bulk endpoint, failing if any were refused
+func (h *HetznerDNSProvider) bulkRequest(ctx context.Context, method string, records []HetznerRecord) error {
+	var resp HetznerBulkResponse
+	if err := h.makeRequest(ctx, method, "/records/bulk", HetznerBulkRequest{Records: records}, &resp); err != nil {
+		return err
+	}
+	if len(resp.InvalidRecords) > 0 {
+		invalid := resp.InvalidRecords[0]
+		return fmt.Errorf("hetzner API rejected %d record(s), first: %s %s %s", len(resp.InvalidRecords), invalid.Type, invalid.Name, invalid.Value)
+	}
+	return nil
+}
+
+func (h *HetznerDNSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	records, err := h.listZoneRecords(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dnsRecords []DNSRecord
+	for _, record := range records {
+		if dnsRecord, ok := h.fromHetznerRecord(record); ok {
+			dnsRecords = append(dnsRecords, dnsRecord)
+		}
+	}
+
+	return dnsRecords, nil
+}
+
+func (h *HetznerDNSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	hRecord, err := h.toHetznerRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// The API refuses duplicate records, so skip values already present
+	existing, err := h.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+	if firstMatch(existing, record, h.fromHetznerRecord) >= 0 {
+		return nil
+	}
+
+	return h.makeRequest(ctx, "POST", "/records", hRecord, nil)
+}
+
+func (h *HetznerDNSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := h.findRecords(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	if len(existing) == 0 {
+		// Record doesn't exist, create it
+		return h.CreateRecord(ctx, zone, record)
+	}
+
+	hRecord, err := h.toHetznerRecord(record)
+	if err != nil {
+		return err
+	}
+
+	// Update the record already holding the value, or the first one, and
+	// remove the others so a single value is left
+	keep := max(firstMatch(existing, record, h.fromHetznerRecord), 0)
+	hRecord.ID = existing[keep].ID
+	if err := h.bulkRequest(ctx, "PUT", []HetznerRecord{hRecord}); err != nil {
+		return err
+	}
+	for i, extra := range existing {
+		if i == keep {
+			continue
+		}
+		if err := h.makeRequest(ctx, "DELETE", "/records/"+url.PathEscape(extra.ID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *HetznerDNSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := h.findRecords(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	i := firstMatch(existing, record, h.fromHetznerRecord)
+	if i < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+	return h.makeRequest(ctx, "DELETE", "/records/"+url.PathEscape(existing[i].ID), nil, nil)
+}
@@ -0,0 +1,171 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeHetzner is a minimal stand-in for the Hetzner DNS API serving one zone.
+// Like the real API it refuses duplicate records and pages record lists.
+type fakeHetzner struct {
+	mu      sync.Mutex
+	records []HetznerRecord
+	nextID  int
+}
+
+func newFakeHetzner(t *testing.T) (*fakeHetzner, *HetznerDNSProvider) {
+	t.Helper()
+
+	fake := &fakeHetzner{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewHetznerDNSProvider(context.Background(), "token", "zone1", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.baseURL = server.URL + "/api/v1"
+	return fake, provider
+}
+
+// duplicate reports whether another record has the same name, type and value
+// as record
+func (f *fakeHetzner) duplicate(record HetznerRecord) bool {
+	for _, existing := range f.records {
+		if existing.ID != record.ID && existing.Name == record.Name && existing.Type == record.Type && existing.Value == record.Value {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeHetzner) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		resp := HetznerErrorResponse{}
+		resp.Error.Message, resp.Error.Code = message, status
+		json.NewEncoder(w).Encode(resp)
+	}
+
+	if req.Header.Get("Auth-API-Token") != "token" {
+		fail(http.StatusUnauthorized, "invalid authentication credentials")
+		return
+	}
+
+	query := req.URL.Query()
+	switch path := strings.TrimPrefix(req.URL.Path, "/api/v1"); {
+	case path == "/zones" && req.Method == "GET":
+		resp := HetznerZonesResponse{Zones: []HetznerZone{}}
+		if query.Get("name") == "example.com" {
+			resp.Zones = append(resp.Zones, HetznerZone{ID: "zone1", Name: "example.com"})
+		}
+		json.NewEncoder(w).Encode(resp)
+	case path == "/records" && req.Method == "GET":
+		if query.Get("zone_id") != "zone1" {
+			fail(http.StatusNotFound, "zone not found")
+			return
+		}
+		page, _ := strconv.Atoi(query.Get("page"))
+		perPage, _ := strconv.Atoi(query.Get("per_page"))
+		start := min((page-1)*perPage, len(f.records))
+		end := min(start+perPage, len(f.records))
+
+		resp := HetznerRecordsResponse{Records: append([]HetznerRecord{}, f.records[start:end]...)}
+		resp.Meta.Pagination = HetznerPagination{Page: page, LastPage: max((len(f.records)+perPage-1)/perPage, 1)}
+		json.NewEncoder(w).Encode(resp)
+	case path == "/records" && req.Method == "POST":
+		var record HetznerRecord
+		if err := json.NewDecoder(req.Body).Decode(&record); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if f.duplicate(record) {
+			fail(http.StatusUnprocessableEntity, "record already exists")
+			return
+		}
+		f.nextID++
+		record.ID = fmt.Sprintf("rec%d", f.nextID)
+		f.records = append(f.records, record)
+		json.NewEncoder(w).Encode(map[string]HetznerRecord{"record": record})
+	case path == "/records/bulk" && req.Method == "PUT":
+		var bulk HetznerBulkRequest
+		if err := json.NewDecoder(req.Body).Decode(&bulk); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		resp := HetznerBulkResponse{InvalidRecords: []HetznerRecord{}}
+		for _, updated := range bulk.Records {
+			i := f.index(updated.ID)
+			if i < 0 || f.duplicate(updated) {
+				resp.InvalidRecords = append(resp.InvalidRecords, updated)
+				continue
+			}
+			f.records[i] = updated
+		}
+		json.NewEncoder(w).Encode(resp)
+	case strings.HasPrefix(path, "/records/") && req.Method == "DELETE":
+		i := f.index(strings.TrimPrefix(path, "/records/"))
+		if i < 0 {
+			fail(http.StatusNotFound, "record not found")
+			return
+		}
+		f.records = append(f.records[:i], f.records[i+1:]...)
+	default:
+		fail(http.StatusNotFound, "not found")
+	}
+}
+
+// index returns the position of the record with the given ID
+func (f *fakeHetzner) index(id string) int {
+	for i, record := range f.records {
+		if record.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestHetznerDNSProvider(t *testing.T) {
+	_, provider := newFakeHetzner(t)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestHetznerDNSZoneLookup(t *testing.T) {
+	_, provider := newFakeHetzner(t)
+
+	provider.zoneID = ""
+	id, err := provider.lookupZoneID(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "zone1" {
+		t.Fatalf("zone ID = %q, want zone1", id)
+	}
+}
+
+func TestHetznerDNSListsEveryPage(t *testing.T) {
+	fake, provider := newFakeHetzner(t)
+
+	// More records than fit on one page
+	for i := range hetznerPageSize + 5 {
+		fake.records = append(fake.records, HetznerRecord{ID: fmt.Sprintf("seed%d", i), ZoneID: "zone1", Type: "A", Name: fmt.Sprintf("node%d", i), Value: "100.64.0.1", TTL: 300})
+	}
+
+	records, err := provider.ListRecords(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != hetznerPageSize+5 {
+		t.Fatalf("listed %d records, want %d", len(records), hetznerPageSize+5)
+	}
+}
@@ -0,0 +1,390 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// hostsFileSignals maps configured signal names to signals
+var hostsFileSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"TERM": syscall.SIGTERM,
+}
+
+// HostsFileConfig holds the settings needed to create a HostsFileProvider
+type HostsFileConfig struct {
+	Path string
+	// Name of the managed block, written as "# BEGIN <name>" and "# END <name>"
+	Marker string
+
+	// Process to signal after each change, e.g. dnsmasq's pidfile
+	PIDFile string
+	Signal  string // HUP, INT or TERM, defaults to HUP
+}
+
+// HostsFileProvider implements DNSProvider by maintaining a block of
+// /etc/hosts style lines, as read by dnsmasq's addn-hosts and the CoreDNS
+// hosts plugin. Lines outside the block are left alone.
+//
+// Hosts files only hold addresses, so TXT records, which dnsscale uses to
+// track ownership, are kept as comments inside the block.
+type HostsFileProvider struct {
+	path    string
+	marker  string
+	pidFile string
+	signal  syscall.Signal
+
+	mu sync.Mutex
+}
+
+// hostsFile is a parsed hosts file split around the managed block
+type hostsFile struct {
+	before   []string
+	records  []DNSRecord
+	after    []string
+	hasBlock bool   // Whether the markers were found
+	indent   string // Leading whitespace of the begin line, kept for the block
+}
+
+func NewHostsFileProvider(cfg HostsFileConfig) (*HostsFileProvider, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	marker := cfg.Marker
+	if marker == "" {
+		marker = "dnsscale"
+	}
+
+	signalName := strings.TrimPrefix(strings.ToUpper(cfg.Signal), "SIG")
+	if signalName == "" {
+		signalName = "HUP"
+	}
+	signal, ok := hostsFileSignals[signalName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signal: %s (supported: HUP, INT, TERM)", cfg.Signal)
+	}
+
+	return &HostsFileProvider{
+		path:    cfg.Path,
+		marker:  marker,
+		pidFile: cfg.PIDFile,
+		signal:  signal,
+	}, nil
+}
+
+func hostsBeginLine(marker string) string { return "# BEGIN " + marker }
+func hostsEndLine(marker string) string   { return "# END " + marker }
+
+// parseBlockLine parses a line of the managed block into records
+func parseBlockLine(line string) []DNSRecord {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	// Ownership comments: # TXT <name> <value>
+	if rest, ok := strings.CutPrefix(line, "# TXT "); ok {
+		name, value, ok := strings.Cut(rest, " ")
+		if !ok {
+			return nil
+		}
+		return []DNSRecord{{Name: strings.ToLower(name), Type: "TXT", Value: strings.TrimSpace(value)}}
+	}
+	if strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return nil
+	}
+	addr, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return nil
+	}
+
+	recordType := "A"
+	if addr.Is6() {
+		recordType = "AAAA"
+	}
+
+	var records []DNSRecord
+	for _, name := range fields[1:] {
+		if strings.HasPrefix(name, "#") {
+			break
+		}
+		records = append(records, DNSRecord{Name: strings.ToLower(name), Type: recordType, Value: addr.String()})
+	}
+	return records
+}
+
+// parseHostsFile splits hosts file data around the block named marker
+func parseHostsFile(data []byte, marker string) (*hostsFile, error) {
+	file := &hostsFile{}
+	inBlock, seenBlock := false, false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case !seenBlock && strings.TrimSpace(line) == hostsBeginLine(marker):
+			inBlock, seenBlock = true, true
+			file.indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		case inBlock && strings.TrimSpace(line) == hostsEndLine(marker):
+			inBlock = false
+		case inBlock:
+			file.records = append(file.records, parseBlockLine(line)...)
+		case seenBlock:
+			file.after = append(file.after, line)
+		default:
+			file.before = append(file.before, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inBlock {
+		return nil, fmt.Errorf("no %q line", hostsEndLine(marker))
+	}
+	file.hasBlock = seenBlock
+
+	return file, nil
+}
+
+// renderHostsFile formats a hosts file, ordering the block named marker by
+// name so that diffs stay readable
+func renderHostsFile(file *hostsFile, marker string) []byte {
+	records := append([]DNSRecord{}, file.records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Value < b.Value
+	})
+
+	var buf bytes.Buffer
+	for _, line := range file.before {
+		buf.WriteString(line + "\n")
+	}
+	buf.WriteString(file.indent + hostsBeginLine(marker) + "\n")
+	for _, record := range records {
+		if record.Type == "TXT" {
+			fmt.Fprintf(&buf, "%s# TXT %s %s\n", file.indent, record.Name, record.Value)
+		} else {
+			fmt.Fprintf(&buf, "%s%s %s\n", file.indent, record.Value, record.Name)
+		}
+	}
+	buf.WriteString(file.indent + hostsEndLine(marker) + "\n")
+	for _, line := range file.after {
+		buf.WriteString(line + "\n")
+	}
+	return buf.Bytes()
+}
+
+// read parses the hosts file. A missing file is treated as empty.
+func (h *HostsFileProvider) read() (*hostsFile, error) {
+	data, err := os.ReadFile(h.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &hostsFile{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %w", err)
+	}
+
+	file, err := parseHostsFile(data, h.marker)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hosts file %s: %w", h.path, err)
+	}
+	return file, nil
+}
+
+// write atomically replaces the hosts file, then signals the configured
+// process
+func (h *HostsFileProvider) write(file *hostsFile) error {
+	dir := filepath.Dir(h.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(h.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary hosts file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(renderHostsFile(file, h.marker)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write hosts file: %w", err)
+	}
+
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(h.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set hosts file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return fmt.Errorf("failed to replace hosts file: %w", err)
+	}
+
+	return h.signalProcess()
+}
+
+// signalProcess signals the process in the configured pidfile, if any
+func (h *HostsFileProvider) signalProcess() error {
+	if h.pidFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(h.pidFile)
+	if err != nil {
+		return fmt.Errorf("failed to read pidfile: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("invalid pid in %s: %w", h.pidFile, err)
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return fmt.Errorf("failed to find process %d: %w", pid, err)
+	}
+	if err := process.Signal(h.signal); err != nil {
+		return fmt.Errorf("failed to signal process %d: %w", pid, err)
+	}
+	return nil
+}
+
+// hostsRecord normalizes a record for storage in the hosts file
+func hostsRecord(record DNSRecord) (DNSRecord, error) {
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil {
+			return DNSRecord{}, fmt.Errorf("invalid %s record value %q: %w", record.Type, record.Value, err)
+		}
+		record.Value = addr.String()
+	case "TXT":
+		record.Value = "\"" + strings.Trim(record.Value, "\"") + "\""
+	default:
+		return DNSRecord{}, fmt.Errorf("hosts files can't hold %s records", record.Type)
+	}
+
+	return DNSRecord{
+		Name:  strings.ToLower(strings.TrimSuffix(record.Name, ".")),
+		Type:  record.Type,
+		Value: record.Value,
+	}, nil
+}
+
+// modify applies change to the managed records and writes the file back if
+// change reports that anything changed
+func (h *HostsFileProvider) modify(record DNSRecord, change func([]DNSRecord, DNSRecord) ([]DNSRecord, bool)) error {
+	record, err := hostsRecord(record)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := h.read()
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	file.records, changed = change(file.records, record)
+	if !changed {
+		return nil
+	}
+	return h.write(file)
+}
+
+func (h *HostsFileProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	file, err := h.read()
+	if err != nil {
+		return nil, err
+	}
+	return file.records, nil
+}
+
+// addHostsRecord adds record unless it's already present
+func addHostsRecord(records []DNSRecord, record DNSRecord) ([]DNSRecord, bool) {
+	for _, existing := range records {
+		if existing == record {
+			return records, false
+		}
+	}
+	return append(records, record), true
+}
+
+// replaceHostsRecords replaces every value for the record's name and type
+// with record
+func replaceHostsRecords(records []DNSRecord, record DNSRecord) ([]DNSRecord, bool) {
+	// Drop every value for the name and type, then add the new one
+	var kept []DNSRecord
+	changed := true
+	for _, existing := range records {
+		if existing.Type == record.Type && strings.EqualFold(existing.Name, record.Name) {
+			if existing == record {
+				changed = false
+			}
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	if !changed && len(kept) == len(records)-1 {
+		return records, false
+	}
+	return append(kept, record), true
+}
+
+// removeHostsRecord removes the record's value
+func removeHostsRecord(records []DNSRecord, record DNSRecord) ([]DNSRecord, bool) {
+	var kept []DNSRecord
+	for _, existing := range records {
+		if strings.EqualFold(existing.Name, record.Name) && sameValue(existing, record) {
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	return kept, len(kept) != len(records)
+}
+
+func (h *HostsFileProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return h.modify(record, addHostsRecord)
+}
+
+func (h *HostsFileProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return h.modify(record, replaceHostsRecords)
+}
+
+func (h *HostsFileProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	return h.modify(record, removeHostsRecord)
+}
@@ -0,0 +1,536 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// infobloxPageSize is the number of objects requested per page
+const infobloxPageSize = 1000
+
+// InfobloxConfig holds the settings needed to create an InfobloxProvider
+type InfobloxConfig struct {
+	URL         string // Grid Master, e.g. https://gm.example.com
+	WAPIVersion string // Defaults to 2.12
+	Username    string
+	Password    string
+	View        string // DNS view, defaults to "default"
+	Zone        string
+
+	// Extensible attribute marking the records dnsscale owns. It must be
+	// defined in the grid as a string attribute.
+	OwnerAttribute string // Defaults to dnsscale-owner
+
+	// Also create PTR records for A and AAAA records
+	CreatePTR bool
+
+	InsecureSkipVerify bool
+	CAFile             string // PEM bundle used to verify the Grid Master
+}
+
+// InfobloxProvider implements DNSProvider for Infoblox NIOS using WAPI.
+//
+// Ownership is recorded as an extensible attribute on the records dnsscale
+// creates rather than in TXT records. dnsscale's ownership TXT records are
+// translated to and from that attribute; other TXT records are stored as
+// record:txt objects.
+type InfobloxProvider struct {
+	username       string
+	password       string
+	view           string
+	zone           string
+	ownerAttribute string
+	createPTR      bool
+	httpClient     *http.Client
+	baseURL        string
+
+	// Owners of names seen by this process, so records created after the
+	// ownership record inherit the attribute
+	owners ownerCache
+}
+
+// InfobloxExtAttr represents the value of an extensible attribute
+type InfobloxExtAttr struct {
+	Value string `json:"value"`
+}
+
+// InfobloxRecord represents a DNS record object in WAPI. Only the fields for
+// the object's type are set.
+type InfobloxRecord struct {
+	Ref      string                     `json:"_ref,omitempty"`
+	Name     string                     `json:"name,omitempty"`
+	View     string                     `json:"view,omitempty"`
+	TTL      *uint32                    `json:"ttl,omitempty"`
+	UseTTL   *bool                      `json:"use_ttl,omitempty"`
+	IPv4Addr string                     `json:"ipv4addr,omitempty"`
+	IPv6Addr string                     `json:"ipv6addr,omitempty"`
+	Text     string                     `json:"text,omitempty"`
+	PTRDName string                     `json:"ptrdname,omitempty"`
+	Target   string                     `json:"target,omitempty"`
+	Priority *uint16                    `json:"priority,omitempty"`
+	Weight   *uint16                    `json:"weight,omitempty"`
+	Port     *uint16                    `json:"port,omitempty"`
+	ExtAttrs map[string]InfobloxExtAttr `json:"extattrs,omitempty"`
+}
+
+// InfobloxSearchResponse represents a page of objects
+type InfobloxSearchResponse struct {
+	Result     []InfobloxRecord `json:"result"`
+	NextPageID string           `json:"next_page_id,omitempty"`
+}
+
+// InfobloxErrorResponse represents an error returned by WAPI
+type InfobloxErrorResponse struct {
+	Error string `json:"Error"`
+	Code  string `json:"code"`
+	Text  string `json:"text"`
+}
+
+// infobloxObjectTypes maps record types to WAPI object types
+var infobloxObjectTypes = map[string]string{
+	"A":    "record:a",
+	"AAAA": "record:aaaa",
+	"TXT":  "record:txt",
+	"SRV":  "record:srv",
+}
+
+func NewInfobloxProvider(cfg InfobloxConfig) (*InfobloxProvider, error) {
+	if cfg.URL == "" || cfg.Username == "" || cfg.Password == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("URL, username, password and zone are required")
+	}
+
+	version := cfg.WAPIVersion
+	if version == "" {
+		version = "2.12"
+	}
+	view := cfg.View
+	if view == "" {
+		view = "default"
+	}
+	ownerAttribute := cfg.OwnerAttribute
+	if ownerAttribute == "" {
+		ownerAttribute = "dnsscale-owner"
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &InfobloxProvider{
+		username:       cfg.Username,
+		password:       cfg.Password,
+		view:           view,
+		zone:           strings.TrimSuffix(cfg.Zone, "."),
+		ownerAttribute: ownerAttribute,
+		createPTR:      cfg.CreatePTR,
+		httpClient:     &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		baseURL:        fmt.Sprintf("%s/wapi/v%s", strings.TrimSuffix(cfg.URL, "/"), version),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to WAPI and decodes the response into out
+// when it is non-nil
+func (i *InfobloxProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := i.baseURL + "/" + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(i.username, i.password)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp InfobloxErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Text != "" {
+			return fmt.Errorf("infoblox API error: %s (code: %s)", errResp.Text, errResp.Code)
+		}
+		return fmt.Errorf("infoblox API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// search fetches every page of objects of objectType matching query
+func (i *InfobloxProvider) search(ctx context.Context, objectType string, query url.Values) ([]InfobloxRecord, error) {
+	query.Set("view", i.view)
+	query.Set("_return_fields+", "ttl,use_ttl,extattrs")
+	query.Set("_return_as_object", "1")
+	query.Set("_paging", "1")
+	query.Set("_max_results", fmt.Sprint(infobloxPageSize))
+
+	var records []InfobloxRecord
+	for {
+		var page InfobloxSearchResponse
+		if err := i.makeRequest(ctx, "GET", objectType+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		records = append(records, page.Result...)
+
+		if page.NextPageID == "" {
+			break
+		}
+		query = url.Values{}
+		query.Set("_page_id", page.NextPageID)
+	}
+
+	return records, nil
+}
+
+// fromInfobloxRecord converts a WAPI object to our internal format
+func fromInfobloxRecord(recordType string, record InfobloxRecord) DNSRecord {
+	dnsRecord := DNSRecord{Name: record.Name, Type: recordType}
+	if record.TTL != nil && (record.UseTTL == nil || *record.UseTTL) {
+		dnsRecord.TTL = int64(*record.TTL)
+	}
+
+	switch recordType {
+	case "A":
+		dnsRecord.Value = record.IPv4Addr
+	case "AAAA":
+		if addr, err := netip.ParseAddr(record.IPv6Addr); err == nil {
+			dnsRecord.Value = addr.String()
+		} else {
+			dnsRecord.Value = record.IPv6Addr
+		}
+	case "TXT":
+		dnsRecord.Value = "\"" + record.Text + "\""
+	case "SRV":
+		dnsRecord.Value = strings.TrimSuffix(record.Target, ".")
+		if record.Priority != nil {
+			dnsRecord.Priority = *record.Priority
+		}
+		if record.Weight != nil {
+			dnsRecord.Weight = *record.Weight
+		}
+		if record.Port != nil {
+			dnsRecord.Port = *record.Port
+		}
+	}
+	return dnsRecord
+}
+
+// toInfobloxRecord converts a record to the body used to create it
+func (i *InfobloxProvider) toInfobloxRecord(record DNSRecord) InfobloxRecord {
+	ttl := uint32(record.TTL)
+	useTTL := record.TTL > 0
+	ibRecord := InfobloxRecord{
+		Name:   strings.TrimSuffix(record.Name, "."),
+		View:   i.view,
+		TTL:    &ttl,
+		UseTTL: &useTTL,
+	}
+
+	switch record.Type {
+	case "A":
+		ibRecord.IPv4Addr = record.Value
+	case "AAAA":
+		ibRecord.IPv6Addr = record.Value
+	case "TXT":
+		ibRecord.Text = strings.Trim(record.Value, "\"")
+	case "SRV":
+		ibRecord.Target = strings.TrimSuffix(record.Value, ".")
+		ibRecord.Priority = &record.Priority
+		ibRecord.Weight = &record.Weight
+		ibRecord.Port = &record.Port
+	}
+	return ibRecord
+}
+
+// infobloxConverter returns a function converting objects of recordType, for
+// use with firstMatch
+func infobloxConverter(recordType string) func(InfobloxRecord) (DNSRecord, bool) {
+	return func(record InfobloxRecord) (DNSRecord, bool) {
+		return fromInfobloxRecord(recordType, record), true
+	}
+}
+
+// findRecords returns the objects with the record's name and type
+func (i *InfobloxProvider) findRecords(ctx context.Context, recordType, name string) ([]InfobloxRecord, error) {
+	objectType, ok := infobloxObjectTypes[recordType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported record type for infoblox: %s", recordType)
+	}
+
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(name, "."))
+	return i.search(ctx, objectType, query)
+}
+
+// ownedRecordTypes are the record types that carry the owner attribute
+var ownedRecordTypes = []string{"A", "AAAA", "SRV"}
+
+// ownerOf returns the owner recorded for name, first from names seen by this
+// process and then from the attribute on existing records
+func (i *InfobloxProvider) ownerOf(ctx context.Context, name string) (string, error) {
+	if owner, ok := i.owners.get(name); ok {
+		return owner, nil
+	}
+
+	for _, recordType := range ownedRecordTypes {
+		records, err := i.findRecords(ctx, recordType, name)
+		if err != nil {
+			return "", err
+		}
+		for _, record := range records {
+			if attr, ok := record.ExtAttrs[i.ownerAttribute]; ok {
+				return attr.Value, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// setOwner records owner for name and applies the owner attribute to every
+// record at the name. An empty owner removes the attribute.
+func (i *InfobloxProvider) setOwner(ctx context.Context, name, owner string) error {
+	i.owners.set(name, owner)
+
+	for _, recordType := range ownedRecordTypes {
+		records, err := i.findRecords(ctx, recordType, name)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			current, hasOwner := record.ExtAttrs[i.ownerAttribute]
+
+			var body map[string]interface{}
+			switch {
+			case owner == "" && hasOwner:
+				body = map[string]interface{}{"extattrs-": map[string]interface{}{i.ownerAttribute: map[string]interface{}{}}}
+			case owner != "" && (!hasOwner || current.Value != owner):
+				body = map[string]interface{}{"extattrs+": map[string]InfobloxExtAttr{i.ownerAttribute: {Value: owner}}}
+			default:
+				continue
+			}
+
+			if err := i.makeRequest(ctx, "PUT", record.Ref, body, nil); err != nil {
+				return fmt.Errorf("failed to update owner attribute: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ptrRecord returns the PTR object pointing at an A or AAAA record
+func (i *InfobloxProvider) ptrRecord(record DNSRecord) InfobloxRecord {
+	ptr := InfobloxRecord{
+		PTRDName: strings.TrimSuffix(record.Name, "."),
+		View:     i.view,
+	}
+	if record.Type == "A" {
+		ptr.IPv4Addr = record.Value
+	} else {
+		ptr.IPv6Addr = record.Value
+	}
+	return ptr
+}
+
+// deletePTR removes the PTR records pointing at an A or AAAA record
+func (i *InfobloxProvider) deletePTR(ctx context.Context, record DNSRecord) error {
+	query := url.Values{}
+	query.Set("ptrdname", strings.TrimSuffix(record.Name, "."))
+	if record.Type == "A" {
+		query.Set("ipv4addr", record.Value)
+	} else {
+		query.Set("ipv6addr", record.Value)
+	}
+
+	ptrs, err := i.search(ctx, "record:ptr", query)
+	if err != nil {
+		return err
+	}
+	for _, ptr := range ptrs {
+		if _, owned := ptr.ExtAttrs[i.ownerAttribute]; !owned {
+			continue
+		}
+		if err := i.makeRequest(ctx, "DELETE", ptr.Ref, nil, nil); err != nil {
+			return fmt.Errorf("failed to delete PTR record: %w", err)
+		}
+	}
+	return nil
+}
+
+// create adds a single record, tagged with the name's owner
+func (i *InfobloxProvider) create(ctx context.Context, record DNSRecord) error {
+	ibRecord := i.toInfobloxRecord(record)
+
+	if record.Type != "TXT" {
+		owner, err := i.ownerOf(ctx, record.Name)
+		if err != nil {
+			return err
+		}
+		if owner != "" {
+			ibRecord.ExtAttrs = map[string]InfobloxExtAttr{i.ownerAttribute: {Value: owner}}
+		}
+	}
+
+	if err := i.makeRequest(ctx, "POST", infobloxObjectTypes[record.Type], ibRecord, nil); err != nil {
+		return err
+	}
+
+	if i.createPTR && (record.Type == "A" || record.Type == "AAAA") {
+		ptr := i.ptrRecord(record)
+		ptr.ExtAttrs = map[string]InfobloxExtAttr{i.ownerAttribute: {Value: OwnershipPrefix}}
+		if err := i.makeRequest(ctx, "POST", "record:ptr", ptr, nil); err != nil {
+			return fmt.Errorf("failed to create PTR record: %w", err)
+		}
+	}
+	return nil
+}
+
+// remove deletes a single object, along with its PTR record
+func (i *InfobloxProvider) remove(ctx context.Context, recordType string, existing InfobloxRecord) error {
+	if err := i.makeRequest(ctx, "DELETE", existing.Ref, nil, nil); err != nil {
+		return err
+	}
+	if i.createPTR && (recordType == "A" || recordType == "AAAA") {
+		return i.deletePTR(ctx, fromInfobloxRecord(recordType, existing))
+	}
+	return nil
+}
+
+func (i *InfobloxProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	var records []DNSRecord
+	owners := make(map[string]DNSRecord)
+
+	for _, recordType := range []string{"A", "AAAA", "TXT", "SRV"} {
+		query := url.Values{}
+		query.Set("zone", i.zone)
+
+		objects, err := i.search(ctx, infobloxObjectTypes[recordType], query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range objects {
+			record := fromInfobloxRecord(recordType, object)
+			records = append(records, record)
+
+			// Present the owner attribute as the TXT record dnsscale expects
+			if attr, ok := object.ExtAttrs[i.ownerAttribute]; ok && recordType != "TXT" {
+				key := ownerKey(object.Name)
+				if _, seen := owners[key]; !seen {
+					owners[key] = DNSRecord{Name: object.Name, Type: "TXT", Value: "\"" + attr.Value + "\"", TTL: record.TTL}
+				}
+			}
+		}
+	}
+
+	for _, owner := range owners {
+		records = append(records, owner)
+	}
+	return records, nil
+}
+
+func (i *InfobloxProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return i.setOwner(ctx, record.Name, strings.Trim(record.Value, "\""))
+	}
+
+	existing, err := i.findRecords(ctx, record.Type, record.Name)
+	if err != nil {
+		return err
+	}
+	if firstMatch(existing, record, infobloxConverter(record.Type)) >= 0 {
+		return nil
+	}
+
+	return i.create(ctx, record)
+}
+
+func (i *InfobloxProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return i.setOwner(ctx, record.Name, strings.Trim(record.Value, "\""))
+	}
+
+	existing, err := i.findRecords(ctx, record.Type, record.Name)
+	if err != nil {
+		return fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	// Add the new value before removing the old ones so the name stays
+	// resolvable
+	keep := firstMatch(existing, record, infobloxConverter(record.Type))
+	var stale []InfobloxRecord
+	for index, candidate := range existing {
+		if index != keep {
+			stale = append(stale, candidate)
+		}
+	}
+
+	if keep < 0 {
+		if err := i.create(ctx, record); err != nil {
+			return err
+		}
+	}
+	for _, candidate := range stale {
+		if err := i.remove(ctx, record.Type, candidate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *InfobloxProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return i.setOwner(ctx, record.Name, "")
+	}
+
+	existing, err := i.findRecords(ctx, record.Type, record.Name)
+	if err != nil {
+		return err
+	}
+
+	index := firstMatch(existing, record, infobloxConverter(record.Type))
+	if index < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+	return i.remove(ctx, record.Type, existing[index])
+}
@@ -0,0 +1,180 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeWAPI is a minimal stand-in for Infoblox WAPI serving record objects in
+// one view. Objects are keyed by their reference, which starts with the
+// object type like real references do.
+type fakeWAPI struct {
+	mu      sync.Mutex
+	objects map[string]InfobloxRecord
+	nextRef int
+}
+
+func newFakeWAPI(t *testing.T) (*fakeWAPI, *httptest.Server) {
+	fake := &fakeWAPI{objects: map[string]InfobloxRecord{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeWAPI) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, pass, ok := req.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/wapi/v2.12/")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	switch {
+	case req.Method == "GET" && !strings.Contains(path, "/"):
+		query := req.URL.Query()
+		resp := InfobloxSearchResponse{Result: []InfobloxRecord{}}
+		for ref, object := range f.objects {
+			if !strings.HasPrefix(ref, path+"/") || object.View != query.Get("view") {
+				continue
+			}
+			if name := query.Get("name"); name != "" && !strings.EqualFold(object.Name, name) {
+				continue
+			}
+			if zone := query.Get("zone"); zone != "" && !strings.HasSuffix(object.Name, "."+zone) && object.Name != zone {
+				continue
+			}
+			if ptrdname := query.Get("ptrdname"); ptrdname != "" && object.PTRDName != ptrdname {
+				continue
+			}
+			resp.Result = append(resp.Result, object)
+		}
+		json.NewEncoder(w).Encode(resp)
+	case req.Method == "POST" && !strings.Contains(path, "/"):
+		var object InfobloxRecord
+		if err := json.NewDecoder(req.Body).Decode(&object); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.nextRef++
+		object.Ref = fmt.Sprintf("%s/ref%d:%s/%s", path, f.nextRef, object.Name, object.View)
+		f.objects[object.Ref] = object
+		json.NewEncoder(w).Encode(object.Ref)
+	case req.Method == "PUT":
+		object, ok := f.objects[path]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		var body struct {
+			Add    map[string]InfobloxExtAttr `json:"extattrs+"`
+			Remove map[string]json.RawMessage `json:"extattrs-"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		attrs := map[string]InfobloxExtAttr{}
+		for name, attr := range object.ExtAttrs {
+			attrs[name] = attr
+		}
+		for name, attr := range body.Add {
+			attrs[name] = attr
+		}
+		for name := range body.Remove {
+			delete(attrs, name)
+		}
+		object.ExtAttrs = attrs
+		f.objects[path] = object
+		json.NewEncoder(w).Encode(path)
+	case req.Method == "DELETE":
+		if _, ok := f.objects[path]; !ok {
+			http.NotFound(w, req)
+			return
+		}
+		delete(f.objects, path)
+		json.NewEncoder(w).Encode(path)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// snapshot returns every object the fake holds
+func (f *fakeWAPI) snapshot() []InfobloxRecord {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var objects []InfobloxRecord
+	for _, object := range f.objects {
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+func newTestInfobloxProvider(t *testing.T, server *httptest.Server) *InfobloxProvider {
+	t.Helper()
+
+	provider, err := NewInfobloxProvider(InfobloxConfig{
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+		Zone:     "example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestInfobloxProvider(t *testing.T) {
+	_, server := newFakeWAPI(t)
+	testProviderSemantics(t, newTestInfobloxProvider(t, server), "example.com")
+}
+
+func TestInfobloxOwnershipAttribute(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeWAPI(t)
+	provider := newTestInfobloxProvider(t, server)
+
+	name := "web1.example.com"
+	owner := DNSRecord{Name: name, Type: "TXT", Value: "\"" + OwnershipPrefix + " node_id=n1\"", TTL: 300}
+	address := DNSRecord{Name: name, Type: "A", Value: "100.64.0.1", TTL: 300}
+
+	if err := provider.UpdateRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.CreateRecord(ctx, "example.com", address); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range fake.snapshot() {
+		if object.IPv4Addr == "" {
+			t.Fatalf("ownership was stored as an object rather than an attribute: %+v", object)
+		}
+		if object.ExtAttrs["dnsscale-owner"].Value != OwnershipPrefix+" node_id=n1" {
+			t.Fatalf("address object isn't tagged with its owner: %+v", object)
+		}
+	}
+	expectValues(t, provider, "example.com", name, "TXT", OwnershipPrefix+" node_id=n1")
+
+	if err := provider.DeleteRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "TXT")
+	for _, object := range fake.snapshot() {
+		if _, ok := object.ExtAttrs["dnsscale-owner"]; ok {
+			t.Fatalf("owner attribute left behind after deleting ownership: %+v", object)
+		}
+	}
+}
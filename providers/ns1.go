@@ -0,0 +1,324 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errNS1NotFound is returned when NS1 reports that a record doesn't exist
+var errNS1NotFound = errors.New("ns1 record not found")
+
+// NS1Provider implements DNSProvider for NS1. Every value for a name and type
+// is an answer of a single NS1 record.
+type NS1Provider struct {
+	apiKey     string
+	zone       string
+	meta       map[string]interface{}
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NS1Answer represents a single answer of an NS1 record. Metadata attached to
+// existing answers is kept when answers are added or removed.
+type NS1Answer struct {
+	Answer []interface{}   `json:"answer"`
+	Meta   json.RawMessage `json:"meta,omitempty"`
+}
+
+// NS1Record represents a record in NS1's API
+type NS1Record struct {
+	Zone    string          `json:"zone"`
+	Domain  string          `json:"domain"`
+	Type    string          `json:"type"`
+	TTL     int64           `json:"ttl,omitempty"`
+	Answers []NS1Answer     `json:"answers"`
+	Meta    json.RawMessage `json:"meta,omitempty"`
+}
+
+// NS1ZoneRecord represents a record summary in a zone
+type NS1ZoneRecord struct {
+	Domain       string   `json:"domain"`
+	Type         string   `json:"type"`
+	TTL          int64    `json:"ttl"`
+	ShortAnswers []string `json:"short_answers"`
+}
+
+// NS1Zone represents a zone in NS1's API
+type NS1Zone struct {
+	Zone    string          `json:"zone"`
+	Records []NS1ZoneRecord `json:"records"`
+}
+
+// NS1ErrorResponse represents an error returned by NS1's API
+type NS1ErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// NewNS1Provider creates a provider for an NS1 zone. meta is set as the
+// record metadata of records dnsscale creates and may be nil.
+func NewNS1Provider(apiKey, zone, endpoint string, meta map[string]interface{}) (*NS1Provider, error) {
+	if apiKey == "" || zone == "" {
+		return nil, fmt.Errorf("API key and zone are required")
+	}
+
+	if endpoint == "" {
+		endpoint = "https://api.nsone.net/v1"
+	}
+
+	return &NS1Provider{
+		apiKey:     apiKey,
+		zone:       strings.TrimSuffix(zone, "."),
+		meta:       meta,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(endpoint, "/"),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the NS1 API and decodes the response
+// into out when it is non-nil
+func (n *NS1Provider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := n.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-NSONE-Key", n.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errNS1NotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp NS1ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Message != "" {
+			return fmt.Errorf("ns1 API error: %s (code: %d)", errResp.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("ns1 API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordEndpoint returns the API path of the record for name and type
+func (n *NS1Provider) recordEndpoint(name, recordType string) string {
+	return fmt.Sprintf("/zones/%s/%s/%s", url.PathEscape(n.zone), url.PathEscape(strings.TrimSuffix(name, ".")), recordType)
+}
+
+// getRecord returns the NS1 record for the record's name and type, or nil if
+// it doesn't exist
+func (n *NS1Provider) getRecord(ctx context.Context, record DNSRecord) (*NS1Record, error) {
+	var existing NS1Record
+	err := n.makeRequest(ctx, "GET", n.recordEndpoint(record.Name, record.Type), nil, &existing)
+	if errors.Is(err, errNS1NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// ns1Answer converts a record's value to an NS1 answer
+func ns1Answer(record DNSRecord) NS1Answer {
+	switch record.Type {
+	case "TXT":
+		return NS1Answer{Answer: []interface{}{strings.Trim(record.Value, "\"")}}
+	case "SRV":
+		return NS1Answer{Answer: []interface{}{record.Priority, record.Weight, record.Port, strings.TrimSuffix(record.Value, ".")}}
+	}
+	return NS1Answer{Answer: []interface{}{record.Value}}
+}
+
+// ns1AnswerData returns the zone file style value of an answer
+func ns1AnswerData(recordType string, answer NS1Answer) string {
+	fields := make([]string, len(answer.Answer))
+	for i, field := range answer.Answer {
+		// Numbers decode as float64
+		if number, ok := field.(float64); ok {
+			fields[i] = strconv.FormatFloat(number, 'f', -1, 64)
+		} else {
+			fields[i] = fmt.Sprint(field)
+		}
+	}
+	if recordType == "TXT" {
+		return "\"" + strings.Join(fields, "") + "\""
+	}
+	return strings.Join(fields, " ")
+}
+
+// ns1AnswerMatches reports whether an existing answer holds record's value
+func ns1AnswerMatches(answer NS1Answer, record DNSRecord) bool {
+	existing, ok := fromRecordData(record.Name, record.Type, record.TTL, ns1AnswerData(record.Type, answer))
+	if !ok {
+		return false
+	}
+	return sameValue(existing, record)
+}
+
+// newRecord returns the body used to create an NS1 record holding answers
+func (n *NS1Provider) newRecord(record DNSRecord, answers []NS1Answer) (NS1Record, error) {
+	ns1Record := NS1Record{
+		Zone:    n.zone,
+		Domain:  strings.TrimSuffix(record.Name, "."),
+		Type:    record.Type,
+		TTL:     record.TTL,
+		Answers: answers,
+	}
+	if len(n.meta) > 0 {
+		meta, err := json.Marshal(n.meta)
+		if err != nil {
+			return NS1Record{}, fmt.Errorf("failed to encode record metadata: %w", err)
+		}
+		ns1Record.Meta = meta
+	}
+	return ns1Record, nil
+}
+
+// putAnswers creates the record with answers, or replaces the answers of an
+// existing record while keeping its metadata
+func (n *NS1Provider) putAnswers(ctx context.Context, record DNSRecord, existing *NS1Record, answers []NS1Answer) error {
+	endpoint := n.recordEndpoint(record.Name, record.Type)
+
+	if existing == nil {
+		body, err := n.newRecord(record, answers)
+		if err != nil {
+			return err
+		}
+		return n.makeRequest(ctx, "PUT", endpoint, body, nil)
+	}
+
+	// Only send the answers so record metadata, filters and the TTL are
+	// left as they are
+	return n.makeRequest(ctx, "POST", endpoint, map[string]interface{}{"answers": answers}, nil)
+}
+
+func (n *NS1Provider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	var ns1Zone NS1Zone
+	if err := n.makeRequest(ctx, "GET", "/zones/"+url.PathEscape(n.zone), nil, &ns1Zone); err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for _, zoneRecord := range ns1Zone.Records {
+		for _, shortAnswer := range zoneRecord.ShortAnswers {
+			data := shortAnswer
+			if zoneRecord.Type == "TXT" {
+				data = "\"" + strings.Trim(shortAnswer, "\"") + "\""
+			}
+			if record, ok := fromRecordData(zoneRecord.Domain, zoneRecord.Type, zoneRecord.TTL, data); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func (n *NS1Provider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := n.getRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return n.putAnswers(ctx, record, nil, []NS1Answer{ns1Answer(record)})
+	}
+
+	// NS1 holds every value as an answer of one record, so add another answer
+	for _, answer := range existing.Answers {
+		if ns1AnswerMatches(answer, record) {
+			return nil
+		}
+	}
+	answers := append(append([]NS1Answer{}, existing.Answers...), ns1Answer(record))
+	return n.putAnswers(ctx, record, existing, answers)
+}
+
+func (n *NS1Provider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := n.getRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	// The record's TTL is left alone, so a lone answer holding the value
+	// means there is nothing to change
+	if existing != nil && len(existing.Answers) == 1 && ns1AnswerMatches(existing.Answers[0], record) {
+		return nil
+	}
+
+	answer := ns1Answer(record)
+	if existing != nil {
+		// Keep the metadata of the answer being kept, if it's already there
+		for _, current := range existing.Answers {
+			if ns1AnswerMatches(current, record) {
+				answer = current
+				break
+			}
+		}
+	}
+	return n.putAnswers(ctx, record, existing, []NS1Answer{answer})
+}
+
+func (n *NS1Provider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := n.getRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other answers and only drop this one
+	var remaining []NS1Answer
+	for _, answer := range existing.Answers {
+		if !ns1AnswerMatches(answer, record) {
+			remaining = append(remaining, answer)
+		}
+	}
+
+	if len(remaining) == len(existing.Answers) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		err := n.makeRequest(ctx, "DELETE", n.recordEndpoint(record.Name, record.Type), nil, nil)
+		if errors.Is(err, errNS1NotFound) {
+			return nil
+		}
+		return err
+	}
+	return n.putAnswers(ctx, record, existing, remaining)
+}
@@ -0,0 +1,210 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeNS1 is a minimal stand-in for the NS1 API serving the records of one
+// zone
+type fakeNS1 struct {
+	mu      sync.Mutex
+	records map[string]NS1Record // Keyed by domain/type
+}
+
+func newFakeNS1(t *testing.T, meta map[string]interface{}) (*fakeNS1, *NS1Provider) {
+	t.Helper()
+
+	fake := &fakeNS1{records: map[string]NS1Record{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewNS1Provider("key", "example.com", server.URL+"/v1", meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, provider
+}
+
+func (f *fakeNS1) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(NS1ErrorResponse{Message: message})
+	}
+
+	if req.Header.Get("X-NSONE-Key") != "key" {
+		fail(http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, "/v1/zones/example.com")
+	if !ok {
+		fail(http.StatusNotFound, "zone not found")
+		return
+	}
+
+	if path == "" && req.Method == "GET" {
+		zone := NS1Zone{Zone: "example.com", Records: []NS1ZoneRecord{}}
+		for _, record := range f.records {
+			summary := NS1ZoneRecord{Domain: record.Domain, Type: record.Type, TTL: record.TTL}
+			for _, answer := range record.Answers {
+				summary.ShortAnswers = append(summary.ShortAnswers, strings.Trim(ns1AnswerData(record.Type, answer), "\""))
+			}
+			zone.Records = append(zone.Records, summary)
+		}
+		json.NewEncoder(w).Encode(zone)
+		return
+	}
+
+	key := strings.TrimPrefix(path, "/")
+	existing, exists := f.records[key]
+	switch req.Method {
+	case "GET":
+		if !exists {
+			fail(http.StatusNotFound, "record not found")
+			return
+		}
+		json.NewEncoder(w).Encode(existing)
+	case "PUT":
+		if exists {
+			fail(http.StatusBadRequest, "record already exists")
+			return
+		}
+		var record NS1Record
+		if err := json.NewDecoder(req.Body).Decode(&record); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		if record.TTL == 0 {
+			record.TTL = 3600
+		}
+		f.records[key] = record
+		json.NewEncoder(w).Encode(record)
+	case "POST":
+		if !exists {
+			fail(http.StatusNotFound, "record not found")
+			return
+		}
+		// Only the fields sent are changed
+		var update map[string]json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&update); err != nil {
+			fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		current, _ := json.Marshal(existing)
+		var merged map[string]json.RawMessage
+		json.Unmarshal(current, &merged)
+		for field, value := range update {
+			merged[field] = value
+		}
+		raw, _ := json.Marshal(merged)
+		var record NS1Record
+		json.Unmarshal(raw, &record)
+		f.records[key] = record
+		json.NewEncoder(w).Encode(record)
+	case "DELETE":
+		if !exists {
+			fail(http.StatusNotFound, "record not found")
+			return
+		}
+		delete(f.records, key)
+		w.Write([]byte("{}"))
+	default:
+		fail(http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// record returns the stored record at domain and type
+func (f *fakeNS1) record(domain, recordType string) NS1Record {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.records[domain+"/"+recordType]
+}
+
+// setAnswerMeta attaches meta to the answer holding value, as a user might
+// in the NS1 portal
+func (f *fakeNS1) setAnswerMeta(t *testing.T, domain, recordType, value, meta string) {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := f.records[domain+"/"+recordType]
+	for i, answer := range record.Answers {
+		if ns1AnswerData(recordType, answer) == value {
+			record.Answers[i].Meta = json.RawMessage(meta)
+			return
+		}
+	}
+	t.Fatalf("no answer %s at %s %s", value, domain, recordType)
+}
+
+// answerMeta returns the metadata of each answer of the record, keyed by the
+// answer's value
+func (f *fakeNS1) answerMeta(domain, recordType string) map[string]string {
+	metas := map[string]string{}
+	for _, answer := range f.record(domain, recordType).Answers {
+		metas[ns1AnswerData(recordType, answer)] = string(answer.Meta)
+	}
+	return metas
+}
+
+func TestNS1Provider(t *testing.T) {
+	_, provider := newFakeNS1(t, nil)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestNS1RecordMetadata(t *testing.T) {
+	ctx := context.Background()
+	fake, provider := newFakeNS1(t, map[string]interface{}{"note": "managed by dnsscale"})
+	name := "web1.example.com"
+	a := func(value string) DNSRecord {
+		return DNSRecord{Name: name, Type: "A", Value: value, TTL: 300}
+	}
+
+	if err := provider.CreateRecord(ctx, "example.com", a("100.64.0.1")); err != nil {
+		t.Fatal(err)
+	}
+	if meta := string(fake.record(name, "A").Meta); meta != `{"note":"managed by dnsscale"}` {
+		t.Fatalf("record metadata = %s, want the configured note", meta)
+	}
+
+	// Metadata on an answer survives answers being added and removed
+	fake.setAnswerMeta(t, name, "A", "100.64.0.1", `{"up":true}`)
+	if err := provider.CreateRecord(ctx, "example.com", a("100.64.0.2")); err != nil {
+		t.Fatal(err)
+	}
+	if meta := fake.answerMeta(name, "A")["100.64.0.1"]; meta != `{"up":true}` {
+		t.Fatalf("answer metadata after adding an answer = %s", meta)
+	}
+	if err := provider.DeleteRecord(ctx, "example.com", a("100.64.0.2")); err != nil {
+		t.Fatal(err)
+	}
+	if meta := fake.answerMeta(name, "A")["100.64.0.1"]; meta != `{"up":true}` {
+		t.Fatalf("answer metadata after removing an answer = %s", meta)
+	}
+
+	// Updating to a value that's already there keeps that answer as it is
+	if err := provider.CreateRecord(ctx, "example.com", a("100.64.0.3")); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.UpdateRecord(ctx, "example.com", a("100.64.0.1")); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "A", "100.64.0.1")
+	if meta := fake.answerMeta(name, "A")["100.64.0.1"]; meta != `{"up":true}` {
+		t.Fatalf("answer metadata after an update = %s", meta)
+	}
+
+	// Record metadata and the TTL are left alone by answer changes
+	record := fake.record(name, "A")
+	if string(record.Meta) != `{"note":"managed by dnsscale"}` || record.TTL != 300 {
+		t.Fatalf("record metadata %s and TTL %d changed by answer updates", record.Meta, record.TTL)
+	}
+}
@@ -0,0 +1,407 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errPiholeUnauthorized is returned when Pi-hole rejects the session, so the
+// request can be retried after logging in again
+var errPiholeUnauthorized = errors.New("pi-hole session is not valid")
+
+// PiholeProvider implements DNSProvider for Pi-hole's local DNS records and
+// local CNAME records, using the Pi-hole v6 API.
+//
+// Pi-hole can't hold TXT records, so the ones dnsscale uses to track
+// ownership are kept in a local state file.
+type PiholeProvider struct {
+	password   string
+	httpClient *http.Client
+	baseURL    string
+	state      *txtStateFile
+
+	mu        sync.Mutex
+	sid       string
+	sidExpiry time.Time
+}
+
+// PiholeAuthRequest represents a login request
+type PiholeAuthRequest struct {
+	Password string `json:"password"`
+}
+
+// PiholeAuthResponse represents the session returned by a login request
+type PiholeAuthResponse struct {
+	Session struct {
+		Valid    bool   `json:"valid"`
+		SID      string `json:"sid"`
+		Validity int    `json:"validity"` // Seconds
+		Message  string `json:"message"`
+	} `json:"session"`
+}
+
+// PiholeDNSConfigResponse represents the local DNS entries in Pi-hole's
+// configuration
+type PiholeDNSConfigResponse struct {
+	Config struct {
+		DNS struct {
+			Hosts        []string `json:"hosts"`
+			CNAMERecords []string `json:"cnameRecords"`
+		} `json:"dns"`
+	} `json:"config"`
+}
+
+// PiholeErrorResponse represents an error returned by Pi-hole's API
+type PiholeErrorResponse struct {
+	Error struct {
+		Key     string `json:"key"`
+		Message string `json:"message"`
+		Hint    string `json:"hint"`
+	} `json:"error"`
+}
+
+// NewPiholeProvider creates a provider for the Pi-hole at baseURL, e.g.
+// http://pi.hole. password may be the web interface password or an app
+// password.
+func NewPiholeProvider(baseURL, password, stateFile string) (*PiholeProvider, error) {
+	if baseURL == "" || stateFile == "" {
+		return nil, fmt.Errorf("URL and state file are required")
+	}
+
+	return &PiholeProvider{
+		password:   password,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api",
+		state:      newTXTStateFile(stateFile),
+	}, nil
+}
+
+// session returns a valid session ID, logging in if there is none yet or the
+// current one has expired. Pi-holes without a password need no session.
+func (p *PiholeProvider) session(ctx context.Context) (string, error) {
+	if p.password == "" {
+		return "", nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sid != "" && time.Now().Before(p.sidExpiry) {
+		return p.sid, nil
+	}
+
+	var resp PiholeAuthResponse
+	if err := p.do(ctx, "POST", "/auth", "", PiholeAuthRequest{Password: p.password}, &resp); err != nil {
+		return "", fmt.Errorf("failed to log in to pi-hole: %w", err)
+	}
+	if !resp.Session.Valid {
+		return "", fmt.Errorf("failed to log in to pi-hole: %s", resp.Session.Message)
+	}
+
+	p.sid = resp.Session.SID
+	// Renew a little early so a request never races the expiry
+	p.sidExpiry = time.Now().Add(time.Duration(resp.Session.Validity)*time.Second - 30*time.Second)
+	return p.sid, nil
+}
+
+// invalidateSession forgets sid so the next request logs in again
+func (p *PiholeProvider) invalidateSession(sid string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sid == sid {
+		p.sid = ""
+	}
+}
+
+// do sends a single HTTP request to the Pi-hole API with sid, if any, and
+// decodes the response into out when it is non-nil
+func (p *PiholeProvider) do(ctx context.Context, method, endpoint, sid string, body, out interface{}) error {
+	reqURL := p.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if sid != "" {
+		req.Header.Set("X-FTL-SID", sid)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized && sid != "" {
+		return errPiholeUnauthorized
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp PiholeErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error.Message != "" {
+			if errResp.Error.Hint != "" {
+				return fmt.Errorf("pi-hole API error: %s: %s (code: %d)", errResp.Error.Message, errResp.Error.Hint, resp.StatusCode)
+			}
+			return fmt.Errorf("pi-hole API error: %s (code: %d)", errResp.Error.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("pi-hole API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// makeRequest makes an authenticated request to the Pi-hole API, logging in
+// again once if the session has been invalidated on the server
+func (p *PiholeProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		sid, err := p.session(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = p.do(ctx, method, endpoint, sid, body, out)
+		if errors.Is(err, errPiholeUnauthorized) && attempt == 0 {
+			p.invalidateSession(sid)
+			continue
+		}
+		return err
+	}
+}
+
+// getDNSConfig fetches the local DNS records and local CNAME records
+func (p *PiholeProvider) getDNSConfig(ctx context.Context) (*PiholeDNSConfigResponse, error) {
+	var hosts, cnames PiholeDNSConfigResponse
+	if err := p.makeRequest(ctx, "GET", "/config/dns/hosts", nil, &hosts); err != nil {
+		return nil, err
+	}
+	if err := p.makeRequest(ctx, "GET", "/config/dns/cnameRecords", nil, &cnames); err != nil {
+		return nil, err
+	}
+
+	hosts.Config.DNS.CNAMERecords = cnames.Config.DNS.CNAMERecords
+	return &hosts, nil
+}
+
+// parsePiholeHost parses a local DNS record entry, "<ip> <name>"
+func parsePiholeHost(entry string) (DNSRecord, bool) {
+	fields := strings.Fields(entry)
+	if len(fields) != 2 {
+		return DNSRecord{}, false
+	}
+	addr, err := netip.ParseAddr(fields[0])
+	if err != nil {
+		return DNSRecord{}, false
+	}
+
+	recordType := "A"
+	if addr.Is6() {
+		recordType = "AAAA"
+	}
+	return DNSRecord{Name: strings.ToLower(fields[1]), Type: recordType, Value: addr.String()}, true
+}
+
+// parsePiholeCNAME parses a local CNAME record entry, "<name>,<target>[,<ttl>]"
+func parsePiholeCNAME(entry string) (DNSRecord, bool) {
+	fields := strings.Split(entry, ",")
+	if len(fields) < 2 {
+		return DNSRecord{}, false
+	}
+	record := DNSRecord{Name: strings.ToLower(fields[0]), Type: "CNAME", Value: strings.ToLower(fields[1])}
+	if len(fields) > 2 {
+		fmt.Sscan(fields[2], &record.TTL)
+	}
+	return record, true
+}
+
+// piholeEntry formats a record as a Pi-hole configuration entry and returns
+// the configuration item holding it
+func piholeEntry(record DNSRecord) (string, string, error) {
+	name := strings.ToLower(strings.TrimSuffix(record.Name, "."))
+
+	switch record.Type {
+	case "A", "AAAA":
+		addr, err := netip.ParseAddr(record.Value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s record value %q: %w", record.Type, record.Value, err)
+		}
+		return "hosts", addr.String() + " " + name, nil
+	case "CNAME":
+		target := strings.ToLower(strings.TrimSuffix(record.Value, "."))
+		if record.TTL > 0 {
+			return "cnameRecords", fmt.Sprintf("%s,%s,%d", name, target, record.TTL), nil
+		}
+		return "cnameRecords", name + "," + target, nil
+	}
+	return "", "", fmt.Errorf("pi-hole can't hold %s records", record.Type)
+}
+
+// piholeRecordsMatch reports whether two records have the same name, type and value
+func piholeRecordsMatch(a, b DNSRecord) bool {
+	return a.Type == b.Type &&
+		strings.EqualFold(strings.TrimSuffix(a.Name, "."), strings.TrimSuffix(b.Name, ".")) &&
+		strings.EqualFold(strings.TrimSuffix(a.Value, "."), strings.TrimSuffix(b.Value, "."))
+}
+
+// entries returns the records in the Pi-hole configuration with the entry
+// each was parsed from
+func (c *PiholeDNSConfigResponse) entries() map[string]DNSRecord {
+	entries := make(map[string]DNSRecord)
+	for _, entry := range c.Config.DNS.Hosts {
+		if record, ok := parsePiholeHost(entry); ok {
+			entries[entry] = record
+		}
+	}
+	for _, entry := range c.Config.DNS.CNAMERecords {
+		if record, ok := parsePiholeCNAME(entry); ok {
+			entries[entry] = record
+		}
+	}
+	return entries
+}
+
+// addEntry adds an entry to a Pi-hole configuration item
+func (p *PiholeProvider) addEntry(ctx context.Context, item, entry string) error {
+	return p.makeRequest(ctx, "PUT", "/config/dns/"+item+"/"+url.PathEscape(entry), nil, nil)
+}
+
+// deleteEntry removes an entry from a Pi-hole configuration item
+func (p *PiholeProvider) deleteEntry(ctx context.Context, item, entry string) error {
+	return p.makeRequest(ctx, "DELETE", "/config/dns/"+item+"/"+url.PathEscape(entry), nil, nil)
+}
+
+func (p *PiholeProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	config, err := p.getDNSConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for _, record := range config.entries() {
+		records = append(records, record)
+	}
+
+	txtRecords, err := p.state.list()
+	if err != nil {
+		return nil, err
+	}
+	return append(records, txtRecords...), nil
+}
+
+func (p *PiholeProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return p.state.add(record)
+	}
+
+	item, entry, err := piholeEntry(record)
+	if err != nil {
+		return err
+	}
+
+	config, err := p.getDNSConfig(ctx)
+	if err != nil {
+		return err
+	}
+	for _, existing := range config.entries() {
+		if piholeRecordsMatch(existing, record) {
+			return nil
+		}
+	}
+
+	return p.addEntry(ctx, item, entry)
+}
+
+func (p *PiholeProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return p.state.replace(record)
+	}
+
+	item, entry, err := piholeEntry(record)
+	if err != nil {
+		return err
+	}
+
+	config, err := p.getDNSConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add the new value before removing the old ones so the name stays
+	// resolvable
+	found := false
+	var stale []string
+	for existingEntry, existing := range config.entries() {
+		if existing.Type != record.Type || !strings.EqualFold(existing.Name, strings.TrimSuffix(record.Name, ".")) {
+			continue
+		}
+		if piholeRecordsMatch(existing, record) && !found {
+			found = true
+			continue
+		}
+		stale = append(stale, existingEntry)
+	}
+
+	if !found {
+		if err := p.addEntry(ctx, item, entry); err != nil {
+			return err
+		}
+	}
+	for _, staleEntry := range stale {
+		if err := p.deleteEntry(ctx, item, staleEntry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *PiholeProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" {
+		return p.state.remove(record)
+	}
+
+	item, _, err := piholeEntry(record)
+	if err != nil {
+		return err
+	}
+
+	config, err := p.getDNSConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	for existingEntry, existing := range config.entries() {
+		if piholeRecordsMatch(existing, record) {
+			return p.deleteEntry(ctx, item, existingEntry)
+		}
+	}
+
+	// Record doesn't exist, nothing to delete
+	return nil
+}
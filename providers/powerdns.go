@@ -0,0 +1,292 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PowerDNSConfig holds the settings needed to create a PowerDNSProvider
+type PowerDNSConfig struct {
+	APIURL   string // e.g. http://127.0.0.1:8081
+	APIKey   string
+	ServerID string // Defaults to localhost
+	Zone     string
+
+	// Rectify the zone after each change, needed for DNSSEC signed zones
+	// that don't use API-RECTIFY
+	Rectify bool
+	// Send NOTIFY to secondaries after each change
+	Notify bool
+}
+
+// PowerDNSProvider implements DNSProvider for the PowerDNS Authoritative
+// server HTTP API
+type PowerDNSProvider struct {
+	apiKey     string
+	zone       string
+	rectify    bool
+	notify     bool
+	httpClient *http.Client
+	baseURL    string
+}
+
+// PowerDNSRecord represents a single value in a PowerDNS rrset
+type PowerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+// PowerDNSRRSet represents a resource record set in PowerDNS's API
+type PowerDNSRRSet struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	TTL        int64            `json:"ttl,omitempty"`
+	ChangeType string           `json:"changetype,omitempty"`
+	Records    []PowerDNSRecord `json:"records"`
+}
+
+// PowerDNSZone represents a zone returned by PowerDNS's API
+type PowerDNSZone struct {
+	ID     string          `json:"id"`
+	Name   string          `json:"name"`
+	RRSets []PowerDNSRRSet `json:"rrsets"`
+}
+
+// PowerDNSRRSetsRequest represents the body of a zone PATCH request
+type PowerDNSRRSetsRequest struct {
+	RRSets []PowerDNSRRSet `json:"rrsets"`
+}
+
+// PowerDNSErrorResponse represents an error returned by PowerDNS's API
+type PowerDNSErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func NewPowerDNSProvider(cfg PowerDNSConfig) (*PowerDNSProvider, error) {
+	if cfg.APIURL == "" || cfg.APIKey == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("API URL, API key and zone are required")
+	}
+
+	serverID := cfg.ServerID
+	if serverID == "" {
+		serverID = "localhost"
+	}
+
+	return &PowerDNSProvider{
+		apiKey:     cfg.APIKey,
+		zone:       fqdn(cfg.Zone),
+		rectify:    cfg.Rectify,
+		notify:     cfg.Notify,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    fmt.Sprintf("%s/api/v1/servers/%s", strings.TrimSuffix(cfg.APIURL, "/"), url.PathEscape(serverID)),
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the zone's endpoint in the PowerDNS
+// API and decodes the response into out when it is non-nil
+func (p *PowerDNSProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := fmt.Sprintf("%s/zones/%s%s", p.baseURL, url.PathEscape(p.zone), endpoint)
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("X-API-Key", p.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp PowerDNSErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Error != "" {
+			return fmt.Errorf("powerdns API error: %s (code: %d)", errResp.Error, resp.StatusCode)
+		}
+		return fmt.Errorf("powerdns API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// getZone fetches the zone with all of its rrsets
+func (p *PowerDNSProvider) getZone(ctx context.Context) (*PowerDNSZone, error) {
+	var zone PowerDNSZone
+	if err := p.makeRequest(ctx, "GET", "", nil, &zone); err != nil {
+		return nil, err
+	}
+	return &zone, nil
+}
+
+// getRRSet returns the rrset for the record's name and type, or nil if it
+// doesn't exist
+func (p *PowerDNSProvider) getRRSet(ctx context.Context, record DNSRecord) (*PowerDNSRRSet, error) {
+	zone, err := p.getZone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rrset := range zone.RRSets {
+		if rrset.Type == record.Type && strings.EqualFold(rrset.Name, fqdn(record.Name)) {
+			return &rrset, nil
+		}
+	}
+	return nil, nil
+}
+
+// patchRRSet applies a single rrset change, then rectifies and notifies the
+// zone if configured
+func (p *PowerDNSProvider) patchRRSet(ctx context.Context, rrset PowerDNSRRSet) error {
+	if err := p.makeRequest(ctx, "PATCH", "", PowerDNSRRSetsRequest{RRSets: []PowerDNSRRSet{rrset}}, nil); err != nil {
+		return err
+	}
+
+	if p.rectify {
+		if err := p.makeRequest(ctx, "PUT", "/rectify", nil, nil); err != nil {
+			return fmt.Errorf("failed to rectify zone: %w", err)
+		}
+	}
+	if p.notify {
+		if err := p.makeRequest(ctx, "PUT", "/notify", nil, nil); err != nil {
+			return fmt.Errorf("failed to notify secondaries: %w", err)
+		}
+	}
+	return nil
+}
+
+func (p *PowerDNSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	pdnsZone, err := p.getZone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for _, rrset := range pdnsZone.RRSets {
+		for _, value := range rrset.Records {
+			if value.Disabled {
+				continue
+			}
+			if record, ok := fromRecordData(rrset.Name, rrset.Type, rrset.TTL, value.Content); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func (p *PowerDNSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := p.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	data := recordData(record)
+	replacement := PowerDNSRRSet{
+		Name:       fqdn(record.Name),
+		Type:       record.Type,
+		TTL:        record.TTL,
+		ChangeType: "REPLACE",
+		Records:    []PowerDNSRecord{{Content: data}},
+	}
+
+	// A REPLACE sets every value of the rrset, so keep the existing ones
+	if existing != nil {
+		for _, value := range existing.Records {
+			if sameData(value.Content, record) {
+				return nil
+			}
+		}
+		replacement.TTL = existing.TTL
+		replacement.Records = append(append([]PowerDNSRecord{}, existing.Records...), replacement.Records...)
+	}
+
+	return p.patchRRSet(ctx, replacement)
+}
+
+func (p *PowerDNSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := p.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	// Leave the zone alone when the rrset already holds only this value, so
+	// rewriting the ownership record on every pass doesn't rectify the zone
+	// and notify the secondaries each time
+	if existing != nil && existing.TTL == record.TTL && len(existing.Records) == 1 &&
+		!existing.Records[0].Disabled && sameData(existing.Records[0].Content, record) {
+		return nil
+	}
+
+	return p.patchRRSet(ctx, PowerDNSRRSet{
+		Name:       fqdn(record.Name),
+		Type:       record.Type,
+		TTL:        record.TTL,
+		ChangeType: "REPLACE",
+		Records:    []PowerDNSRecord{{Content: recordData(record)}},
+	})
+}
+
+func (p *PowerDNSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := p.getRRSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other values in the rrset and only drop this one
+	var remaining []PowerDNSRecord
+	for _, value := range existing.Records {
+		if !sameData(value.Content, record) {
+			remaining = append(remaining, value)
+		}
+	}
+
+	if len(remaining) == len(existing.Records) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return p.patchRRSet(ctx, PowerDNSRRSet{
+			Name:       existing.Name,
+			Type:       existing.Type,
+			ChangeType: "DELETE",
+			Records:    []PowerDNSRecord{},
+		})
+	}
+
+	return p.patchRRSet(ctx, PowerDNSRRSet{
+		Name:       existing.Name,
+		Type:       existing.Type,
+		TTL:        existing.TTL,
+		ChangeType: "REPLACE",
+		Records:    remaining,
+	})
+}
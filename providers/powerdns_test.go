@@ -0,0 +1,151 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePowerDNS is a minimal stand-in for the PowerDNS Authoritative API
+// serving the rrsets of one zone
+type fakePowerDNS struct {
+	mu        sync.Mutex
+	rrsets    []PowerDNSRRSet
+	patches   int
+	rectifies int
+	notifies  int
+}
+
+func newFakePowerDNS(t *testing.T) (*fakePowerDNS, *PowerDNSProvider) {
+	t.Helper()
+
+	fake := &fakePowerDNS{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewPowerDNSProvider(PowerDNSConfig{
+		APIURL:  server.URL,
+		APIKey:  "secret",
+		Zone:    "example.com",
+		Rectify: true,
+		Notify:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, provider
+}
+
+func (f *fakePowerDNS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if req.Header.Get("X-API-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(PowerDNSErrorResponse{Error: "Unauthorized"})
+		return
+	}
+	path, ok := strings.CutPrefix(req.URL.Path, "/api/v1/servers/localhost/zones/example.com.")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(PowerDNSErrorResponse{Error: "Not Found"})
+		return
+	}
+
+	switch {
+	case req.Method == "GET" && path == "":
+		json.NewEncoder(w).Encode(PowerDNSZone{ID: "example.com.", Name: "example.com.", RRSets: f.rrsets})
+	case req.Method == "PATCH" && path == "":
+		var body PowerDNSRRSetsRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PowerDNSErrorResponse{Error: err.Error()})
+			return
+		}
+		for _, change := range body.RRSets {
+			var kept []PowerDNSRRSet
+			for _, rrset := range f.rrsets {
+				if rrset.Name != change.Name || rrset.Type != change.Type {
+					kept = append(kept, rrset)
+				}
+			}
+			if change.ChangeType == "REPLACE" {
+				change.ChangeType = ""
+				kept = append(kept, change)
+			}
+			f.rrsets = kept
+		}
+		f.patches++
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == "PUT" && path == "/rectify":
+		f.rectifies++
+		json.NewEncoder(w).Encode(map[string]string{"result": "Rectified"})
+	case req.Method == "PUT" && path == "/notify":
+		f.notifies++
+		json.NewEncoder(w).Encode(map[string]string{"result": "Notification queued"})
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// writes returns how many rrset changes, rectifies and notifies were made
+func (f *fakePowerDNS) writes() (int, int, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.patches, f.rectifies, f.notifies
+}
+
+func TestPowerDNSProvider(t *testing.T) {
+	_, provider := newFakePowerDNS(t)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestPowerDNSUpdateUnchanged(t *testing.T) {
+	ctx := context.Background()
+	fake, provider := newFakePowerDNS(t)
+
+	owner := DNSRecord{Name: "web1.example.com", Type: "TXT", Value: "\"" + OwnershipPrefix + " node_id=n1\"", TTL: 300}
+	if err := provider.UpdateRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if patches, rectifies, notifies := fake.writes(); patches != 1 || rectifies != 1 || notifies != 1 {
+		t.Fatalf("first update made %d patches, %d rectifies and %d notifies, want one each", patches, rectifies, notifies)
+	}
+
+	// PowerDNS stores TXT content quoted, so the rewrite has to match the
+	// value regardless of quoting
+	if err := provider.UpdateRecord(ctx, "example.com", DNSRecord{Name: owner.Name, Type: "TXT", Value: OwnershipPrefix + " node_id=n1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.UpdateRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+
+	if patches, rectifies, notifies := fake.writes(); patches != 1 || rectifies != 1 || notifies != 1 {
+		t.Fatalf("updating to the current value made %d patches, %d rectifies and %d notifies, want none", patches-1, rectifies-1, notifies-1)
+	}
+}
+
+func TestPowerDNSMatchesAddressSpelling(t *testing.T) {
+	ctx := context.Background()
+	_, provider := newFakePowerDNS(t)
+	name := "web1.example.com"
+
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0:0:0:0:0:1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "AAAA", "fd7a:115c:a1e0:0:0:0:0:1")
+
+	if err := provider.DeleteRecord(ctx, "example.com", DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "AAAA")
+}
@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// valuesAt returns the sorted values of the records p lists at name with the
+// given type. TXT quotes and trailing dots are dropped so providers can be
+// compared regardless of how they format values.
+func valuesAt(t *testing.T, p DNSProvider, zone, name, recordType string) []string {
+	t.Helper()
+
+	records, err := p.ListRecords(context.Background(), zone)
+	if err != nil {
+		t.Fatalf("ListRecords: %v", err)
+	}
+
+	var values []string
+	for _, record := range records {
+		if record.Type != recordType || !strings.EqualFold(strings.TrimSuffix(record.Name, "."), strings.TrimSuffix(name, ".")) {
+			continue
+		}
+		value := strings.TrimSuffix(strings.Trim(record.Value, "\""), ".")
+		if recordType == "SRV" {
+			value = record.SRVValue()
+		}
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	return values
+}
+
+func expectValues(t *testing.T, p DNSProvider, zone, name, recordType string, want ...string) {
+	t.Helper()
+
+	sort.Strings(want)
+	got := valuesAt(t, p, zone, name, recordType)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("%s %s values = %q, want %q", name, recordType, got, want)
+	}
+}
+
+// testProviderSemantics runs the value-level semantics the reconciler relies
+// on against p, which must start without records at web1.<zone>: create adds
+// a value, delete removes only the given value, and update collapses every
+// value for the name and type into one
+func testProviderSemantics(t *testing.T, p DNSProvider, zone string) {
+	t.Helper()
+
+	ctx := context.Background()
+	name := "web1." + zone
+	a := func(value string) DNSRecord {
+		return DNSRecord{Name: name, Type: "A", Value: value, TTL: 300}
+	}
+	must := func(step string, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("%s: %v", step, err)
+		}
+	}
+
+	must("create first value", p.CreateRecord(ctx, zone, a("100.64.0.1")))
+	must("create second value", p.CreateRecord(ctx, zone, a("100.64.0.2")))
+	must("create existing value", p.CreateRecord(ctx, zone, a("100.64.0.2")))
+	expectValues(t, p, zone, name, "A", "100.64.0.1", "100.64.0.2")
+
+	must("delete one value", p.DeleteRecord(ctx, zone, a("100.64.0.1")))
+	expectValues(t, p, zone, name, "A", "100.64.0.2")
+	must("delete missing value", p.DeleteRecord(ctx, zone, a("100.64.0.1")))
+	expectValues(t, p, zone, name, "A", "100.64.0.2")
+
+	must("create third value", p.CreateRecord(ctx, zone, a("100.64.0.3")))
+	must("update", p.UpdateRecord(ctx, zone, a("100.64.0.4")))
+	expectValues(t, p, zone, name, "A", "100.64.0.4")
+	must("update to the same value", p.UpdateRecord(ctx, zone, a("100.64.0.4")))
+	expectValues(t, p, zone, name, "A", "100.64.0.4")
+
+	must("create AAAA", p.CreateRecord(ctx, zone, DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300}))
+	expectValues(t, p, zone, name, "AAAA", "fd7a:115c:a1e0::1")
+	expectValues(t, p, zone, name, "A", "100.64.0.4")
+
+	owner := DNSRecord{Name: name, Type: "TXT", Value: "\"" + OwnershipPrefix + " node_id=n1\"", TTL: 300}
+	must("update ownership TXT", p.UpdateRecord(ctx, zone, owner))
+	must("update ownership TXT again", p.UpdateRecord(ctx, zone, owner))
+	expectValues(t, p, zone, name, "TXT", OwnershipPrefix+" node_id=n1")
+
+	must("delete A", p.DeleteRecord(ctx, zone, a("100.64.0.4")))
+	must("delete AAAA", p.DeleteRecord(ctx, zone, DNSRecord{Name: name, Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300}))
+	must("delete TXT", p.DeleteRecord(ctx, zone, owner))
+	expectValues(t, p, zone, name, "A")
+	expectValues(t, p, zone, name, "AAAA")
+	expectValues(t, p, zone, name, "TXT")
+}
@@ -0,0 +1,167 @@
+package providers
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+)
+
+// OwnershipPrefix starts the TXT values dnsscale writes to mark the names it
+// manages. Providers that track ownership some other way use it to tell these
+// records apart from ordinary TXT records.
+const OwnershipPrefix = "dnsscale-managed"
+
+// isOwnershipValue reports whether a TXT value is a dnsscale ownership marker
+func isOwnershipValue(value string) bool {
+	return strings.HasPrefix(strings.Trim(value, "\""), OwnershipPrefix+" ")
+}
+
+// ownerCache remembers the ownership value of each name for providers that
+// record ownership on the records themselves, so records created after the
+// ownership record inherit it. The zero value is ready to use.
+type ownerCache struct {
+	mu     sync.Mutex
+	owners map[string]string
+}
+
+// ownerKey normalizes a name for use as a cache key
+func ownerKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// get returns the owner cached for name, and whether there is one
+func (c *ownerCache) get(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	owner, ok := c.owners[ownerKey(name)]
+	return owner, ok
+}
+
+// set caches owner for name. An empty owner forgets the name.
+func (c *ownerCache) set(name, owner string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if owner == "" {
+		delete(c.owners, ownerKey(name))
+		return
+	}
+	if c.owners == nil {
+		c.owners = make(map[string]string)
+	}
+	c.owners[ownerKey(name)] = owner
+}
+
+// fqdn returns name with a trailing dot, as required by APIs that work with
+// fully qualified names
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// recordData returns the zone file representation of a record's value. SRV
+// targets are made fully qualified.
+func recordData(record DNSRecord) string {
+	if record.Type == "SRV" {
+		record.Value = fqdn(record.Value)
+		return record.SRVValue()
+	}
+	return record.Value
+}
+
+// fromRecordData converts a zone file style value back into a DNSRecord,
+// returning false for types dnsscale doesn't manage
+func fromRecordData(name, recordType string, ttl int64, data string) (DNSRecord, bool) {
+	switch recordType {
+	case "A", "AAAA", "TXT":
+		return DNSRecord{
+			Name:  name,
+			Type:  recordType,
+			Value: data,
+			TTL:   ttl,
+		}, true
+	case "SRV":
+		record, err := ParseSRVValue(name, ttl, data)
+		if err != nil {
+			return DNSRecord{}, false
+		}
+		return record, true
+	}
+	return DNSRecord{}, false
+}
+
+// sameData reports whether data, a zone file style value of record's type,
+// holds the same value as record
+func sameData(data string, record DNSRecord) bool {
+	existing, ok := fromRecordData(record.Name, record.Type, record.TTL, data)
+	return ok && sameValue(existing, record)
+}
+
+// relativeName converts a fully qualified record name into a name relative to
+// zone, "@" being the apex
+func relativeName(name, zone string) (string, error) {
+	name = strings.TrimSuffix(name, ".")
+	zone = strings.TrimSuffix(zone, ".")
+	if strings.EqualFold(name, zone) {
+		return "@", nil
+	}
+	suffix := "." + zone
+	if len(name) > len(suffix) && strings.EqualFold(name[len(name)-len(suffix):], suffix) {
+		return name[:len(name)-len(suffix)], nil
+	}
+	return "", fmt.Errorf("record %s is not in zone %s", name, zone)
+}
+
+// absoluteName converts a name relative to zone back into a fully qualified
+// name without a trailing dot
+func absoluteName(name, zone string) string {
+	zone = strings.TrimSuffix(zone, ".")
+	if name == "" || name == "@" {
+		return zone
+	}
+	return name + "." + zone
+}
+
+// sameValue reports whether existing holds the same value as record, which
+// must be of the same type. TXT quoting, trailing dots and the case of target
+// names, and the spelling of IPv6 addresses don't matter.
+func sameValue(existing, record DNSRecord) bool {
+	if existing.Type != record.Type {
+		return false
+	}
+	switch record.Type {
+	case "TXT":
+		return strings.Trim(existing.Value, "\"") == strings.Trim(record.Value, "\"")
+	case "SRV":
+		existing.Value = strings.TrimSuffix(existing.Value, ".")
+		record.Value = strings.TrimSuffix(record.Value, ".")
+		return strings.EqualFold(existing.SRVValue(), record.SRVValue())
+	case "CNAME":
+		return strings.EqualFold(strings.TrimSuffix(existing.Value, "."), strings.TrimSuffix(record.Value, "."))
+	case "AAAA":
+		a, errA := netip.ParseAddr(existing.Value)
+		b, errB := netip.ParseAddr(record.Value)
+		if errA == nil && errB == nil {
+			return a == b
+		}
+	}
+	return existing.Value == record.Value
+}
+
+// firstMatch returns the index of the first candidate holding record's value,
+// or of the first candidate at all when record has no value, and -1 if there
+// is none. Providers use it to delete a single value when several records
+// share a name, converting their own record type with convert.
+func firstMatch[T any](candidates []T, record DNSRecord, convert func(T) (DNSRecord, bool)) int {
+	for i, candidate := range candidates {
+		if record.Value == "" {
+			return i
+		}
+		if existing, ok := convert(candidate); ok && sameValue(existing, record) {
+			return i
+		}
+	}
+	return -1
+}
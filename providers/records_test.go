@@ -0,0 +1,63 @@
+package providers
+
+import "testing"
+
+func TestSameValue(t *testing.T) {
+	srv := func(target string) DNSRecord {
+		return DNSRecord{Type: "SRV", Value: target, Priority: 10, Weight: 5, Port: 443}
+	}
+
+	tests := []struct {
+		name     string
+		existing DNSRecord
+		record   DNSRecord
+		want     bool
+	}{
+		{"same A", DNSRecord{Type: "A", Value: "100.64.0.1"}, DNSRecord{Type: "A", Value: "100.64.0.1"}, true},
+		{"different A", DNSRecord{Type: "A", Value: "100.64.0.1"}, DNSRecord{Type: "A", Value: "100.64.0.2"}, false},
+		{"different type", DNSRecord{Type: "A", Value: "100.64.0.1"}, DNSRecord{Type: "AAAA", Value: "100.64.0.1"}, false},
+		{"AAAA spelling", DNSRecord{Type: "AAAA", Value: "fd7a:115c:a1e0:0::1"}, DNSRecord{Type: "AAAA", Value: "fd7a:115c:a1e0::1"}, true},
+		{"TXT quoting", DNSRecord{Type: "TXT", Value: "dnsscale-managed node_id=n1"}, DNSRecord{Type: "TXT", Value: "\"dnsscale-managed node_id=n1\""}, true},
+		{"SRV trailing dot and case", srv("Web1.example.com"), srv("web1.example.com."), true},
+		{"SRV port", srv("web1.example.com"), DNSRecord{Type: "SRV", Value: "web1.example.com", Priority: 10, Weight: 5, Port: 80}, false},
+		{"CNAME trailing dot", DNSRecord{Type: "CNAME", Value: "web1.example.com."}, DNSRecord{Type: "CNAME", Value: "web1.example.com"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameValue(tt.existing, tt.record); got != tt.want {
+				t.Errorf("sameValue(%+v, %+v) = %v, want %v", tt.existing, tt.record, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstMatch(t *testing.T) {
+	candidates := []string{"100.64.0.1", "bad", "100.64.0.2"}
+	convert := func(value string) (DNSRecord, bool) {
+		return DNSRecord{Type: "A", Value: value}, value != "bad"
+	}
+
+	if i := firstMatch(candidates, DNSRecord{Type: "A", Value: "100.64.0.2"}, convert); i != 2 {
+		t.Errorf("matching value: got index %d, want 2", i)
+	}
+	if i := firstMatch(candidates, DNSRecord{Type: "A", Value: "100.64.0.3"}, convert); i != -1 {
+		t.Errorf("missing value: got index %d, want -1", i)
+	}
+	if i := firstMatch(candidates, DNSRecord{Type: "A"}, convert); i != 0 {
+		t.Errorf("no value: got index %d, want 0", i)
+	}
+}
+
+func TestSameData(t *testing.T) {
+	srv := DNSRecord{Name: "_http._tcp.example.com", Type: "SRV", Value: "web1.example.com", Priority: 10, Weight: 5, Port: 80}
+	if !sameData("10 5 80 web1.example.com.", srv) {
+		t.Error("SRV data with a fully qualified target didn't match")
+	}
+	if !sameData("fd7a:115c:a1e0:0:0:0:0:1", DNSRecord{Type: "AAAA", Value: "fd7a:115c:a1e0::1"}) {
+		t.Error("AAAA data spelled out didn't match")
+	}
+	if sameData("\"other\"", DNSRecord{Type: "TXT", Value: "\"dnsscale-managed node_id=n1\""}) {
+		t.Error("different TXT data matched")
+	}
+}
@@ -0,0 +1,238 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// RFC2136Config holds the settings needed to create an RFC2136Provider
+type RFC2136Config struct {
+	// Server is the primary name server, as host or host:port
+	Server string
+	Zone   string
+
+	// TSIG key used to sign updates and zone transfers. Leaving the key name
+	// empty sends unsigned messages.
+	TSIGKeyName   string
+	TSIGSecret    string // Base64 encoded
+	TSIGAlgorithm string // hmac-sha256 or hmac-sha512
+
+	Timeout time.Duration
+}
+
+// RFC2136Provider implements DNSProvider by sending RFC 2136 dynamic updates
+// to a primary name server such as BIND or Knot, and reading the zone back
+// with AXFR
+type RFC2136Provider struct {
+	server        string
+	zone          string
+	tsigKeyName   string
+	tsigSecret    string
+	tsigAlgorithm string
+	timeout       time.Duration
+}
+
+// rfc2136Algorithms maps configured TSIG algorithm names to their DNS names
+var rfc2136Algorithms = map[string]string{
+	"hmac-sha256": dns.HmacSHA256,
+	"hmac-sha512": dns.HmacSHA512,
+}
+
+func NewRFC2136Provider(cfg RFC2136Config) (*RFC2136Provider, error) {
+	if cfg.Server == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("server and zone are required")
+	}
+
+	server := cfg.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+
+	provider := &RFC2136Provider{
+		server:  server,
+		zone:    dns.Fqdn(cfg.Zone),
+		timeout: cfg.Timeout,
+	}
+	if provider.timeout <= 0 {
+		provider.timeout = 10 * time.Second
+	}
+
+	if cfg.TSIGKeyName != "" {
+		if cfg.TSIGSecret == "" {
+			return nil, fmt.Errorf("TSIG secret is required when a TSIG key name is set")
+		}
+		algorithm := cfg.TSIGAlgorithm
+		if algorithm == "" {
+			algorithm = "hmac-sha256"
+		}
+		dnsAlgorithm, ok := rfc2136Algorithms[strings.ToLower(algorithm)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TSIG algorithm: %s (supported: hmac-sha256, hmac-sha512)", algorithm)
+		}
+
+		provider.tsigKeyName = dns.Fqdn(cfg.TSIGKeyName)
+		provider.tsigSecret = cfg.TSIGSecret
+		provider.tsigAlgorithm = dnsAlgorithm
+	}
+
+	return provider, nil
+}
+
+// sign adds a TSIG record to msg when a key is configured
+func (p *RFC2136Provider) sign(msg *dns.Msg) {
+	if p.tsigKeyName != "" {
+		msg.SetTsig(p.tsigKeyName, p.tsigAlgorithm, 300, time.Now().Unix())
+	}
+}
+
+// tsigSecrets returns the secrets used to sign and verify messages
+func (p *RFC2136Provider) tsigSecrets() map[string]string {
+	if p.tsigKeyName == "" {
+		return nil
+	}
+	return map[string]string{p.tsigKeyName: p.tsigSecret}
+}
+
+// sendUpdate signs and sends an UPDATE message, failing on any response code
+// other than success
+func (p *RFC2136Provider) sendUpdate(ctx context.Context, msg *dns.Msg) error {
+	p.sign(msg)
+
+	client := &dns.Client{
+		Net:        "tcp",
+		Timeout:    p.timeout,
+		TsigSecret: p.tsigSecrets(),
+	}
+
+	resp, _, err := client.ExchangeContext(ctx, msg, p.server)
+	if err != nil {
+		return fmt.Errorf("dns update failed: %w", err)
+	}
+	if resp.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("dns update rejected: %s", dns.RcodeToString[resp.Rcode])
+	}
+	return nil
+}
+
+// toRR converts a record to a resource record
+func toRR(record DNSRecord) (dns.RR, error) {
+	data := recordData(record)
+	if record.Type == "TXT" && !strings.HasPrefix(data, "\"") {
+		data = "\"" + data + "\""
+	}
+
+	rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", dns.Fqdn(record.Name), record.TTL, record.Type, data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s record %s: %w", record.Type, record.Name, err)
+	}
+	return rr, nil
+}
+
+// fromRR converts a resource record to our internal format, returning false
+// for types dnsscale doesn't manage
+func fromRR(rr dns.RR) (DNSRecord, bool) {
+	header := rr.Header()
+	record := DNSRecord{
+		Name: header.Name,
+		Type: dns.TypeToString[header.Rrtype],
+		TTL:  int64(header.Ttl),
+	}
+
+	switch v := rr.(type) {
+	case *dns.A:
+		record.Value = v.A.String()
+	case *dns.AAAA:
+		record.Value = v.AAAA.String()
+	case *dns.TXT:
+		record.Value = "\"" + strings.Join(v.Txt, "") + "\""
+	case *dns.SRV:
+		record.Value = strings.TrimSuffix(v.Target, ".")
+		record.Priority = v.Priority
+		record.Weight = v.Weight
+		record.Port = v.Port
+	default:
+		return DNSRecord{}, false
+	}
+
+	return record, true
+}
+
+func (p *RFC2136Provider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	msg := new(dns.Msg)
+	msg.SetAxfr(p.zone)
+	p.sign(msg)
+
+	transfer := &dns.Transfer{
+		DialTimeout:  p.timeout,
+		ReadTimeout:  p.timeout,
+		WriteTimeout: p.timeout,
+		TsigSecret:   p.tsigSecrets(),
+	}
+
+	envelopes, err := transfer.In(msg, p.server)
+	if err != nil {
+		return nil, fmt.Errorf("zone transfer failed: %w", err)
+	}
+
+	var records []DNSRecord
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			// Drain the channel so the transfer goroutine can exit
+			for range envelopes {
+			}
+			return nil, fmt.Errorf("zone transfer failed: %w", envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			if record, ok := fromRR(rr); ok {
+				records = append(records, record)
+			}
+		}
+	}
+
+	return records, nil
+}
+
+func (p *RFC2136Provider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	// Adding a value that already exists is a no-op for the server
+	msg := new(dns.Msg)
+	msg.SetUpdate(p.zone)
+	msg.Insert([]dns.RR{rr})
+	return p.sendUpdate(ctx, msg)
+}
+
+func (p *RFC2136Provider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	// Replace every value in one update so the name never goes unresolvable
+	msg := new(dns.Msg)
+	msg.SetUpdate(p.zone)
+	msg.RemoveRRset([]dns.RR{rr})
+	msg.Insert([]dns.RR{rr})
+	return p.sendUpdate(ctx, msg)
+}
+
+func (p *RFC2136Provider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	// Removing a value that doesn't exist is a no-op for the server
+	msg := new(dns.Msg)
+	msg.SetUpdate(p.zone)
+	msg.Remove([]dns.RR{rr})
+	return p.sendUpdate(ctx, msg)
+}
@@ -0,0 +1,162 @@
+package providers
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	testTSIGKey    = "dnsscale."
+	testTSIGSecret = "ZG5zc2NhbGUtdGVzdC1zZWNyZXQ="
+)
+
+// fakeRFC2136Server is a minimal primary name server for one zone that
+// applies signed dynamic updates and serves signed zone transfers
+type fakeRFC2136Server struct {
+	mu  sync.Mutex
+	soa dns.RR
+	rrs []dns.RR
+}
+
+// startFakeRFC2136Server serves a fake primary for example.com over TCP and
+// returns its address
+func startFakeRFC2136Server(t *testing.T) string {
+	t.Helper()
+
+	soa, err := dns.NewRR("example.com. 3600 IN SOA ns1.example.com. hostmaster.example.com. 1 3600 600 86400 300")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          listener,
+		Handler:           &fakeRFC2136Server{soa: soa},
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accept function refuses UPDATE messages
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+	<-started
+
+	return listener.Addr().String()
+}
+
+func (f *fakeRFC2136Server) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
+	resp := new(dns.Msg)
+	tsig := req.IsTsig()
+	if tsig == nil || w.TsigStatus() != nil {
+		resp.SetRcode(req, dns.RcodeNotAuth)
+		w.WriteMsg(resp)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case req.Opcode == dns.OpcodeUpdate:
+		f.update(req.Ns)
+		resp.SetReply(req)
+	case len(req.Question) == 1 && req.Question[0].Qtype == dns.TypeAXFR:
+		rrs := append([]dns.RR{f.soa}, f.rrs...)
+		ch := make(chan *dns.Envelope, 1)
+		ch <- &dns.Envelope{RR: append(rrs, f.soa)}
+		close(ch)
+		new(dns.Transfer).Out(w, req, ch)
+		return
+	default:
+		resp.SetRcode(req, dns.RcodeNotImplemented)
+	}
+
+	resp.SetTsig(tsig.Hdr.Name, tsig.Algorithm, tsig.Fudge, time.Now().Unix())
+	w.WriteMsg(resp)
+}
+
+// update applies the update section of a message as described in RFC 2136
+// section 3.4.2
+func (f *fakeRFC2136Server) update(updates []dns.RR) {
+	for _, rr := range updates {
+		header := rr.Header()
+		switch header.Class {
+		case dns.ClassANY:
+			f.remove(func(existing dns.RR) bool {
+				return strings.EqualFold(existing.Header().Name, header.Name) &&
+					(header.Rrtype == dns.TypeANY || existing.Header().Rrtype == header.Rrtype)
+			})
+		case dns.ClassNONE:
+			target := dns.Copy(rr)
+			target.Header().Class = dns.ClassINET
+			f.remove(func(existing dns.RR) bool {
+				return dns.IsDuplicate(existing, target)
+			})
+		default:
+			duplicate := false
+			for _, existing := range f.rrs {
+				duplicate = duplicate || dns.IsDuplicate(existing, rr)
+			}
+			if !duplicate {
+				f.rrs = append(f.rrs, dns.Copy(rr))
+			}
+		}
+	}
+}
+
+// remove drops every record matching match
+func (f *fakeRFC2136Server) remove(match func(dns.RR) bool) {
+	kept := f.rrs[:0]
+	for _, existing := range f.rrs {
+		if !match(existing) {
+			kept = append(kept, existing)
+		}
+	}
+	f.rrs = kept
+}
+
+func TestRFC2136Provider(t *testing.T) {
+	addr := startFakeRFC2136Server(t)
+
+	provider, err := NewRFC2136Provider(RFC2136Config{
+		Server:      addr,
+		Zone:        "example.com",
+		TSIGKeyName: testTSIGKey,
+		TSIGSecret:  testTSIGSecret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestRFC2136ProviderRejectedKey(t *testing.T) {
+	addr := startFakeRFC2136Server(t)
+
+	provider, err := NewRFC2136Provider(RFC2136Config{
+		Server:      addr,
+		Zone:        "example.com",
+		TSIGKeyName: testTSIGKey,
+		TSIGSecret:  "d3Jvbmctc2VjcmV0",
+		Timeout:     2 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.CreateRecord(t.Context(), "example.com", record); err == nil {
+		t.Fatal("CreateRecord succeeded with the wrong TSIG secret")
+	}
+}
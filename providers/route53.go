@@ -2,6 +2,9 @@ package providers
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
@@ -12,11 +15,54 @@ import (
 type DNSRecord struct {
 	Name  string
 	Type  string
-	Value string
+	Value string // For SRV records this is the target host name
 	TTL   int64
+
+	// SRV specific fields
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+}
+
+// SRVValue formats an SRV record's data in zone file order:
+// "priority weight port target"
+func (r DNSRecord) SRVValue() string {
+	return fmt.Sprintf("%d %d %d %s", r.Priority, r.Weight, r.Port, r.Value)
 }
 
-// DNSProvider interface for different cloud providers
+// ParseSRVValue parses SRV data in zone file order into an SRV DNSRecord
+func ParseSRVValue(name string, ttl int64, value string) (DNSRecord, error) {
+	fields := strings.Fields(value)
+	if len(fields) != 4 {
+		return DNSRecord{}, fmt.Errorf("invalid SRV value %q", value)
+	}
+
+	var numbers [3]uint16
+	for i := range numbers {
+		n, err := strconv.ParseUint(fields[i], 10, 16)
+		if err != nil {
+			return DNSRecord{}, fmt.Errorf("invalid SRV value %q: %w", value, err)
+		}
+		numbers[i] = uint16(n)
+	}
+
+	return DNSRecord{
+		Name:     name,
+		Type:     "SRV",
+		Value:    fields[3],
+		TTL:      ttl,
+		Priority: numbers[0],
+		Weight:   numbers[1],
+		Port:     numbers[2],
+	}, nil
+}
+
+// DNSProvider interface for different cloud providers.
+//
+// Records are handled one value at a time: CreateRecord adds a value alongside
+// any others with the same name and type, DeleteRecord removes only the
+// matching value, and UpdateRecord replaces every value for the name and type
+// with the one given.
 type DNSProvider interface {
 	ListRecords(ctx context.Context, zone string) ([]DNSRecord, error)
 	CreateRecord(ctx context.Context, zone string, record DNSRecord) error
@@ -24,6 +70,13 @@ type DNSProvider interface {
 	DeleteRecord(ctx context.Context, zone string, record DNSRecord) error
 }
 
+// ResyncNotifier is implemented by providers whose records can disappear
+// without dnsscale deleting them, e.g. when an etcd lease expires. Every
+// record is published again whenever the channel receives.
+type ResyncNotifier interface {
+	Resync() <-chan struct{}
+}
+
 // Route53Provider implements DNSProvider for AWS Route53
 type Route53Provider struct {
 	client *route53.Client
@@ -56,15 +109,25 @@ func (r *Route53Provider) ListRecords(ctx context.Context, zone string) ([]DNSRe
 		}
 
 		for _, rrs := range page.ResourceRecordSets {
-			if rrs.Type == "A" || rrs.Type == "AAAA" {
+			// TXT records are included so dnsscale can find its ownership records
+			switch rrs.Type {
+			case "A", "AAAA", "TXT":
 				for _, rr := range rrs.ResourceRecords {
 					records = append(records, DNSRecord{
-						Name:  *rrs.Name,
+						Name:  route53RecordName(*rrs.Name),
 						Type:  string(rrs.Type),
 						Value: *rr.Value,
 						TTL:   *rrs.TTL,
 					})
 				}
+			case "SRV":
+				for _, rr := range rrs.ResourceRecords {
+					record, err := ParseSRVValue(route53RecordName(*rrs.Name), *rrs.TTL, *rr.Value)
+					if err != nil {
+						return nil, err
+					}
+					records = append(records, record)
+				}
 			}
 		}
 	}
@@ -72,21 +135,51 @@ func (r *Route53Provider) ListRecords(ctx context.Context, zone string) ([]DNSRe
 	return records, nil
 }
 
-func (r *Route53Provider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+// route53RecordName undoes Route53's octal escaping of the wildcard label, so
+// "\052.node.example.com." is returned as "*.node.example.com."
+func route53RecordName(name string) string {
+	return strings.ReplaceAll(name, "\\052", "*")
+}
+
+// route53Value returns the resource record value for record
+func route53Value(record DNSRecord) string {
+	if record.Type == "SRV" {
+		return record.SRVValue()
+	}
+	return record.Value
+}
+
+// getRecordSet returns the record set for the record's name and type, or nil
+// if it doesn't exist
+func (r *Route53Provider) getRecordSet(ctx context.Context, record DNSRecord) (*types.ResourceRecordSet, error) {
+	maxItems := int32(1)
+	out, err := r.client.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    &r.zoneID,
+		StartRecordName: &record.Name,
+		StartRecordType: types.RRType(record.Type),
+		MaxItems:        &maxItems,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rrs := range out.ResourceRecordSets {
+		if rrs.Type == types.RRType(record.Type) && rrs.Name != nil &&
+			strings.EqualFold(strings.TrimSuffix(route53RecordName(*rrs.Name), "."), strings.TrimSuffix(record.Name, ".")) {
+			return &rrs, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *Route53Provider) changeRecordSet(ctx context.Context, action types.ChangeAction, rrs *types.ResourceRecordSet) error {
 	_, err := r.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: &r.zoneID,
 		ChangeBatch: &types.ChangeBatch{
 			Changes: []types.Change{
 				{
-					Action: types.ChangeActionCreate,
-					ResourceRecordSet: &types.ResourceRecordSet{
-						Name: &record.Name,
-						Type: types.RRType(record.Type),
-						TTL:  &record.TTL,
-						ResourceRecords: []types.ResourceRecord{
-							{Value: &record.Value},
-						},
-					},
+					Action:            action,
+					ResourceRecordSet: rrs,
 				},
 			},
 		},
@@ -94,7 +187,37 @@ func (r *Route53Provider) CreateRecord(ctx context.Context, zone string, record
 	return err
 }
 
+func (r *Route53Provider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	// Route53 holds every value for a name and type in one record set, so an
+	// additional value is merged into the existing set
+	value := route53Value(record)
+	existing, err := r.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.changeRecordSet(ctx, types.ChangeActionCreate, &types.ResourceRecordSet{
+			Name: &record.Name,
+			Type: types.RRType(record.Type),
+			TTL:  &record.TTL,
+			ResourceRecords: []types.ResourceRecord{
+				{Value: &value},
+			},
+		})
+	}
+
+	for _, rr := range existing.ResourceRecords {
+		if rr.Value != nil && sameData(*rr.Value, record) {
+			return nil
+		}
+	}
+
+	existing.ResourceRecords = append(existing.ResourceRecords, types.ResourceRecord{Value: &value})
+	return r.changeRecordSet(ctx, types.ChangeActionUpsert, existing)
+}
+
 func (r *Route53Provider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	value := route53Value(record)
 	_, err := r.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: &r.zoneID,
 		ChangeBatch: &types.ChangeBatch{
@@ -106,7 +229,7 @@ func (r *Route53Provider) UpdateRecord(ctx context.Context, zone string, record
 						Type: types.RRType(record.Type),
 						TTL:  &record.TTL,
 						ResourceRecords: []types.ResourceRecord{
-							{Value: &record.Value},
+							{Value: &value},
 						},
 					},
 				},
@@ -117,23 +240,31 @@ func (r *Route53Provider) UpdateRecord(ctx context.Context, zone string, record
 }
 
 func (r *Route53Provider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
-	_, err := r.client.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
-		HostedZoneId: &r.zoneID,
-		ChangeBatch: &types.ChangeBatch{
-			Changes: []types.Change{
-				{
-					Action: types.ChangeActionDelete,
-					ResourceRecordSet: &types.ResourceRecordSet{
-						Name: &record.Name,
-						Type: types.RRType(record.Type),
-						TTL:  &record.TTL,
-						ResourceRecords: []types.ResourceRecord{
-							{Value: &record.Value},
-						},
-					},
-				},
-			},
-		},
-	})
-	return err
+	existing, err := r.getRecordSet(ctx, record)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Keep any other values in the set and only drop this one
+	var remaining []types.ResourceRecord
+	for _, rr := range existing.ResourceRecords {
+		if rr.Value != nil && sameData(*rr.Value, record) {
+			continue
+		}
+		remaining = append(remaining, rr)
+	}
+
+	if len(remaining) == len(existing.ResourceRecords) {
+		return nil
+	}
+	if len(remaining) == 0 {
+		return r.changeRecordSet(ctx, types.ChangeActionDelete, existing)
+	}
+
+	existing.ResourceRecords = remaining
+	return r.changeRecordSet(ctx, types.ChangeActionUpsert, existing)
 }
@@ -0,0 +1,477 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RouterOSConfig holds the settings needed to create a RouterOSProvider
+type RouterOSConfig struct {
+	URL      string // Router's REST API, e.g. https://192.168.88.1
+	Username string
+	Password string
+	Zone     string // Only entries for names in this zone are managed
+
+	InsecureSkipVerify bool   // Routers commonly use self-signed certificates
+	CAFile             string // PEM bundle used to verify the router
+}
+
+// RouterOSProvider implements DNSProvider for MikroTik RouterOS static DNS
+// entries using the RouterOS v7 REST API.
+//
+// Ownership is recorded in the comment of the entries dnsscale creates rather
+// than in TXT entries. dnsscale's ownership TXT records are translated to and
+// from that comment; other TXT records are stored as TXT entries.
+type RouterOSProvider struct {
+	username   string
+	password   string
+	zone       string
+	httpClient *http.Client
+	baseURL    string
+
+	// Owners of names seen by this process, so entries created after the
+	// ownership record inherit the comment
+	owners ownerCache
+}
+
+// RouterOSEntry represents an /ip/dns/static entry. RouterOS reports every
+// property as a string, and entries created before v7 may have no type,
+// meaning A. IDs look like "*1A" and are used in paths as they are.
+type RouterOSEntry struct {
+	ID          string `json:".id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Address     string `json:"address,omitempty"`
+	CNAME       string `json:"cname,omitempty"`
+	Text        string `json:"text,omitempty"`
+	SRVPriority string `json:"srv-priority,omitempty"`
+	SRVWeight   string `json:"srv-weight,omitempty"`
+	SRVPort     string `json:"srv-port,omitempty"`
+	SRVTarget   string `json:"srv-target,omitempty"`
+	TTL         string `json:"ttl,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Disabled    string `json:"disabled,omitempty"`
+}
+
+// RouterOSErrorResponse represents an error returned by the REST API
+type RouterOSErrorResponse struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+	Detail  string `json:"detail"`
+}
+
+func NewRouterOSProvider(cfg RouterOSConfig) (*RouterOSProvider, error) {
+	if cfg.URL == "" || cfg.Username == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("URL, username and zone are required")
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &RouterOSProvider{
+		username:   cfg.Username,
+		password:   cfg.Password,
+		zone:       strings.ToLower(strings.TrimSuffix(cfg.Zone, ".")),
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+		baseURL:    strings.TrimSuffix(cfg.URL, "/") + "/rest/ip/dns/static",
+	}, nil
+}
+
+// makeRequest makes an HTTP request to the static DNS entries in the REST API
+// and decodes the response into out when it is non-nil
+func (r *RouterOSProvider) makeRequest(ctx context.Context, method, endpoint string, body, out interface{}) error {
+	reqURL := r.baseURL + endpoint
+
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(r.username, r.password)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var errResp RouterOSErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Message != "" {
+			if errResp.Detail != "" {
+				return fmt.Errorf("routeros API error: %s: %s (code: %d)", errResp.Message, errResp.Detail, resp.StatusCode)
+			}
+			return fmt.Errorf("routeros API error: %s (code: %d)", errResp.Message, resp.StatusCode)
+		}
+		return fmt.Errorf("routeros API request failed with status %d", resp.StatusCode)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseRouterOSDuration parses a RouterOS time value such as "1d2h3m4s",
+// "00:05:00" or "1w2d00:05:00" into seconds
+func parseRouterOSDuration(value string) int64 {
+	units, clock := value, ""
+	if strings.Contains(value, ":") {
+		idx := strings.LastIndexAny(value, "wd")
+		units, clock = value[:idx+1], value[idx+1:]
+	}
+
+	var total, number int64
+	for _, c := range units {
+		switch {
+		case c >= '0' && c <= '9':
+			number = number*10 + int64(c-'0')
+		case c == 'w':
+			total, number = total+number*7*24*3600, 0
+		case c == 'd':
+			total, number = total+number*24*3600, 0
+		case c == 'h':
+			total, number = total+number*3600, 0
+		case c == 'm':
+			total, number = total+number*60, 0
+		case c == 's':
+			total, number = total+number, 0
+		}
+	}
+	// A bare number is in seconds
+	total += number
+
+	if clock != "" {
+		multiplier := int64(1)
+		fields := strings.Split(clock, ":")
+		for i := len(fields) - 1; i >= 0; i-- {
+			seconds, _ := strconv.ParseFloat(fields[i], 64)
+			total += int64(seconds) * multiplier
+			multiplier *= 60
+		}
+	}
+	return total
+}
+
+// entryType returns the entry's record type
+func (e RouterOSEntry) entryType() string {
+	if e.Type == "" {
+		return "A"
+	}
+	return strings.ToUpper(e.Type)
+}
+
+// fromRouterOSEntry converts an entry to our internal format, returning false
+// for types dnsscale doesn't manage
+func fromRouterOSEntry(entry RouterOSEntry) (DNSRecord, bool) {
+	record := DNSRecord{
+		Name: strings.ToLower(entry.Name),
+		Type: entry.entryType(),
+		TTL:  parseRouterOSDuration(entry.TTL),
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+		record.Value = entry.Address
+	case "CNAME":
+		record.Value = strings.TrimSuffix(entry.CNAME, ".")
+	case "TXT":
+		record.Value = "\"" + entry.Text + "\""
+	case "SRV":
+		priority, errPriority := strconv.ParseUint(entry.SRVPriority, 10, 16)
+		weight, errWeight := strconv.ParseUint(entry.SRVWeight, 10, 16)
+		port, errPort := strconv.ParseUint(entry.SRVPort, 10, 16)
+		if errPriority != nil || errWeight != nil || errPort != nil {
+			return DNSRecord{}, false
+		}
+		record.Priority = uint16(priority)
+		record.Weight = uint16(weight)
+		record.Port = uint16(port)
+		record.Value = strings.TrimSuffix(entry.SRVTarget, ".")
+	default:
+		return DNSRecord{}, false
+	}
+
+	return record, true
+}
+
+// toRouterOSEntry converts a record to the body used to create an entry
+func toRouterOSEntry(record DNSRecord) (RouterOSEntry, error) {
+	entry := RouterOSEntry{
+		Name: strings.TrimSuffix(record.Name, "."),
+		Type: record.Type,
+		TTL:  fmt.Sprintf("%ds", record.TTL),
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+		entry.Address = record.Value
+	case "CNAME":
+		entry.CNAME = strings.TrimSuffix(record.Value, ".")
+	case "TXT":
+		entry.Text = strings.Trim(record.Value, "\"")
+	case "SRV":
+		entry.SRVPriority = strconv.Itoa(int(record.Priority))
+		entry.SRVWeight = strconv.Itoa(int(record.Weight))
+		entry.SRVPort = strconv.Itoa(int(record.Port))
+		entry.SRVTarget = strings.TrimSuffix(record.Value, ".")
+	default:
+		return RouterOSEntry{}, fmt.Errorf("unsupported record type for routeros: %s", record.Type)
+	}
+
+	return entry, nil
+}
+
+// inZone reports whether name is the zone or a name below it
+func (r *RouterOSProvider) inZone(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return name == r.zone || strings.HasSuffix(name, "."+r.zone)
+}
+
+// listEntries fetches the enabled entries matching query, which filters on
+// entry properties
+func (r *RouterOSProvider) listEntries(ctx context.Context, query url.Values) ([]RouterOSEntry, error) {
+	endpoint := ""
+	if len(query) > 0 {
+		endpoint = "?" + query.Encode()
+	}
+
+	var entries []RouterOSEntry
+	if err := r.makeRequest(ctx, "GET", endpoint, nil, &entries); err != nil {
+		return nil, err
+	}
+
+	// Entries without a name are regexp entries, which dnsscale doesn't manage
+	var enabled []RouterOSEntry
+	for _, entry := range entries {
+		if entry.Disabled != "true" && entry.Name != "" {
+			enabled = append(enabled, entry)
+		}
+	}
+	return enabled, nil
+}
+
+// findEntries returns the entries with the given name, and type if not empty
+func (r *RouterOSProvider) findEntries(ctx context.Context, name, recordType string) ([]RouterOSEntry, error) {
+	query := url.Values{}
+	query.Set("name", strings.TrimSuffix(name, "."))
+
+	entries, err := r.listEntries(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing entries: %w", err)
+	}
+
+	var matching []RouterOSEntry
+	for _, entry := range entries {
+		if !strings.EqualFold(entry.Name, strings.TrimSuffix(name, ".")) {
+			continue
+		}
+		if recordType == "" || entry.entryType() == recordType {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
+
+// ownerOf returns the owner recorded for name, first from names seen by this
+// process and then from the comment on existing entries
+func (r *RouterOSProvider) ownerOf(ctx context.Context, name string) (string, error) {
+	if owner, ok := r.owners.get(name); ok {
+		return owner, nil
+	}
+
+	entries, err := r.findEntries(ctx, name, "")
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if isOwnershipValue(entry.Comment) {
+			return entry.Comment, nil
+		}
+	}
+	return "", nil
+}
+
+// setOwner records owner for name and sets it as the comment of every entry
+// at the name. An empty owner clears the comments dnsscale set.
+func (r *RouterOSProvider) setOwner(ctx context.Context, name, owner string) error {
+	r.owners.set(name, owner)
+
+	entries, err := r.findEntries(ctx, name, "")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		switch {
+		case owner == "" && !isOwnershipValue(entry.Comment):
+			// Leave comments dnsscale didn't write alone
+			continue
+		case entry.Comment == owner:
+			continue
+		}
+
+		if err := r.makeRequest(ctx, "PATCH", "/"+entry.ID, map[string]string{"comment": owner}, nil); err != nil {
+			return fmt.Errorf("failed to update entry comment: %w", err)
+		}
+	}
+	return nil
+}
+
+// create adds a single entry, commented with the name's owner
+func (r *RouterOSProvider) create(ctx context.Context, record DNSRecord) error {
+	entry, err := toRouterOSEntry(record)
+	if err != nil {
+		return err
+	}
+
+	owner, err := r.ownerOf(ctx, record.Name)
+	if err != nil {
+		return err
+	}
+	entry.Comment = owner
+
+	return r.makeRequest(ctx, "PUT", "", entry, nil)
+}
+
+func (r *RouterOSProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	entries, err := r.listEntries(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	owners := make(map[string]DNSRecord)
+	for _, entry := range entries {
+		if !r.inZone(entry.Name) {
+			continue
+		}
+		record, ok := fromRouterOSEntry(entry)
+		if !ok {
+			continue
+		}
+		records = append(records, record)
+
+		// Present the owner comment as the TXT record dnsscale expects
+		if isOwnershipValue(entry.Comment) {
+			if _, seen := owners[record.Name]; !seen {
+				owners[record.Name] = DNSRecord{Name: record.Name, Type: "TXT", Value: "\"" + entry.Comment + "\"", TTL: record.TTL}
+			}
+		}
+	}
+
+	for _, owner := range owners {
+		records = append(records, owner)
+	}
+	return records, nil
+}
+
+func (r *RouterOSProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return r.setOwner(ctx, record.Name, strings.Trim(record.Value, "\""))
+	}
+
+	existing, err := r.findEntries(ctx, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+	if firstMatch(existing, record, fromRouterOSEntry) >= 0 {
+		return nil
+	}
+
+	return r.create(ctx, record)
+}
+
+func (r *RouterOSProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return r.setOwner(ctx, record.Name, strings.Trim(record.Value, "\""))
+	}
+
+	existing, err := r.findEntries(ctx, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) == 0 {
+		// Record doesn't exist, create it
+		return r.create(ctx, record)
+	}
+
+	// Keep the entry already holding the value, or else the first one, and
+	// remove any others, leaving a single value
+	keep := max(firstMatch(existing, record, fromRouterOSEntry), 0)
+
+	entry, err := toRouterOSEntry(record)
+	if err != nil {
+		return err
+	}
+	// The name and type can't change, so they aren't sent
+	entry.Name, entry.Type = "", ""
+	if err := r.makeRequest(ctx, "PATCH", "/"+existing[keep].ID, entry, nil); err != nil {
+		return err
+	}
+
+	for i, extra := range existing {
+		if i == keep {
+			continue
+		}
+		if err := r.makeRequest(ctx, "DELETE", "/"+extra.ID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RouterOSProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if record.Type == "TXT" && isOwnershipValue(record.Value) {
+		return r.setOwner(ctx, record.Name, "")
+	}
+
+	existing, err := r.findEntries(ctx, record.Name, record.Type)
+	if err != nil {
+		return err
+	}
+
+	i := firstMatch(existing, record, fromRouterOSEntry)
+	if i < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+	return r.makeRequest(ctx, "DELETE", "/"+existing[i].ID, nil, nil)
+}
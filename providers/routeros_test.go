@@ -0,0 +1,184 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRouterOS is a minimal stand-in for the RouterOS REST API serving
+// /ip/dns/static entries
+type fakeRouterOS struct {
+	mu      sync.Mutex
+	entries map[string]RouterOSEntry
+	nextID  int
+}
+
+func newFakeRouterOS(t *testing.T) (*fakeRouterOS, *httptest.Server) {
+	fake := &fakeRouterOS{entries: map[string]RouterOSEntry{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeRouterOS) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if user, pass, ok := req.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/rest/ip/dns/static")
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	if path == "" {
+		switch req.Method {
+		case "GET":
+			name := req.URL.Query().Get("name")
+			entries := []RouterOSEntry{}
+			for _, entry := range f.entries {
+				if name == "" || entry.Name == name {
+					entries = append(entries, entry)
+				}
+			}
+			json.NewEncoder(w).Encode(entries)
+		case "PUT":
+			var entry RouterOSEntry
+			if err := json.NewDecoder(req.Body).Decode(&entry); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.nextID++
+			entry.ID = fmt.Sprintf("*%X", f.nextID)
+			f.entries[entry.ID] = entry
+			json.NewEncoder(w).Encode(entry)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	id := strings.TrimPrefix(path, "/")
+	entry, exists := f.entries[id]
+	if !exists {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(RouterOSErrorResponse{Error: 404, Message: "Not Found"})
+		return
+	}
+
+	switch req.Method {
+	case "PATCH":
+		// Merge the given properties into the entry, where an empty value
+		// unsets the property
+		var fields map[string]string
+		if err := json.NewDecoder(req.Body).Decode(&fields); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		current := map[string]string{}
+		raw, _ := json.Marshal(entry)
+		json.Unmarshal(raw, &current)
+		for key, value := range fields {
+			current[key] = value
+		}
+		raw, _ = json.Marshal(current)
+		entry = RouterOSEntry{}
+		json.Unmarshal(raw, &entry)
+		f.entries[id] = entry
+		json.NewEncoder(w).Encode(entry)
+	case "DELETE":
+		delete(f.entries, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// snapshot returns every entry the fake holds
+func (f *fakeRouterOS) snapshot() []RouterOSEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var entries []RouterOSEntry
+	for _, entry := range f.entries {
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newTestRouterOSProvider(t *testing.T, server *httptest.Server) *RouterOSProvider {
+	t.Helper()
+
+	provider, err := NewRouterOSProvider(RouterOSConfig{
+		URL:      server.URL,
+		Username: "admin",
+		Password: "secret",
+		Zone:     "example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestRouterOSProvider(t *testing.T) {
+	_, server := newFakeRouterOS(t)
+	testProviderSemantics(t, newTestRouterOSProvider(t, server), "example.com")
+}
+
+func TestRouterOSOwnershipComment(t *testing.T) {
+	ctx := context.Background()
+	fake, server := newFakeRouterOS(t)
+	provider := newTestRouterOSProvider(t, server)
+
+	name := "web1.example.com"
+	owner := DNSRecord{Name: name, Type: "TXT", Value: "\"" + OwnershipPrefix + " node_id=n1\"", TTL: 300}
+	address := DNSRecord{Name: name, Type: "A", Value: "100.64.0.1", TTL: 300}
+
+	if err := provider.UpdateRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.CreateRecord(ctx, "example.com", address); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range fake.snapshot() {
+		if entry.Type == "TXT" {
+			t.Fatalf("ownership was stored as a TXT entry rather than a comment: %+v", entry)
+		}
+		if entry.Comment != OwnershipPrefix+" node_id=n1" {
+			t.Fatalf("entry isn't commented with its owner: %+v", entry)
+		}
+	}
+	expectValues(t, provider, "example.com", name, "TXT", OwnershipPrefix+" node_id=n1")
+
+	// A fresh provider finds the owner in the comment
+	provider = newTestRouterOSProvider(t, server)
+	if err := provider.CreateRecord(ctx, "example.com", DNSRecord{Name: name, Type: "A", Value: "100.64.0.2", TTL: 300}); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range fake.snapshot() {
+		if entry.Comment != OwnershipPrefix+" node_id=n1" {
+			t.Fatalf("new entry isn't commented with its owner: %+v", entry)
+		}
+	}
+
+	if err := provider.DeleteRecord(ctx, "example.com", owner); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", name, "TXT")
+	for _, entry := range fake.snapshot() {
+		if entry.Comment != "" {
+			t.Fatalf("owner comment left behind after deleting ownership: %+v", entry)
+		}
+	}
+}
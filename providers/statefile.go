@@ -0,0 +1,169 @@
+package providers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// txtStateFile keeps TXT records in a local JSON file for providers whose
+// backends can't hold them. dnsscale uses TXT records to track which names it
+// owns, so without them it would never clean up after itself.
+type txtStateFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// txtState is the on-disk format of a txtStateFile, mapping record names to
+// their TXT values
+type txtState struct {
+	TXT map[string][]string `json:"txt"`
+}
+
+func newTXTStateFile(path string) *txtStateFile {
+	return &txtStateFile{path: path}
+}
+
+// stateName normalizes a record name for use as a key
+func stateName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// stateValue normalizes a TXT value to the quoted form used by other providers
+func stateValue(value string) string {
+	return "\"" + strings.Trim(value, "\"") + "\""
+}
+
+func (s *txtStateFile) load() (*txtState, error) {
+	state := &txtState{TXT: map[string][]string{}}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", s.path, err)
+	}
+	if state.TXT == nil {
+		state.TXT = map[string][]string{}
+	}
+	return state, nil
+}
+
+// save atomically replaces the state file
+func (s *txtStateFile) save(state *txtState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), "."+filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// modify applies change to the values stored for record's name and saves the
+// result if they changed
+func (s *txtStateFile) modify(record DNSRecord, change func(values []string, value string) []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	name := stateName(record.Name)
+	before := state.TXT[name]
+	after := change(append([]string{}, before...), stateValue(record.Value))
+
+	if strings.Join(before, "\x00") == strings.Join(after, "\x00") {
+		return nil
+	}
+	if len(after) == 0 {
+		delete(state.TXT, name)
+	} else {
+		state.TXT[name] = after
+	}
+	return s.save(state)
+}
+
+// list returns every stored TXT record
+func (s *txtStateFile) list() ([]DNSRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(state.TXT))
+	for name := range state.TXT {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var records []DNSRecord
+	for _, name := range names {
+		for _, value := range state.TXT[name] {
+			records = append(records, DNSRecord{Name: name, Type: "TXT", Value: value})
+		}
+	}
+	return records, nil
+}
+
+// add stores a TXT value alongside any others for the name
+func (s *txtStateFile) add(record DNSRecord) error {
+	return s.modify(record, func(values []string, value string) []string {
+		for _, existing := range values {
+			if existing == value {
+				return values
+			}
+		}
+		return append(values, value)
+	})
+}
+
+// replace stores a TXT value in place of any others for the name
+func (s *txtStateFile) replace(record DNSRecord) error {
+	return s.modify(record, func(values []string, value string) []string {
+		return []string{value}
+	})
+}
+
+// remove drops a single TXT value for the name
+func (s *txtStateFile) remove(record DNSRecord) error {
+	return s.modify(record, func(values []string, value string) []string {
+		var kept []string
+		for _, existing := range values {
+			if existing != value {
+				kept = append(kept, existing)
+			}
+		}
+		return kept
+	})
+}
@@ -0,0 +1,337 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TechnitiumProvider implements DNSProvider for Technitium DNS Server using
+// its HTTP API. Records are managed in a primary zone hosted by the server.
+type TechnitiumProvider struct {
+	apiToken   string
+	zone       string
+	httpClient *http.Client
+	baseURL    string
+}
+
+// TechnitiumRecord represents a record in Technitium's API. The fields of
+// RData depend on the record type.
+type TechnitiumRecord struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	TTL      int64  `json:"ttl"`
+	Disabled bool   `json:"disabled"`
+	RData    struct {
+		IPAddress string `json:"ipAddress"`
+		Text      string `json:"text"`
+		CNAME     string `json:"cname"`
+		Priority  uint16 `json:"priority"`
+		Weight    uint16 `json:"weight"`
+		Port      uint16 `json:"port"`
+		Target    string `json:"target"`
+	} `json:"rData"`
+}
+
+// TechnitiumRecordsResponse represents the records returned by
+// /api/zones/records/get
+type TechnitiumRecordsResponse struct {
+	Records []TechnitiumRecord `json:"records"`
+}
+
+// TechnitiumResponse represents the envelope every Technitium API response is
+// wrapped in. Errors are reported in the envelope rather than the HTTP status.
+type TechnitiumResponse struct {
+	Status       string          `json:"status"`
+	ErrorMessage string          `json:"errorMessage"`
+	Response     json.RawMessage `json:"response"`
+}
+
+// NewTechnitiumProvider creates a provider for a zone on the Technitium DNS
+// Server at baseURL, e.g. http://127.0.0.1:5380
+func NewTechnitiumProvider(baseURL, apiToken, zone string) (*TechnitiumProvider, error) {
+	if baseURL == "" || apiToken == "" || zone == "" {
+		return nil, fmt.Errorf("URL, API token and zone are required")
+	}
+
+	return &TechnitiumProvider{
+		apiToken:   apiToken,
+		zone:       strings.TrimSuffix(zone, "."),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		baseURL:    strings.TrimSuffix(baseURL, "/") + "/api/zones/records",
+	}, nil
+}
+
+// makeRequest calls a records API endpoint with params and decodes the
+// response into out when it is non-nil. Parameters are sent as a form so the
+// token doesn't end up in request logs.
+func (t *TechnitiumProvider) makeRequest(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	reqURL := t.baseURL + endpoint
+
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("token", t.apiToken)
+	form.Set("zone", t.zone)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "dnsscale/1.0")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("technitium API request failed with status %d", resp.StatusCode)
+	}
+
+	var envelope TechnitiumResponse
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if envelope.Status != "ok" {
+		if envelope.ErrorMessage != "" {
+			return fmt.Errorf("technitium API error: %s (status: %s)", envelope.ErrorMessage, envelope.Status)
+		}
+		return fmt.Errorf("technitium API request failed with status %s", envelope.Status)
+	}
+
+	if out != nil && len(envelope.Response) > 0 {
+		if err := json.Unmarshal(envelope.Response, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// getRecords returns the records at domain, or every record in the zone when
+// listZone is set
+func (t *TechnitiumProvider) getRecords(ctx context.Context, domain string, listZone bool) ([]TechnitiumRecord, error) {
+	params := url.Values{}
+	params.Set("domain", strings.TrimSuffix(domain, "."))
+	params.Set("listZone", strconv.FormatBool(listZone))
+
+	var resp TechnitiumRecordsResponse
+	if err := t.makeRequest(ctx, "/get", params, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Records, nil
+}
+
+// fromTechnitiumRecord converts a Technitium record to our internal format,
+// returning false for types dnsscale doesn't manage
+func fromTechnitiumRecord(record TechnitiumRecord) (DNSRecord, bool) {
+	dnsRecord := DNSRecord{
+		Name: record.Name,
+		Type: record.Type,
+		TTL:  record.TTL,
+	}
+
+	switch record.Type {
+	case "A", "AAAA":
+		dnsRecord.Value = record.RData.IPAddress
+	case "CNAME":
+		dnsRecord.Value = strings.TrimSuffix(record.RData.CNAME, ".")
+	case "TXT":
+		dnsRecord.Value = "\"" + record.RData.Text + "\""
+	case "SRV":
+		dnsRecord.Priority = record.RData.Priority
+		dnsRecord.Weight = record.RData.Weight
+		dnsRecord.Port = record.RData.Port
+		dnsRecord.Value = strings.TrimSuffix(record.RData.Target, ".")
+	default:
+		return DNSRecord{}, false
+	}
+
+	return dnsRecord, true
+}
+
+// technitiumParams returns the API parameters identifying a record's name,
+// type and value
+func technitiumParams(record DNSRecord) (url.Values, error) {
+	params := url.Values{}
+	params.Set("domain", strings.TrimSuffix(record.Name, "."))
+	params.Set("type", record.Type)
+
+	switch record.Type {
+	case "A", "AAAA":
+		params.Set("ipAddress", record.Value)
+	case "CNAME":
+		params.Set("cname", strings.TrimSuffix(record.Value, "."))
+	case "TXT":
+		params.Set("text", strings.Trim(record.Value, "\""))
+	case "SRV":
+		params.Set("priority", strconv.Itoa(int(record.Priority)))
+		params.Set("weight", strconv.Itoa(int(record.Weight)))
+		params.Set("port", strconv.Itoa(int(record.Port)))
+		params.Set("target", strings.TrimSuffix(record.Value, "."))
+	default:
+		return nil, fmt.Errorf("unsupported record type for technitium: %s", record.Type)
+	}
+
+	return params, nil
+}
+
+// findRecords returns the records with the record's name and type
+func (t *TechnitiumProvider) findRecords(ctx context.Context, record DNSRecord) ([]TechnitiumRecord, error) {
+	records, err := t.getRecords(ctx, record.Name, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing records: %w", err)
+	}
+
+	var matching []TechnitiumRecord
+	for _, candidate := range records {
+		if candidate.Type == record.Type && strings.EqualFold(candidate.Name, strings.TrimSuffix(record.Name, ".")) {
+			matching = append(matching, candidate)
+		}
+	}
+	return matching, nil
+}
+
+// findRecord returns whether a record with the record's name, type and value
+// exists
+func (t *TechnitiumProvider) findRecord(ctx context.Context, record DNSRecord) (bool, error) {
+	existing, err := t.findRecords(ctx, record)
+	if err != nil {
+		return false, err
+	}
+	return firstMatch(existing, record, fromTechnitiumRecord) >= 0, nil
+}
+
+func (t *TechnitiumProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	records, err := t.getRecords(ctx, t.zone, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var dnsRecords []DNSRecord
+	for _, record := range records {
+		if record.Disabled {
+			continue
+		}
+		if dnsRecord, ok := fromTechnitiumRecord(record); ok {
+			dnsRecords = append(dnsRecords, dnsRecord)
+		}
+	}
+
+	return dnsRecords, nil
+}
+
+func (t *TechnitiumProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	params, err := technitiumParams(record)
+	if err != nil {
+		return err
+	}
+
+	found, err := t.findRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+
+	params.Set("ttl", strconv.FormatInt(record.TTL, 10))
+	return t.makeRequest(ctx, "/add", params, nil)
+}
+
+func (t *TechnitiumProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	existing, err := t.findRecords(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	// Keep the record already holding the value, if there is one, so the
+	// update doesn't create a duplicate
+	var current []DNSRecord
+	for _, candidate := range existing {
+		converted, ok := fromTechnitiumRecord(candidate)
+		if !ok {
+			continue
+		}
+		if sameValue(converted, record) {
+			current = append([]DNSRecord{converted}, current...)
+		} else {
+			current = append(current, converted)
+		}
+	}
+
+	if len(current) == 0 {
+		// Record doesn't exist, create it
+		return t.CreateRecord(ctx, zone, record)
+	}
+	if len(current) == 1 && current[0].TTL == record.TTL && sameValue(current[0], record) {
+		// Already the only value
+		return nil
+	}
+
+	// Update the first record to the new value and TTL and remove any others,
+	// leaving a single value
+	params, err := technitiumParams(current[0])
+	if err != nil {
+		return err
+	}
+	newParams, err := technitiumParams(record)
+	if err != nil {
+		return err
+	}
+	if record.Type == "CNAME" {
+		// A name holds a single CNAME, which is replaced in place
+		params = newParams
+	} else {
+		for key, values := range newParams {
+			if key == "domain" || key == "type" {
+				continue
+			}
+			params["new"+strings.ToUpper(key[:1])+key[1:]] = values
+		}
+	}
+	params.Set("ttl", strconv.FormatInt(record.TTL, 10))
+	if err := t.makeRequest(ctx, "/update", params, nil); err != nil {
+		return err
+	}
+
+	for _, extra := range current[1:] {
+		if err := t.DeleteRecord(ctx, zone, extra); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *TechnitiumProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	if _, err := technitiumParams(record); err != nil {
+		return err
+	}
+
+	existing, err := t.findRecords(ctx, record)
+	if err != nil {
+		return err
+	}
+	i := firstMatch(existing, record, fromTechnitiumRecord)
+	if i < 0 {
+		// Record doesn't exist, nothing to delete
+		return nil
+	}
+
+	// Identify the record by the value as the server holds it
+	current, _ := fromTechnitiumRecord(existing[i])
+	params, err := technitiumParams(current)
+	if err != nil {
+		return err
+	}
+	return t.makeRequest(ctx, "/delete", params, nil)
+}
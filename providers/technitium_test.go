@@ -0,0 +1,190 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTechnitium is a minimal stand-in for the Technitium records API serving
+// one zone. Like the real server it parses addresses, so any spelling of an
+// address identifies the record.
+type fakeTechnitium struct {
+	mu      sync.Mutex
+	records []TechnitiumRecord
+	writes  int
+}
+
+func newFakeTechnitium(t *testing.T) (*fakeTechnitium, *TechnitiumProvider) {
+	t.Helper()
+
+	fake := &fakeTechnitium{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	provider, err := NewTechnitiumProvider(server.URL, "token", "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, provider
+}
+
+// technitiumRecordFromForm builds the record described by form, reading the
+// value from the fields starting with prefix
+func technitiumRecordFromForm(form url.Values, prefix string) TechnitiumRecord {
+	field := func(key string) string {
+		if prefix != "" {
+			key = prefix + strings.ToUpper(key[:1]) + key[1:]
+		}
+		return form.Get(key)
+	}
+	number := func(key string) uint16 {
+		n, _ := strconv.ParseUint(field(key), 10, 16)
+		return uint16(n)
+	}
+
+	record := TechnitiumRecord{Name: form.Get("domain"), Type: form.Get("type")}
+	record.TTL, _ = strconv.ParseInt(form.Get("ttl"), 10, 64)
+	record.RData.IPAddress = field("ipAddress")
+	record.RData.Text = field("text")
+	record.RData.CNAME = field("cname")
+	record.RData.Priority = number("priority")
+	record.RData.Weight = number("weight")
+	record.RData.Port = number("port")
+	record.RData.Target = field("target")
+	if addr, err := netip.ParseAddr(record.RData.IPAddress); err == nil {
+		record.RData.IPAddress = addr.String()
+	}
+	return record
+}
+
+// index returns the position of the record with want's name, type and value
+func (f *fakeTechnitium) index(want TechnitiumRecord) int {
+	for i, record := range f.records {
+		if record.Name == want.Name && record.Type == want.Type && record.RData == want.RData {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *fakeTechnitium) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := req.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reply := func(response interface{}, errorMessage string) {
+		envelope := TechnitiumResponse{Status: "ok"}
+		if errorMessage != "" {
+			envelope = TechnitiumResponse{Status: "error", ErrorMessage: errorMessage}
+		}
+		if response != nil {
+			envelope.Response, _ = json.Marshal(response)
+		}
+		json.NewEncoder(w).Encode(envelope)
+	}
+
+	if req.PostForm.Get("token") != "token" {
+		json.NewEncoder(w).Encode(TechnitiumResponse{Status: "invalid-token", ErrorMessage: "Invalid token"})
+		return
+	}
+	if req.PostForm.Get("zone") != "example.com" {
+		reply(nil, "No such zone was found")
+		return
+	}
+
+	form := req.PostForm
+	switch req.URL.Path {
+	case "/api/zones/records/get":
+		resp := TechnitiumRecordsResponse{Records: []TechnitiumRecord{}}
+		for _, record := range f.records {
+			if form.Get("listZone") == "true" || record.Name == form.Get("domain") {
+				resp.Records = append(resp.Records, record)
+			}
+		}
+		reply(resp, "")
+	case "/api/zones/records/add":
+		record := technitiumRecordFromForm(form, "")
+		if f.index(record) >= 0 {
+			reply(nil, "Cannot add record: record already exists")
+			return
+		}
+		f.writes++
+		f.records = append(f.records, record)
+		reply(nil, "")
+	case "/api/zones/records/update":
+		i := f.index(technitiumRecordFromForm(form, ""))
+		if i < 0 {
+			reply(nil, "Cannot update record: record does not exist")
+			return
+		}
+		updated := technitiumRecordFromForm(form, "new")
+		if updated.Type == "CNAME" {
+			updated = technitiumRecordFromForm(form, "")
+		}
+		f.writes++
+		f.records[i] = updated
+		reply(nil, "")
+	case "/api/zones/records/delete":
+		i := f.index(technitiumRecordFromForm(form, ""))
+		if i < 0 {
+			reply(nil, "Cannot delete record: record does not exist")
+			return
+		}
+		f.writes++
+		f.records = append(f.records[:i], f.records[i+1:]...)
+		reply(nil, "")
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+// writeCount returns how many changes the fake has applied
+func (f *fakeTechnitium) writeCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writes
+}
+
+func TestTechnitiumProvider(t *testing.T) {
+	_, provider := newFakeTechnitium(t)
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestTechnitiumUpdateUnchanged(t *testing.T) {
+	ctx := context.Background()
+	fake, provider := newFakeTechnitium(t)
+
+	record := DNSRecord{Name: "web1.example.com", Type: "AAAA", Value: "fd7a:115c:a1e0::1", TTL: 300}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	writes := fake.writeCount()
+
+	// The same address spelled out is the same value
+	record.Value = "fd7a:115c:a1e0:0:0:0:0:1"
+	if err := provider.CreateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.writeCount(); got != writes {
+		t.Fatalf("writing the current value made %d writes, want none", got-writes)
+	}
+
+	if err := provider.DeleteRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	expectValues(t, provider, "example.com", record.Name, "AAAA")
+}
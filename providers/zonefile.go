@@ -0,0 +1,346 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// ZoneFileConfig holds the settings needed to create a ZoneFileProvider
+type ZoneFileConfig struct {
+	Path string
+	Zone string
+
+	// Fragment writes records only, without SOA and NS records, for use with
+	// $INCLUDE from a zone file maintained elsewhere
+	Fragment bool
+
+	// Used to create the SOA and NS records of a new standalone zone file.
+	// Records already in the file are kept.
+	PrimaryNS   string   // Defaults to ns1.<zone>
+	Hostmaster  string   // Defaults to hostmaster.<zone>
+	Nameservers []string // Defaults to PrimaryNS
+
+	// Run through sh -c after every change, e.g. "rndc reload example.com"
+	ReloadCommand string
+	ReloadTimeout time.Duration
+}
+
+// ZoneFileProvider implements DNSProvider by rendering records into a zone
+// file on disk, for sites where no DNS API is reachable
+type ZoneFileProvider struct {
+	path          string
+	zone          string
+	fragment      bool
+	primaryNS     string
+	hostmaster    string
+	nameservers   []string
+	reloadCommand string
+	reloadTimeout time.Duration
+
+	mu sync.Mutex
+}
+
+func NewZoneFileProvider(cfg ZoneFileConfig) (*ZoneFileProvider, error) {
+	if cfg.Path == "" || cfg.Zone == "" {
+		return nil, fmt.Errorf("path and zone are required")
+	}
+
+	zone := dns.Fqdn(cfg.Zone)
+	provider := &ZoneFileProvider{
+		path:          cfg.Path,
+		zone:          zone,
+		fragment:      cfg.Fragment,
+		primaryNS:     cfg.PrimaryNS,
+		hostmaster:    cfg.Hostmaster,
+		reloadCommand: cfg.ReloadCommand,
+		reloadTimeout: cfg.ReloadTimeout,
+	}
+
+	if provider.primaryNS == "" {
+		provider.primaryNS = "ns1." + zone
+	}
+	if provider.hostmaster == "" {
+		provider.hostmaster = "hostmaster." + zone
+	}
+	provider.primaryNS = dns.Fqdn(provider.primaryNS)
+	provider.hostmaster = dns.Fqdn(provider.hostmaster)
+
+	for _, ns := range cfg.Nameservers {
+		provider.nameservers = append(provider.nameservers, dns.Fqdn(ns))
+	}
+	if len(provider.nameservers) == 0 {
+		provider.nameservers = []string{provider.primaryNS}
+	}
+
+	if provider.reloadTimeout <= 0 {
+		provider.reloadTimeout = 30 * time.Second
+	}
+
+	return provider, nil
+}
+
+// readZone parses every resource record in the zone file. A missing file is
+// treated as an empty zone.
+func (z *ZoneFileProvider) readZone() ([]dns.RR, error) {
+	data, err := os.ReadFile(z.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read zone file: %w", err)
+	}
+
+	parser := dns.NewZoneParser(bytes.NewReader(data), z.zone, z.path)
+	parser.SetIncludeAllowed(false)
+
+	var rrs []dns.RR
+	for rr, ok := parser.Next(); ok; rr, ok = parser.Next() {
+		rrs = append(rrs, rr)
+	}
+	if err := parser.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse zone file: %w", err)
+	}
+	return rrs, nil
+}
+
+// nextSerial returns a date based serial (YYYYMMDDnn) greater than current
+func nextSerial(current uint32, now time.Time) uint32 {
+	dated, _ := strconv.ParseUint(now.UTC().Format("20060102")+"00", 10, 32)
+	if uint32(dated) > current {
+		return uint32(dated)
+	}
+	return current + 1
+}
+
+// withSOA bumps the serial of the zone's SOA record, creating the SOA and NS
+// records first if the file doesn't have them yet
+func (z *ZoneFileProvider) withSOA(rrs []dns.RR) []dns.RR {
+	for _, rr := range rrs {
+		if soa, ok := rr.(*dns.SOA); ok && strings.EqualFold(soa.Hdr.Name, z.zone) {
+			soa.Serial = nextSerial(soa.Serial, time.Now())
+			return rrs
+		}
+	}
+
+	soa := &dns.SOA{
+		Hdr:     dns.RR_Header{Name: z.zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 3600},
+		Ns:      z.primaryNS,
+		Mbox:    z.hostmaster,
+		Serial:  nextSerial(0, time.Now()),
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  604800,
+		Minttl:  300,
+	}
+	head := []dns.RR{soa}
+	for _, ns := range z.nameservers {
+		head = append(head, &dns.NS{
+			Hdr: dns.RR_Header{Name: z.zone, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: 3600},
+			Ns:  ns,
+		})
+	}
+	return append(head, rrs...)
+}
+
+// render formats the records as a zone file, with the SOA first and the
+// remaining records ordered by name and type so that diffs stay readable
+func (z *ZoneFileProvider) render(rrs []dns.RR) []byte {
+	sort.SliceStable(rrs, func(i, j int) bool {
+		a, b := rrs[i].Header(), rrs[j].Header()
+		if (a.Rrtype == dns.TypeSOA) != (b.Rrtype == dns.TypeSOA) {
+			return a.Rrtype == dns.TypeSOA
+		}
+		if !strings.EqualFold(a.Name, b.Name) {
+			// The apex sorts first
+			if strings.EqualFold(a.Name, z.zone) || strings.EqualFold(b.Name, z.zone) {
+				return strings.EqualFold(a.Name, z.zone)
+			}
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}
+		return a.Rrtype < b.Rrtype
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "; Managed by dnsscale, changes will be overwritten\n")
+	if !z.fragment {
+		fmt.Fprintf(&buf, "$ORIGIN %s\n", z.zone)
+	}
+	for _, rr := range rrs {
+		buf.WriteString(rr.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// writeZone atomically replaces the zone file, then runs the reload command
+func (z *ZoneFileProvider) writeZone(ctx context.Context, rrs []dns.RR) error {
+	if !z.fragment {
+		rrs = z.withSOA(rrs)
+	}
+
+	dir := filepath.Dir(z.path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(z.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary zone file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(z.render(rrs)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write zone file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write zone file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write zone file: %w", err)
+	}
+
+	// Keep the permissions of the file being replaced so the name server can
+	// still read it
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(z.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to set zone file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), z.path); err != nil {
+		return fmt.Errorf("failed to replace zone file: %w", err)
+	}
+
+	return z.reload(ctx)
+}
+
+// reload runs the configured reload command, if any
+func (z *ZoneFileProvider) reload(ctx context.Context) error {
+	if z.reloadCommand == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, z.reloadTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, "sh", "-c", z.reloadCommand).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("reload command failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// modify applies change to the records in the zone file and writes the result
+// back if change reports that anything changed
+func (z *ZoneFileProvider) modify(ctx context.Context, change func([]dns.RR) ([]dns.RR, bool)) error {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	rrs, err := z.readZone()
+	if err != nil {
+		return err
+	}
+
+	rrs, changed := change(rrs)
+	if !changed {
+		return nil
+	}
+	return z.writeZone(ctx, rrs)
+}
+
+func (z *ZoneFileProvider) ListRecords(ctx context.Context, zone string) ([]DNSRecord, error) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	rrs, err := z.readZone()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []DNSRecord
+	for _, rr := range rrs {
+		if record, ok := fromRR(rr); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (z *ZoneFileProvider) CreateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	return z.modify(ctx, func(rrs []dns.RR) ([]dns.RR, bool) {
+		for _, existing := range rrs {
+			if dns.IsDuplicate(existing, rr) {
+				return rrs, false
+			}
+		}
+		return append(rrs, rr), true
+	})
+}
+
+func (z *ZoneFileProvider) UpdateRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	return z.modify(ctx, func(rrs []dns.RR) ([]dns.RR, bool) {
+		var current []dns.RR
+		for _, existing := range rrs {
+			header := existing.Header()
+			if header.Rrtype == rr.Header().Rrtype && strings.EqualFold(header.Name, rr.Header().Name) {
+				current = append(current, existing)
+			}
+		}
+
+		// Leave the file and its serial alone when nothing would change
+		if len(current) == 1 && dns.IsDuplicate(current[0], rr) && current[0].Header().Ttl == rr.Header().Ttl {
+			return rrs, false
+		}
+
+		// Drop every value for the name and type, then add the new one
+		kept := rrs[:0]
+		for _, existing := range rrs {
+			header := existing.Header()
+			if header.Rrtype == rr.Header().Rrtype && strings.EqualFold(header.Name, rr.Header().Name) {
+				continue
+			}
+			kept = append(kept, existing)
+		}
+		return append(kept, rr), true
+	})
+}
+
+func (z *ZoneFileProvider) DeleteRecord(ctx context.Context, zone string, record DNSRecord) error {
+	rr, err := toRR(record)
+	if err != nil {
+		return err
+	}
+
+	return z.modify(ctx, func(rrs []dns.RR) ([]dns.RR, bool) {
+		kept := rrs[:0]
+		for _, existing := range rrs {
+			if !dns.IsDuplicate(existing, rr) {
+				kept = append(kept, existing)
+			}
+		}
+		return kept, len(kept) != len(rrs)
+	})
+}
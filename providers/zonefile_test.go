@@ -0,0 +1,64 @@
+package providers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestZoneFileProvider(t *testing.T) {
+	provider, err := NewZoneFileProvider(ZoneFileConfig{
+		Path: filepath.Join(t.TempDir(), "example.com.zone"),
+		Zone: "example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testProviderSemantics(t, provider, "example.com")
+}
+
+func TestZoneFileUpdateUnchanged(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "example.com.zone")
+	provider, err := NewZoneFileProvider(ZoneFileConfig{Path: path, Zone: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	record := DNSRecord{Name: "web1.example.com", Type: "A", Value: "100.64.0.1", TTL: 300}
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatalf("updating to the current value rewrote the zone file:\n%s\nbecame\n%s", before, after)
+	}
+
+	// A different TTL is a change
+	record.TTL = 600
+	if err := provider.UpdateRecord(ctx, "example.com", record); err != nil {
+		t.Fatal(err)
+	}
+	records, err := provider.ListRecords(ctx, "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if r.Type == "A" && r.TTL != 600 {
+			t.Fatalf("TTL = %d after update, want 600", r.TTL)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jaxxstorm/dnsscale/providers"
+	"go.uber.org/zap"
+)
+
+// serviceKeyPrefix marks queue items that refer to an SRV service record
+const serviceKeyPrefix = "service:"
+
+// staleServicesKey queues the removal of records left behind by services that
+// are no longer configured
+const staleServicesKey = "cleanup:services"
+
+// serviceLabel returns the "_service._proto" owner name of a service's SRV
+// record, relative to the managed domain
+func serviceLabel(service ServiceConfig) string {
+	protocol := service.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("_%s._%s", strings.TrimPrefix(service.Service, "_"), strings.TrimPrefix(protocol, "_"))
+}
+
+// serviceOwnershipValue returns the TXT value marking a service's SRV record
+// as managed by dnsscale
+func serviceOwnershipValue(label string) string {
+	return fmt.Sprintf("\"%s service=%s\"", providers.OwnershipPrefix, label)
+}
+
+// serviceTagsMatch reports whether a node has any of a service's tags. A
+// service without tags matches every node.
+func serviceTagsMatch(service ServiceConfig, node TailscaleNode) bool {
+	if len(service.Tags) == 0 {
+		return true
+	}
+	for _, tag := range service.Tags {
+		if nodeHasTag(node, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceMatchesNode reports whether a node advertises a service. A node
+// matches when it has any of the service's tags and all of its attributes.
+func serviceMatchesNode(service ServiceConfig, node TailscaleNode) bool {
+	if !serviceTagsMatch(service, node) {
+		return false
+	}
+
+	// Attribute keys are matched case-insensitively since configuration keys
+	// are lowercased when loaded
+	for key, want := range service.Attributes {
+		matched := false
+		for attr, value := range node.Attributes {
+			if strings.EqualFold(attr, key) && value == want {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// servicesUseAttributes reports whether any service matches on posture
+// attributes, which have to be fetched separately for every device
+func servicesUseAttributes(services []ServiceConfig) bool {
+	for _, service := range services {
+		if len(service.Attributes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// needsAttributes reports whether a node's posture attributes are needed,
+// which is only the case for managed nodes with the tags of a service that
+// matches on attributes. This keeps the number of attribute API calls per poll
+// down.
+func (r *DNSReconciler) needsAttributes(node TailscaleNode) bool {
+	if !r.shouldManageNode(node) {
+		return false
+	}
+	for _, service := range r.services {
+		if len(service.Attributes) > 0 && serviceTagsMatch(service, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeStaleServices deletes SRV records whose service is no longer
+// configured
+func (r *DNSReconciler) removeStaleServices(ctx context.Context) error {
+	configured := make(map[string]bool)
+	for _, service := range r.services {
+		configured[strings.Trim(serviceOwnershipValue(serviceLabel(service)), "\"")] = true
+	}
+	return r.removeStaleOwners(ctx, "service=", configured)
+}
+
+// queueServices queues every configured service so its targets are recomputed
+func (r *DNSReconciler) queueServices() {
+	for _, service := range r.services {
+		r.queue.Add(serviceKeyPrefix + serviceLabel(service))
+	}
+}
+
+// reconcileService publishes the SRV record for a service, with one target
+// per matching node
+func (r *DNSReconciler) reconcileService(ctx context.Context, label string) error {
+	var service ServiceConfig
+	var found bool
+	for _, s := range r.services {
+		if serviceLabel(s) == label {
+			service = s
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("service %s is not configured", label)
+	}
+
+	recordName := fmt.Sprintf("%s.%s", label, r.domain)
+
+	var desired []providers.DNSRecord
+	var targets []string
+
+	r.cacheMutex.RLock()
+	for _, node := range r.nodeCache {
+		if !r.shouldManageNode(node) || !serviceMatchesNode(service, node) {
+			continue
+		}
+		if service.OnlineOnly && !node.Online {
+			continue
+		}
+		if !r.health.healthy(node, false) {
+			continue
+		}
+
+		target := fmt.Sprintf("%s.%s", node.Name, r.domain)
+		targets = append(targets, target)
+		desired = append(desired, providers.DNSRecord{
+			Name:     recordName,
+			Type:     "SRV",
+			Value:    target,
+			TTL:      defaultRecordTTL,
+			Priority: service.Priority,
+			Weight:   service.Weight,
+			Port:     service.Port,
+		})
+	}
+	r.cacheMutex.RUnlock()
+	sort.Strings(targets)
+
+	if err := r.syncOwnedRecords(ctx, recordName, serviceOwnershipValue(label), []string{"SRV"}, desired); err != nil {
+		return err
+	}
+
+	r.logger.Info("Reconciled service record",
+		zap.String("record_name", recordName),
+		zap.Uint16("port", service.Port),
+		zap.Strings("targets", targets))
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// nodeStatus describes a node as seen by the reconciler
+type nodeStatus struct {
+	ID       string        `json:"id"`
+	Name     string        `json:"name"`
+	Online   bool          `json:"online"`
+	Managed  bool          `json:"managed"`
+	Healthy  bool          `json:"healthy"`
+	LastSeen time.Time     `json:"last_seen"`
+	Probes   []probeResult `json:"probes,omitempty"`
+}
+
+// statusResponse is returned by the status endpoint
+type statusResponse struct {
+	Domain string       `json:"domain"`
+	Nodes  []nodeStatus `json:"nodes"`
+}
+
+// status returns a snapshot of every cached node and its health
+func (r *DNSReconciler) status() statusResponse {
+	r.cacheMutex.RLock()
+	nodes := make([]TailscaleNode, 0, len(r.nodeCache))
+	for _, node := range r.nodeCache {
+		nodes = append(nodes, node)
+	}
+	r.cacheMutex.RUnlock()
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	resp := statusResponse{
+		Domain: r.domain,
+		Nodes:  make([]nodeStatus, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		resp.Nodes = append(resp.Nodes, nodeStatus{
+			ID:       node.ID,
+			Name:     node.Name,
+			Online:   node.Online,
+			Managed:  r.shouldManageNode(node),
+			Healthy:  r.health.healthy(node, false),
+			LastSeen: node.LastSeen,
+			Probes:   r.health.nodeResults(node.ID),
+		})
+	}
+	return resp
+}
+
+// serveStatus serves the reconciler's status as JSON on addr until ctx is done
+func (r *DNSReconciler) serveStatus(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(r.status()); err != nil {
+			r.logger.Warn("Failed to write status response", zap.Error(err))
+		}
+	})
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	r.logger.Info("Starting status server", zap.String("address", addr))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		r.logger.Error("Status server failed", zap.Error(err))
+	}
+}